@@ -1,3 +1,3 @@
 wrk.method = "GET"
 wrk.headers["Accept"] = "application/json"
-wrk.path = "/api/v1/post/search2?keyword=es&orderby=correlation&page=1&size=20"
\ No newline at end of file
+wrk.path = "/api/v1/post/search?keyword=es&orderby=correlation&page=1&size=20"
\ No newline at end of file
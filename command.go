@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bluebell/dao/search"
+	"bluebell/logic"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// 打印的 id 数量上限，避免输出过多
+const maxPrintIDs = 100
+
+const commandUsage = `usage: bluebell [-c config] <command> [options]
+
+commands:
+  reindex   将 mysql 中的帖子、用户或社区重新写入搜索引擎
+  verify    校验搜索引擎中的索引，报告缺失和多余的帖子
+`
+
+// runCommand 运行子命令，返回进程退出码
+func runCommand(args []string) int {
+	switch args[0] {
+	case "reindex":
+		return runReindex(args[1:])
+	case "verify":
+		return runVerify(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, commandUsage)
+		return 2
+	}
+}
+
+func runReindex(args []string) int {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	engine := fs.String("engine", search.EngineAll, "target engine: bleve, elasticsearch or all")
+	typ := fs.String("type", "post", "document type: post, user or community")
+	batch := fs.Int("batch", 500, "number of posts or users per batch")
+	resume := fs.Bool("resume", false, "resume from the last interrupted reindex (post only)")
+	fs.Parse(args)
+	if *batch <= 0 {
+		fmt.Fprintln(os.Stderr, "reindex: batch must be greater than 0")
+		return 2
+	}
+
+	progress := func(done, total int64) {
+		percent := 100.0
+		if total > 0 {
+			percent = float64(done) * 100 / float64(total)
+		}
+		fmt.Printf("\r[reindex] %v %v: %d/%d (%.1f%%)", *engine, *typ, done, total, percent)
+	}
+	var err error
+	switch *typ {
+	case "post":
+		err = logic.ReindexPosts(*engine, *batch, *resume, progress)
+	case "user":
+		err = logic.ReindexUsers(*engine, *batch, progress)
+	case "community":
+		err = logic.ReindexCommunities(*engine, progress)
+	default:
+		fmt.Fprintln(os.Stderr, "reindex: type must be post, user or community")
+		return 2
+	}
+	fmt.Println()
+	if err != nil {
+		if *typ == "post" {
+			fmt.Fprintf(os.Stderr, "reindex failed: %+v\nrerun with --resume to continue\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "reindex failed: %+v\n", err)
+		}
+		return 1
+	}
+	fmt.Println("reindex completed")
+	return 0
+}
+
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	engine := fs.String("engine", search.EngineAll, "engine to verify: bleve, elasticsearch or all")
+	batch := fs.Int("batch", 500, "number of posts per batch")
+	fs.Parse(args)
+	if *batch <= 0 {
+		fmt.Fprintln(os.Stderr, "verify: batch must be greater than 0")
+		return 2
+	}
+
+	reports, err := logic.VerifyPostIndex(*engine, *batch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify failed: %+v\n", err)
+		return 1
+	}
+
+	consistent := true
+	for _, report := range reports {
+		fmt.Printf("[verify] %v: checked %d posts, %d missing, %d orphaned\n",
+			report.Engine, report.Checked, len(report.Missing), len(report.Orphaned))
+		if len(report.Missing) > 0 {
+			consistent = false
+			fmt.Printf("  missing: %v\n", truncateIDs(report.Missing))
+		}
+		if len(report.Orphaned) > 0 {
+			consistent = false
+			fmt.Printf("  orphaned: %v\n", truncateIDs(report.Orphaned))
+		}
+	}
+	if !consistent {
+		return 1
+	}
+	return 0
+}
+
+func truncateIDs[T any](ids []T) string {
+	if len(ids) <= maxPrintIDs {
+		return fmt.Sprint(ids)
+	}
+	return fmt.Sprintf("%v ... (%d more)", ids[:maxPrintIDs], len(ids)-maxPrintIDs)
+}
@@ -29,6 +29,17 @@ const (
 	CodeTimeOut
 
 	CodeInvalidVerificationCode
+
+	CodeAccountLocked
+
+	CodeTwoFactorEnabled
+	CodeTwoFactorNotEnabled
+
+	CodeOAuthFailed
+
+	CodeBlocked
+
+	CodeKarmaNotEnough
 )
 
 var codeMsgMap = map[Code]string{
@@ -58,6 +69,17 @@ var codeMsgMap = map[Code]string{
 	CodeTimeOut: "请求超时",
 
 	CodeInvalidVerificationCode: "无效验证码",
+
+	CodeAccountLocked: "登录失败次数过多，账户已被锁定，请稍后再试",
+
+	CodeTwoFactorEnabled:    "已开启两步验证",
+	CodeTwoFactorNotEnabled: "未开启两步验证",
+
+	CodeOAuthFailed: "第三方登录失败",
+
+	CodeBlocked: "对方已将你拉黑",
+
+	CodeKarmaNotEnough: "karma 不足，暂时无法进行该操作",
 }
 
 func (c Code) getMsg() string {
@@ -18,6 +18,30 @@ type ResponseUserLogin struct {
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
+// 开启了两步验证的用户，登录第一步返回该结构
+type ResponseTwoFactorChallenge struct {
+	NeedTwoFactor  bool   `json:"need_two_factor"`
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type ResponseTwoFactorEnroll struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth URI，可以生成二维码
+}
+
+type ResponseTwoFactorConfirm struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ResponseOAuthURL struct {
+	AuthURL string `json:"auth_url"`
+}
+
+// 第三方重新授权成功后返回，注销账户时代替密码
+type ResponseOAuthReauth struct {
+	ReauthToken string `json:"reauth_token"`
+}
+
 type ResponseUserInfo struct {
 	UserName string           `json:"user_name"`
 	Avatar   string           `json:"avatar"`
@@ -43,13 +67,20 @@ type ResponsePostDetail struct {
 		CreatedAt     models.Time `json:"created_at"`
 	} `json:"community_info"`
 	PostInfo struct {
-		PostID    int64       `json:"post_id,string"`
-		Title     string      `json:"title"`
-		Content   string      `json:"content"`
-		CreatedAt models.Time `json:"created_at"`
-		UpdatedAt models.Time `json:"updated_at"`
-		VoteNum   int64       `json:"vote_num"`
+		PostID       int64       `json:"post_id,string"`
+		Title        string      `json:"title"`
+		Content      string      `json:"content"`
+		CreatedAt    models.Time `json:"created_at"`
+		UpdatedAt    models.Time `json:"updated_at"`
+		VoteNum      int64       `json:"vote_num"`
+		IsBookmarked bool        `json:"is_bookmarked"`
+		RepostCount  int64       `json:"repost_count"`
 	} `json:"post_info"`
+	RepostInfo *struct {
+		OriginalPostID  int64           `json:"original_post_id,string"`
+		Original        *models.PostDTO `json:"original"`         // 原帖，原帖被删除时为空
+		OriginalRemoved bool            `json:"original_removed"` // 原帖是否已被删除
+	} `json:"repost_info,omitempty"` // 不是转发时为空
 }
 
 type ResponseQiniuCallback struct {
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// UserExportHandler 用户数据导出接口
+//
+//	@Summary		用户数据导出接口
+//	@Description	导出当前用户的个人信息、帖子、评论、点赞/点踩记录，format 为 zip 时返回 zip 压缩包
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string	false	"Bearer 用户令牌"
+//	@Param			format			query	string	false	"导出格式（json、zip），默认 json"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.UserExport}
+//	@Router			/user/export [get]
+func UserExportHandler(ctx *gin.Context) {
+	value, exists := ctx.Get("user_id")
+	if !exists {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.Errorf("controller.UserExportHandler: get user_id from context failed")
+		return
+	}
+	userID := value.(int64)
+
+	params := models.ParamUserExport{}
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if params.Format == "zip" {
+		data, err := logic.ExportUserDataZip(userID)
+		if err != nil {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+			return
+		}
+		filename := fmt.Sprintf("bluebell_export_%d_%s.zip", userID, time.Now().Format("20060102150405"))
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		ctx.Data(http.StatusOK, "application/zip", data)
+		return
+	}
+
+	data, err := logic.ExportUserData(userID)
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	common.ResponseSuccess(ctx, data)
+}
+
+// UserDeleteHandler 账户注销接口
+//
+//	@Summary		账户注销接口
+//	@Description	注销当前账户。mode 为 anonymize（默认）时保留帖子、评论，只抹去个人信息；为 remove 时同时删除帖子、评论
+//	@Description	需要重新验证身份：开启了两步验证时提供 code（TOTP code 或恢复码），否则提供 password；
+//	@Description	第三方登录的用户也可以提供 reauth_token（通过 /user/oauth/{provider}/reauth 重新授权得到）
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			mode			query	string					false	"注销方式（anonymize、remove）"
+//	@Param			object			body	models.ParamUserDelete	true	"密码、两步验证 code 或 reauth_token"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user [delete]
+func UserDeleteHandler(ctx *gin.Context) {
+	value, exists := ctx.Get("user_id")
+	if !exists {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.Errorf("controller.UserDeleteHandler: get user_id from context failed")
+		return
+	}
+	userID := value.(int64)
+
+	params := models.ParamUserDelete{}
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if err := logic.DeleteAccount(userID, getRoleInfo(ctx), &params); err != nil {
+		if errors.Is(err, bluebell.ErrAccountLocked) {
+			common.ResponseError(ctx, common.CodeAccountLocked)
+		} else if errors.Is(err, bluebell.ErrWrongPassword) {
+			common.ResponseError(ctx, common.CodeWrongPassword)
+		} else if errors.Is(err, bluebell.ErrInvalidVerificationCode) {
+			common.ResponseError(ctx, common.CodeInvalidVerificationCode)
+		} else if errors.Is(err, bluebell.ErrInvalidToken) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidToken, "重新授权已过期，请重新授权")
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	bluebell "bluebell/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// 获取 Auth 中间件解析出的角色信息
+func getRoleInfo(ctx *gin.Context) models.RoleInfo {
+	value, _ := ctx.Get("role")
+	role, _ := value.(models.RoleInfo)
+	return role
+}
+
+// AdminGrantRoleHandler 授予角色接口（admin only）
+//
+//	@Summary		授予角色接口（admin only）
+//	@Description	将用户设置为管理员，或某个社区的版主；用户需要重新登录后生效
+//	@Tags			管理员相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamRoleUpdate	false	"用户、角色、社区"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/admin/role/grant [post]
+func AdminGrantRoleHandler(ctx *gin.Context) {
+	roleUpdateHelper(ctx, logic.GrantRole)
+}
+
+// AdminRevokeRoleHandler 撤销角色接口（admin only）
+//
+//	@Summary		撤销角色接口（admin only）
+//	@Description	撤销用户的管理员、版主角色；用户需要重新登录后生效
+//	@Tags			管理员相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamRoleUpdate	false	"用户、角色、社区"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/admin/role/revoke [post]
+func AdminRevokeRoleHandler(ctx *gin.Context) {
+	roleUpdateHelper(ctx, logic.RevokeRole)
+}
+
+func roleUpdateHelper(ctx *gin.Context, update func(params *models.ParamRoleUpdate) error) {
+	params := new(models.ParamRoleUpdate)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if err := update(params); err != nil {
+		if errors.Is(err, bluebell.ErrUserNotExist) {
+			common.ResponseError(ctx, common.CodeUserNotExist)
+		} else if errors.Is(err, bluebell.ErrNoSuchCommunity) {
+			common.ResponseError(ctx, common.CodeNoSuchCommunity)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// UserBlockHandler 拉黑用户接口
+//
+//	@Summary		拉黑用户接口
+//	@Description	拉黑用户后，不再看到对方的帖子、评论，对方不能回复、@ 自己，已经拉黑过不会报错；帖子、评论列表在分页后过滤，当页可能不足 page_size 条，total 只扣除当页被过滤的数量
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamUserBlock	false	"被拉黑者"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/block [post]
+func UserBlockHandler(ctx *gin.Context) {
+	blockHelper(ctx, true)
+}
+
+// UserUnblockHandler 取消拉黑接口
+//
+//	@Summary		取消拉黑接口
+//	@Description	取消拉黑用户，没有拉黑过不会报错
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamUserBlock	false	"被拉黑者"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/unblock [post]
+func UserUnblockHandler(ctx *gin.Context) {
+	blockHelper(ctx, false)
+}
+
+func blockHelper(ctx *gin.Context, block bool) {
+	userID := ctx.GetInt64("user_id")
+
+	params := models.ParamUserBlock{}
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	var err error
+	if block {
+		err = logic.BlockUser(userID, params.UserID)
+	} else {
+		err = logic.UnblockUser(userID, params.UserID)
+	}
+	if err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "不能拉黑自己")
+		} else if errors.Is(err, bluebell.ErrUserNotExist) {
+			common.ResponseError(ctx, common.CodeUserNotExist)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
+
+// BlockListHandler 拉黑列表接口
+//
+//	@Summary		拉黑列表接口
+//	@Description	按拉黑时间倒序返回被拉黑者的 user_id
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string	false	"Bearer 用户令牌"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=[]string}
+//	@Router			/user/blocks [get]
+func BlockListHandler(ctx *gin.Context) {
+	list, err := logic.GetBlockList(ctx.GetInt64("user_id"))
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	common.ResponseSuccess(ctx, list)
+}
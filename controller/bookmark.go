@@ -0,0 +1,108 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// BookmarkHandler 收藏帖子接口
+//
+//	@Summary		收藏帖子接口
+//	@Description	收藏帖子，已经收藏过则移动到 folder 对应的收藏夹，移动后按新的收藏时间排序
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamBookmark	false	"帖子 id、收藏夹"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/bookmark [post]
+func BookmarkHandler(ctx *gin.Context) {
+	params := new(models.ParamBookmark)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if err := logic.BookmarkPost(ctx.GetInt64("user_id"), params); err != nil {
+		if errors.Is(err, bluebell.ErrNoSuchPost) {
+			common.ResponseError(ctx, common.CodeNoSuchPost)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
+
+// UnbookmarkHandler 取消收藏接口
+//
+//	@Summary		取消收藏接口
+//	@Description	取消收藏帖子，没有收藏过不会报错
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamUnbookmark	false	"帖子 id"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/unbookmark [post]
+func UnbookmarkHandler(ctx *gin.Context) {
+	params := new(models.ParamUnbookmark)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if err := logic.UnbookmarkPost(ctx.GetInt64("user_id"), params.PostID); err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
+
+// BookmarkListHandler 收藏列表接口
+//
+//	@Summary		收藏列表接口
+//	@Description	按收藏时间倒序查询收藏的帖子，基于游标分页，next_cursor 为空表示没有更多数据
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string						false	"Bearer 用户令牌"
+//	@Param			object			query	models.ParamBookmarkList	false	"查询参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.BookmarkListDTO}
+//	@Router			/user/bookmarks [get]
+func BookmarkListHandler(ctx *gin.Context) {
+	params := &models.ParamBookmarkList{
+		PageSize: DefaultPageSize,
+	}
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	data, err := logic.GetBookmarkList(ctx.GetInt64("user_id"), params)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "无效的 cursor")
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, data)
+}
@@ -34,8 +34,14 @@ func CommentCreateHandler(ctx *gin.Context) {
 	userID := ctx.GetInt64("user_id")
 	commentDTO, err := logic.CreateComment(comment, userID)
 	if err != nil {
-		common.ResponseError(ctx, common.CodeInternalErr)
-		logger.ErrorWithStack(err)
+		if errors.Is(err, bluebell.ErrBlocked) {
+			common.ResponseError(ctx, common.CodeBlocked)
+		} else if errors.Is(err, bluebell.ErrNoSuchPost) {
+			common.ResponseError(ctx, common.CodeNoSuchPost)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
 		return
 	}
 
@@ -78,6 +84,50 @@ func CommentListHandler(ctx *gin.Context) {
 		return
 	}
 
+	// 登录用户过滤被拉黑的用户的评论
+	if userID := ctx.GetInt64("user_id"); userID != 0 {
+		if list, err = logic.FilterBlockedComments(userID, list); err != nil {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+
+	common.ResponseSuccess(ctx, list)
+}
+
+// CommentSearchHandler 评论搜索接口
+//
+//	@Summary		评论搜索接口
+//	@Description	根据关键字搜索评论，指定 obj_id 时只在该帖子的评论中搜索，否则全局搜索，结果附带所在帖子的标题
+//	@Tags			评论相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			object	query	models.ParamCommentSearch	false	"查询参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.CommentSearchListDTO}
+//	@Router			/comment/search [get]
+func CommentSearchHandler(ctx *gin.Context) {
+	param := &models.ParamCommentSearch{
+		PageNum:  1,
+		PageSize: 10,
+	}
+	if err := ctx.ShouldBindQuery(param); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+	// 拒绝服务
+	if param.PageNum*param.PageSize >= 1e4 {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "Too much data requested")
+		return
+	}
+
+	list, err := logic.SearchComments(ctx.GetInt64("user_id"), param)
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
 	common.ResponseSuccess(ctx, list)
 }
 
@@ -101,9 +151,11 @@ func CommentRemoveHandler(ctx *gin.Context) {
 	}
 	userID := ctx.GetInt64("user_id")
 
-	if err := logic.RemoveComment(params, userID); err != nil {
+	if err := logic.RemoveComment(params, userID, getRoleInfo(ctx)); err != nil {
 		if errors.Is(err, bluebell.ErrForbidden) {
 			common.ResponseError(ctx, common.CodeForbidden)
+		} else if errors.Is(err, bluebell.ErrNoSuchComment) {
+			common.ResponseError(ctx, common.CodeNoSuchComment)
 		} else {
 			common.ResponseError(ctx, common.CodeInternalErr)
 			logger.ErrorWithStack(err)
@@ -75,10 +75,10 @@ func CommunityDetailHandler(ctx *gin.Context) {
 	common.ResponseSuccess(ctx, detail)
 }
 
-// CommunityCreateHandler 创建社区接口（root user only）
+// CommunityCreateHandler 创建社区接口
 //
-//	@Summary		创建社区接口（root user only）
-//	@Description	创建社区接口（root user only）
+//	@Summary		创建社区接口
+//	@Description	管理员，或 karma 达到 service.karma.privilege.create_community 的用户可以创建社区
 //	@Tags			社区相关接口
 //	@Accept			application/json
 //	@Produce		application/json
@@ -88,26 +88,16 @@ func CommunityDetailHandler(ctx *gin.Context) {
 //	@Success		200	{object}	common.Response
 //	@Router			/community/create [post]
 func CommunityCreateHandler(ctx *gin.Context) {
-	// 获取 user_id
-	value, _ := ctx.Get("user_id")
-	userID, ok := value.(int64)
-	if !ok {
-		common.ResponseError(ctx, common.CodeInternalErr)
-		// 打日志
-		logger.Errorf("controller.CreatePostHandler: convert user_id from context to int64 failed")
-		return
-	}
-	if userID != 0 {
-		common.ResponseError(ctx, common.CodeForbidden)
-		return
-	}
-
 	params := new(models.ParamCommunityCreate)
 	if err := ctx.ShouldBindJSON(&params); err != nil {
 		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
 		return
 	}
 
+	if !checkKarmaPrivilege(ctx, logic.KarmaPrivilegeCreateCommunity) {
+		return
+	}
+
 	if err := logic.CreateCommunity(params); err != nil {
 		common.ResponseError(ctx, common.CodeInternalErr)
 		logger.ErrorWithStack(err)
@@ -116,3 +106,84 @@ func CommunityCreateHandler(ctx *gin.Context) {
 
 	common.ResponseSuccess(ctx, nil)
 }
+
+// CommunityJoinHandler 加入社区接口
+//
+//	@Summary		加入社区接口
+//	@Description	加入社区，已经加入过不会报错
+//	@Tags			社区相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string						false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamCommunityMember	false	"社区 id"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/community/join [post]
+func CommunityJoinHandler(ctx *gin.Context) {
+	communityMemberHelper(ctx, true)
+}
+
+// CommunityLeaveHandler 退出社区接口
+//
+//	@Summary		退出社区接口
+//	@Description	退出社区，没有加入过不会报错
+//	@Tags			社区相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string						false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamCommunityMember	false	"社区 id"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/community/leave [post]
+func CommunityLeaveHandler(ctx *gin.Context) {
+	communityMemberHelper(ctx, false)
+}
+
+func communityMemberHelper(ctx *gin.Context, join bool) {
+	userID := ctx.GetInt64("user_id")
+
+	params := new(models.ParamCommunityMember)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	var err error
+	if join {
+		err = logic.JoinCommunity(userID, params.CommunityID)
+	} else {
+		err = logic.LeaveCommunity(userID, params.CommunityID)
+	}
+	if err != nil {
+		if errors.Is(err, bluebell.ErrNoSuchCommunity) {
+			common.ResponseError(ctx, common.CodeNoSuchCommunity)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
+
+// CommunityJoinedListHandler 加入的社区列表接口
+//
+//	@Summary		加入的社区列表接口
+//	@Description	当前用户加入的社区列表，按加入时间倒序
+//	@Tags			社区相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string	false	"Bearer 用户令牌"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=[]models.CommunityDTO}
+//	@Router			/community/joined [get]
+func CommunityJoinedListHandler(ctx *gin.Context) {
+	list, err := logic.GetJoinedCommunityList(ctx.GetInt64("user_id"))
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+	common.ResponseSuccess(ctx, list)
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// UserFollowHandler 关注用户接口
+//
+//	@Summary		关注用户接口
+//	@Description	关注用户，已经关注过不会报错
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamUserFollow	false	"被关注者"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/follow [post]
+func UserFollowHandler(ctx *gin.Context) {
+	followHelper(ctx, true)
+}
+
+// UserUnfollowHandler 取消关注接口
+//
+//	@Summary		取消关注接口
+//	@Description	取消关注用户，没有关注过不会报错
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamUserFollow	false	"被关注者"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/unfollow [post]
+func UserUnfollowHandler(ctx *gin.Context) {
+	followHelper(ctx, false)
+}
+
+func followHelper(ctx *gin.Context, follow bool) {
+	value, exists := ctx.Get("user_id")
+	if !exists {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.Errorf("controller.followHelper: get user_id from context failed")
+		return
+	}
+	userID := value.(int64)
+
+	params := models.ParamUserFollow{}
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	var err error
+	if follow {
+		err = logic.FollowUser(userID, params.UserID)
+	} else {
+		err = logic.UnfollowUser(userID, params.UserID)
+	}
+	if err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "不能关注自己")
+		} else if errors.Is(err, bluebell.ErrUserNotExist) {
+			common.ResponseError(ctx, common.CodeUserNotExist)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
+
+// PostFeedHandler 关注流接口
+//
+//	@Summary		关注流接口
+//	@Description	按时间倒序查询关注的用户发布的帖子
+//	@Tags			帖子相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			query	models.ParamPostFeed	false	"查询参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.PostListDTO}
+//	@Router			/post/feed [get]
+func PostFeedHandler(ctx *gin.Context) {
+	value, exists := ctx.Get("user_id")
+	if !exists {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.Errorf("controller.PostFeedHandler: get user_id from context failed")
+		return
+	}
+	userID := value.(int64)
+
+	params := &models.ParamPostFeed{
+		PageNum:  DefaultPageNum,
+		PageSize: DefaultPageSize,
+	}
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	list, total, err := logic.GetFeedPostList(userID, params)
+	if err == nil {
+		list, total, err = logic.FilterBlockedPosts(userID, list, total)
+	}
+	if err == nil {
+		list, err = logic.FillBookmarkFlag(userID, list)
+	}
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	common.ResponseSuccess(ctx, &models.PostListDTO{
+		Total: total,
+		Posts: list,
+	})
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/logger"
+	"bluebell/logic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// 检查当前用户的 karma 是否满足操作的要求，不满足时直接响应错误
+func checkKarmaPrivilege(ctx *gin.Context, privilege string) bool {
+	err := logic.CheckKarmaPrivilege(ctx.GetInt64("user_id"), getRoleInfo(ctx), privilege)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, bluebell.ErrKarmaNotEnough) {
+		common.ResponseError(ctx, common.CodeKarmaNotEnough)
+	} else {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// DirectMessageSendHandler 发送私信接口
+//
+//	@Summary		发送私信接口
+//	@Description	发送私信给 to_user_id，被对方拉黑时不能发送
+//	@Tags			私信相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string							false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamDirectMessageSend	false	"私信"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.DirectMessageDTO}
+//	@Router			/messages [post]
+func DirectMessageSendHandler(ctx *gin.Context) {
+	params := new(models.ParamDirectMessageSend)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	message, err := logic.SendDirectMessage(ctx.GetInt64("user_id"), params)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "不能给自己发私信")
+		} else if errors.Is(err, bluebell.ErrUserNotExist) {
+			common.ResponseError(ctx, common.CodeUserNotExist)
+		} else if errors.Is(err, bluebell.ErrBlocked) {
+			common.ResponseError(ctx, common.CodeBlocked)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, message)
+}
+
+// ConversationListHandler 会话列表接口
+//
+//	@Summary		会话列表接口
+//	@Description	按最后一条私信的时间倒序查询会话，同时返回每个会话及总的未读数
+//	@Tags			私信相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string							false	"Bearer 用户令牌"
+//	@Param			object			query	models.ParamConversationList	false	"查询参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.ConversationListDTO}
+//	@Router			/messages/conversations [get]
+func ConversationListHandler(ctx *gin.Context) {
+	params := &models.ParamConversationList{
+		PageNum:  DefaultPageNum,
+		PageSize: DefaultPageSize,
+	}
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	data, err := logic.GetConversationList(ctx.GetInt64("user_id"), params)
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	common.ResponseSuccess(ctx, data)
+}
+
+// DirectMessageHistoryHandler 私信记录接口
+//
+//	@Summary		私信记录接口
+//	@Description	基于游标分页，按发送时间倒序查询与 peer_id 的私信，查询第一页时会话标记为已读
+//	@Tags			私信相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string								false	"Bearer 用户令牌"
+//	@Param			object			query	models.ParamDirectMessageHistory	false	"查询参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.DirectMessageListDTO}
+//	@Router			/messages/history [get]
+func DirectMessageHistoryHandler(ctx *gin.Context) {
+	params := &models.ParamDirectMessageHistory{
+		PageSize: 20,
+	}
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	data, err := logic.GetDirectMessageHistory(ctx.GetInt64("user_id"), params)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "无效的 cursor")
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, data)
+}
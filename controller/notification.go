@@ -0,0 +1,77 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// NotificationListHandler 通知列表接口
+//
+//	@Summary		通知列表接口
+//	@Description	按更新时间倒序查询当前用户的通知，同时返回未读通知数
+//	@Tags			通知相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string							false	"Bearer 用户令牌"
+//	@Param			object			query	models.ParamNotificationList	false	"查询参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=models.NotificationListDTO}
+//	@Router			/notifications [get]
+func NotificationListHandler(ctx *gin.Context) {
+	params := &models.ParamNotificationList{
+		PageNum:  DefaultPageNum,
+		PageSize: DefaultPageSize,
+	}
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	data, err := logic.GetNotificationList(ctx.GetInt64("user_id"), params)
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	common.ResponseSuccess(ctx, data)
+}
+
+// NotificationReadHandler 标记通知已读接口
+//
+//	@Summary		标记通知已读接口
+//	@Description	标记 ids 对应的通知为已读，all 为 true 时标记所有通知
+//	@Tags			通知相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string							false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamNotificationRead	false	"通知 id"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/notifications/read [post]
+func NotificationReadHandler(ctx *gin.Context) {
+	params := new(models.ParamNotificationRead)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if err := logic.ReadNotifications(ctx.GetInt64("user_id"), params); err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseError(ctx, common.CodeInvalidParam)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	"bluebell/logger"
+	"bluebell/logic"
+
+	bluebell "bluebell/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// OAuthLoginURLHandler 获取第三方登录授权地址接口
+//
+//	@Summary		获取第三方登录授权地址接口
+//	@Description	获取第三方平台的授权地址，前端跳转到该地址完成授权
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			provider	path		string	true	"第三方平台名称，如 github"
+//	@Success		200			{object}	common.Response{data=common.ResponseOAuthURL}
+//	@Router			/user/oauth/{provider}/login [get]
+func OAuthLoginURLHandler(ctx *gin.Context) {
+	authURL, err := logic.OAuthGetAuthURL(ctx.Param("provider"), 0)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrNotFound) {
+			common.ResponseErrorWithMsg(ctx, common.CodeNotFound, "不支持的登录方式")
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponseOAuthURL{
+		AuthURL: authURL,
+	})
+}
+
+// OAuthReauthURLHandler 获取第三方重新授权地址接口
+//
+//	@Summary		获取第三方重新授权地址接口
+//	@Description	已登录用户重新验证身份（如注销账户）时使用，授权完成后回调接口返回 common.ResponseOAuthReauth
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header		string	false	"Bearer 用户令牌"
+//	@Param			provider		path		string	true	"第三方平台名称，如 github"
+//	@Security		ApiKeyAuth
+//	@Success		200				{object}	common.Response{data=common.ResponseOAuthURL}
+//	@Router			/user/oauth/{provider}/reauth [get]
+func OAuthReauthURLHandler(ctx *gin.Context) {
+	authURL, err := logic.OAuthGetAuthURL(ctx.Param("provider"), ctx.GetInt64("user_id"))
+	if err != nil {
+		if errors.Is(err, bluebell.ErrNotFound) {
+			common.ResponseErrorWithMsg(ctx, common.CodeNotFound, "不支持的登录方式")
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponseOAuthURL{
+		AuthURL: authURL,
+	})
+}
+
+// OAuthCallbackHandler 第三方登录回调接口
+//
+//	@Summary		第三方登录回调接口
+//	@Description	使用第三方平台返回的 code、state 登录，首次登录会自动注册；开启了两步验证的用户返回 common.ResponseTwoFactorChallenge
+//	@Description	state 来自重新授权接口时不会登录，返回 common.ResponseOAuthReauth
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			provider	path		string	true	"第三方平台名称，如 github"
+//	@Param			code		query		string	true	"授权码"
+//	@Param			state		query		string	true	"state"
+//	@Success		200			{object}	common.Response{data=common.ResponseUserLogin}
+//	@Router			/user/oauth/{provider}/callback [get]
+func OAuthCallbackHandler(ctx *gin.Context) {
+	code, state := ctx.Query("code"), ctx.Query("state")
+	if code == "" || state == "" {
+		common.ResponseError(ctx, common.CodeInvalidParam)
+		return
+	}
+
+	if logic.IsOAuthReauthState(state) {
+		reauthToken, err := logic.OAuthReauth(ctx.Param("provider"), code, state)
+		if err != nil {
+			if errors.Is(err, bluebell.ErrForbidden) {
+				common.ResponseErrorWithMsg(ctx, common.CodeForbidden, "该第三方账户未绑定到当前用户")
+			} else {
+				responseOAuthError(ctx, err)
+			}
+			return
+		}
+		common.ResponseSuccess(ctx, common.ResponseOAuthReauth{
+			ReauthToken: reauthToken,
+		})
+		return
+	}
+
+	usr, access_token, refresh_token, challenge_token, err := logic.OAuthLogin(ctx.Param("provider"), code, state)
+	if err != nil {
+		responseOAuthError(ctx, err)
+		return
+	}
+
+	if challenge_token != "" {
+		common.ResponseSuccess(ctx, common.ResponseTwoFactorChallenge{
+			NeedTwoFactor:  true,
+			ChallengeToken: challenge_token,
+		})
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponseUserLogin{
+		UserName:     usr.UserName,
+		UserID:       usr.UserID,
+		Avatar:       usr.Avatar,
+		Email:        usr.Email,
+		Gender:       usr.Gender,
+		Intro:        usr.Intro,
+		AccessToken:  access_token,
+		RefreshToken: refresh_token,
+	})
+}
+
+func responseOAuthError(ctx *gin.Context, err error) {
+	if errors.Is(err, bluebell.ErrNotFound) {
+		common.ResponseErrorWithMsg(ctx, common.CodeNotFound, "不支持的登录方式")
+	} else if errors.Is(err, bluebell.ErrInvalidToken) {
+		common.ResponseErrorWithMsg(ctx, common.CodeOAuthFailed, "授权已过期，请重新登录")
+	} else if errors.Is(err, bluebell.ErrOAuthFailed) {
+		common.ResponseError(ctx, common.CodeOAuthFailed)
+		logger.Warnf("controller:OAuthCallbackHandler: %v", err.Error())
+	} else {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+	}
+}
@@ -18,6 +18,8 @@ const (
 	DefaultPageNum  = 1
 	DefaultPageSize = 10
 	DefaultOrderBy  = "time"
+
+	DefaultSuggestSize = 5
 )
 
 // CreatePostHandler 创建帖子接口
@@ -82,6 +84,46 @@ func CreatePostHandler(ctx *gin.Context) {
 	})
 }
 
+// PostRepostHandler 转发帖子接口
+//
+//	@Summary		转发帖子接口
+//	@Description	将帖子转发到指定社区，可以附带自己的评论；转发一篇转发时，引用的是最初的原帖
+//	@Tags			帖子相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string					false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamPostRepost	false	"转发参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=common.ResponsePostCreate}
+//	@Router			/post/repost [post]
+func PostRepostHandler(ctx *gin.Context) {
+	params := new(models.ParamPostRepost)
+	if err := ctx.ShouldBindJSON(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	if _, err := logic.GetCommunityDetailByID(params.CommunityID); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "不存在的社区")
+		return
+	}
+
+	postID, err := logic.RepostPost(ctx.GetInt64("user_id"), params)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrNoSuchPost) {
+			common.ResponseError(ctx, common.CodeNoSuchPost)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponsePostCreate{
+		PostID: postID,
+	})
+}
+
 // PostDetailHandler 获取帖子详情接口
 //
 //	@Summary		获取帖子详情接口
@@ -120,9 +162,19 @@ func PostDetailHandler(ctx *gin.Context) {
 		}
 		return
 	}
+	posts, err := logic.FillBookmarkFlag(ctx.GetInt64("user_id"), []*models.PostDTO{post})
+	if err == nil {
+		posts, err = logic.FillRepostOriginals(posts)
+	}
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInternalErr)
+		logger.ErrorWithStack(err)
+		return
+	}
+	post = posts[0]
 
 	// 合并一下，方便看
-	common.ResponseSuccess(ctx, &common.ResponsePostDetail{
+	detail := &common.ResponsePostDetail{
 		AuthorInfo: struct {
 			AuthorID   int64  "json:\"author_id,string\""
 			AuthorName string "json:\"author_name\""
@@ -142,21 +194,37 @@ func PostDetailHandler(ctx *gin.Context) {
 			CreatedAt:     post.CommunityCreatedAt,
 		},
 		PostInfo: struct {
-			PostID    int64       "json:\"post_id,string\""
-			Title     string      "json:\"title\""
-			Content   string      "json:\"content\""
-			CreatedAt models.Time "json:\"created_at\""
-			UpdatedAt models.Time "json:\"updated_at\""
-			VoteNum   int64       "json:\"vote_num\""
+			PostID       int64       "json:\"post_id,string\""
+			Title        string      "json:\"title\""
+			Content      string      "json:\"content\""
+			CreatedAt    models.Time "json:\"created_at\""
+			UpdatedAt    models.Time "json:\"updated_at\""
+			VoteNum      int64       "json:\"vote_num\""
+			IsBookmarked bool        "json:\"is_bookmarked\""
+			RepostCount  int64       "json:\"repost_count\""
 		}{
-			PostID:    post.PostID,
-			Title:     post.Title,
-			Content:   post.Content,
-			VoteNum:   post.VoteNum,
-			CreatedAt: post.CreatedAt,
-			UpdatedAt: post.UpdatedAt,
+			PostID:       post.PostID,
+			Title:        post.Title,
+			Content:      post.Content,
+			VoteNum:      post.VoteNum,
+			CreatedAt:    post.CreatedAt,
+			UpdatedAt:    post.UpdatedAt,
+			IsBookmarked: post.IsBookmarked,
+			RepostCount:  post.RepostCount,
 		},
-	})
+	}
+	if post.OriginalPostID != 0 {
+		detail.RepostInfo = &struct {
+			OriginalPostID  int64           "json:\"original_post_id,string\""
+			Original        *models.PostDTO "json:\"original\""
+			OriginalRemoved bool            "json:\"original_removed\""
+		}{
+			OriginalPostID:  post.OriginalPostID,
+			Original:        post.Original,
+			OriginalRemoved: post.OriginalRemoved,
+		}
+	}
+	common.ResponseSuccess(ctx, detail)
 }
 
 // PostVoteHandler 帖子投票接口
@@ -207,7 +275,7 @@ func PostVoteHandler(ctx *gin.Context) {
 // PostListHandler 帖子列表接口
 //
 //	@Summary		帖子列表接口
-//	@Description	按社区按时间(time)或分数(score)排序查询帖子列表接口
+//	@Description	按社区按时间(time)或分数(score)排序查询帖子列表接口，scope 为 joined 时查询加入的社区的帖子（需要登录）
 //	@Tags			帖子相关接口
 //	@Accept			application/json
 //	@Produce		application/json
@@ -230,7 +298,27 @@ func PostListHandler(ctx *gin.Context) {
 		return
 	}
 
-	list, total, err := logic.GetAllPostList(params)
+	var list []*models.PostDTO
+	var total int
+	var err error
+	if params.Scope == "joined" {
+		// 由 middleware.TryAuth 设置
+		userID, exists := ctx.Get("user_id")
+		if !exists {
+			common.ResponseError(ctx, common.CodeNeedLogin)
+			return
+		}
+		list, total, err = logic.GetJoinedPostList(userID.(int64), params)
+	} else {
+		list, total, err = logic.GetAllPostList(params)
+	}
+	// 已登录，过滤被拉黑的用户的帖子，并设置 is_bookmarked
+	if userID, exists := ctx.Get("user_id"); exists && err == nil {
+		list, total, err = logic.FilterBlockedPosts(userID.(int64), list, total)
+		if err == nil {
+			list, err = logic.FillBookmarkFlag(userID.(int64), list)
+		}
+	}
 
 	if err != nil {
 		if errors.Is(err, bluebell.ErrInvalidParam) {
@@ -251,13 +339,13 @@ func PostListHandler(ctx *gin.Context) {
 // PostSearchHandler 帖子搜索接口
 //
 //	@Summary		帖子搜索接口
-//	@Description	使用 bleve 实现，根据关键字搜索帖子，包含过期帖子
+//	@Description	根据关键字搜索帖子，包含过期帖子，支持按社区、作者、发布日期、最少赞成票数过滤，返回高亮片段，可选返回按社区、月份的分面统计，使用的搜索引擎（bleve 或 elasticsearch）由配置决定
 //	@Tags			帖子相关接口
 //	@Accept			application/json
 //	@Produce		application/json
 //	@Param			object	query	models.ParamPostListByKeyword	false	"查询参数"
 //	@Security		ApiKeyAuth
-//	@Success		200	{object}	common.Response{data=[]models.PostListDTO}
+//	@Success		200	{object}	common.Response{data=models.PostSearchListDTO}
 //	@Router			/post/search [get]
 func PostSearchHandler(ctx *gin.Context) {
 	// 解析数据
@@ -278,7 +366,7 @@ func PostSearchHandler(ctx *gin.Context) {
 	}
 
 	// 关键字检索
-	postList, total, err := logic.GetPostListByKeyword(params)
+	list, err := logic.GetPostListByKeyword(params)
 	if err != nil {
 		if errors.Is(err, bluebell.ErrInvalidParam) {
 			common.ResponseError(ctx, common.CodeInvalidParam)
@@ -290,58 +378,71 @@ func PostSearchHandler(ctx *gin.Context) {
 	}
 
 	// 返回帖子列表
-	common.ResponseSuccess(ctx, &models.PostListDTO{
-		Total: total,
-		Posts: postList,
-	})
+	common.ResponseSuccess(ctx, list)
 }
 
-// PostSearchHandler2 帖子搜索接口
+// PostSuggestHandler 搜索补全接口
 //
-//	@Summary		帖子搜索接口
-//	@Description	使用 elasticsearch 实现，根据关键字搜索帖子，包含过期帖子
+//	@Summary		搜索补全接口
+//	@Description	根据已输入的前缀补全帖子标题，并返回近期以该前缀开头的热门搜索词，按 IP 限流
 //	@Tags			帖子相关接口
 //	@Accept			application/json
 //	@Produce		application/json
-//	@Param			object	query	models.ParamPostListByKeyword	false	"查询参数"
-//	@Security		ApiKeyAuth
-//	@Success		200	{object}	common.Response{data=[]models.PostListDTO}
-//	@Router			/post/search2 [get]
-func PostSearchHandler2(ctx *gin.Context) {
-	// 解析数据
-	params := &models.ParamPostListByKeyword{
-		PageNum:  DefaultPageNum,
-		PageSize: DefaultPageSize,
-		OrderBy:  "correlation", // 这里使用相关性作为默认排序规则
-	}
+//	@Param			object	query	models.ParamPostSuggest	false	"查询参数"
+//	@Success		200	{object}	common.Response{data=models.PostSuggestDTO}
+//	@Router			/post/suggest [get]
+func PostSuggestHandler(ctx *gin.Context) {
+	params := &models.ParamPostSuggest{Size: DefaultSuggestSize}
 	if err := ctx.ShouldBindQuery(params); err != nil {
 		msg := utils.ParseToValidationError(err)
 		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, msg)
 		return
 	}
-	// 拒绝服务
-	if params.PageNum*params.PageSize >= 1e4 {
-		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "Too much data requested")
-		return
-	}
 
-	// 关键字检索
-	postList, total, err := logic.GetPostListByKeyword2(params)
+	suggestions, err := logic.GetPostSuggestions(params)
 	if err != nil {
-		if errors.Is(err, bluebell.ErrInvalidParam) {
-			common.ResponseError(ctx, common.CodeInvalidParam)
+		if errors.Is(err, bluebell.ErrTimeout) {
+			common.ResponseError(ctx, common.CodeTimeOut)
 			return
 		}
 		common.ResponseError(ctx, common.CodeInternalErr)
 		logger.ErrorWithStack(err)
 		return
 	}
+	common.ResponseSuccess(ctx, suggestions)
+}
 
-	// 返回帖子列表
-	common.ResponseSuccess(ctx, &models.PostListDTO{
-		Total: total,
-		Posts: postList,
-	})
+// PostRelatedHandler 相关帖子接口
+//
+//	@Summary		相关帖子接口
+//	@Description	获取与帖子内容相似的同社区帖子，结合帖子分数排序，用于帖子详情页
+//	@Tags			帖子相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			object	query	models.ParamPostRelated	false	"查询参数"
+//	@Success		200	{object}	common.Response{data=[]models.PostDTO}
+//	@Router			/post/related [get]
+func PostRelatedHandler(ctx *gin.Context) {
+	params := new(models.ParamPostRelated)
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		msg := utils.ParseToValidationError(err)
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, msg)
+		return
+	}
+
+	list, err := logic.GetRelatedPosts(params.PostID)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrNoSuchPost) {
+			common.ResponseError(ctx, common.CodeNoSuchPost)
+		} else if errors.Is(err, bluebell.ErrTimeout) {
+			common.ResponseError(ctx, common.CodeTimeOut)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+	common.ResponseSuccess(ctx, list)
 }
 
 // PostHotController 火热帖子列表接口
@@ -402,9 +503,11 @@ func PostRemoveHandler(ctx *gin.Context)  {
 	userID := value.(int64)
 
 	// 删除帖子
-	if err := logic.RemovePost(userID, params); err != nil {
+	if err := logic.RemovePost(userID, getRoleInfo(ctx), params); err != nil {
 		if errors.Is(err, bluebell.ErrForbidden) {
 			common.ResponseError(ctx, common.CodeForbidden)
+		} else if errors.Is(err, bluebell.ErrNoSuchPost) {
+			common.ResponseError(ctx, common.CodeNoSuchPost)
 		} else {
 			common.ResponseError(ctx, common.CodeInternalErr)
 			logger.ErrorWithStack(err)
@@ -12,7 +12,7 @@ import (
 // QiniuGenUploadTokenHandler 获取七牛云文件上传 token 接口
 //
 //	@Summary		获取七牛云文件上传 token 接口
-//	@Description	获取七牛云文件上传 token 接口
+//	@Description	获取七牛云文件上传 token 接口，karma 需要达到 service.karma.privilege.upload_image
 //	@Tags			七牛云相关接口
 //	@Accept			application/json
 //	@Produce		application/json
@@ -21,6 +21,9 @@ import (
 //	@Success		200	{object}	common.Response{data=string}
 //	@Router			/qiniu/upload/gentoken [get]
 func QiniuGenUploadTokenHandler(ctx *gin.Context) {
+	if !checkKarmaPrivilege(ctx, logic.KarmaPrivilegeUploadImage) {
+		return
+	}
 	uploadToken := logic.QiniuGenUploadToken()
 	common.ResponseSuccess(ctx, uploadToken)
 }
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	"bluebell/dao/search"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// SearchHandler 统一搜索接口
+//
+//	@Summary		统一搜索接口
+//	@Description	根据 type 搜索帖子、用户（用户名、简介）或社区（名称、简介），type 为 post 时支持 /post/search 的所有查询参数
+//	@Tags			搜索相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			object	query	models.ParamSearch	false	"查询参数"
+//	@Success		200	{object}	common.Response{data=models.SearchResultDTO}
+//	@Router			/search [get]
+func SearchHandler(ctx *gin.Context) {
+	params := &models.ParamSearch{
+		Type:     models.SearchTypePost,
+		PageNum:  DefaultPageNum,
+		PageSize: DefaultPageSize,
+	}
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		msg := utils.ParseToValidationError(err)
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, msg)
+		return
+	}
+	// 拒绝服务
+	if params.PageNum*params.PageSize >= 1e4 {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, "Too much data requested")
+		return
+	}
+
+	res := &models.SearchResultDTO{Type: params.Type}
+	var err error
+	switch params.Type {
+	case models.SearchTypeUser:
+		res.Users, err = logic.SearchUsers(params)
+	case models.SearchTypeCommunity:
+		res.Communities, err = logic.SearchCommunities(params)
+	default:
+		postParams := &models.ParamPostListByKeyword{
+			PageNum:  DefaultPageNum,
+			PageSize: DefaultPageSize,
+			OrderBy:  "correlation",
+		}
+		if err := ctx.ShouldBindQuery(postParams); err != nil {
+			msg := utils.ParseToValidationError(err)
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, msg)
+			return
+		}
+		res.Posts, err = logic.GetPostListByKeyword(postParams)
+	}
+	if err != nil {
+		if errors.Is(err, bluebell.ErrInvalidParam) {
+			common.ResponseError(ctx, common.CodeInvalidParam)
+		} else if errors.Is(err, bluebell.ErrTimeout) {
+			common.ResponseError(ctx, common.CodeTimeOut)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+	common.ResponseSuccess(ctx, res)
+}
+
+// SearchStatusHandler 搜索引擎状态接口
+//
+//	@Summary		搜索引擎状态接口
+//	@Description	获取各个搜索引擎的熔断状态，查询引擎熔断时，查询会降级到其他启用的引擎
+//	@Tags			搜索相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Success		200	{object}	common.Response{data=models.SearchStatusDTO}
+//	@Router			/search/status [get]
+func SearchStatusHandler(ctx *gin.Context) {
+	common.ResponseSuccess(ctx, search.Status())
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// StreamHandler 实时推送接口
+//
+//	@Summary		实时推送接口
+//	@Description	基于 SSE（Server-Sent Events），推送新通知（notification），以及订阅的帖子的新评论（comment）；浏览器 EventSource 可以通过 query 参数 token 传递 access_token
+//	@Tags			通知相关接口
+//	@Produce		text/event-stream
+//	@Param			Authorization	header	string				false	"Bearer 用户令牌"
+//	@Param			object			query	models.ParamStream	false	"订阅参数"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	models.StreamEvent
+//	@Router			/stream [get]
+func StreamHandler(ctx *gin.Context) {
+	params := new(models.ParamStream)
+	if err := ctx.ShouldBindQuery(params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+	postIDs, err := utils.ConvertStringSliceToInt64Slice(params.PostIDs)
+	if err != nil {
+		common.ResponseError(ctx, common.CodeInvalidParam)
+		return
+	}
+
+	events, unsubscribe, err := logic.SubscribeStream(ctx.GetInt64("user_id"), postIDs)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrServerClosing) {
+			common.ResponseError(ctx, common.CodeServerBusy)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+	defer unsubscribe()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // 关闭 nginx 的缓冲
+
+	// 定时发送心跳，避免连接被代理断开
+	heartbeat := time.NewTicker(time.Second * time.Duration(viper.GetInt("service.stream.heartbeat_interval")))
+	defer heartbeat.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok { // 服务器关闭
+				return false
+			}
+			ctx.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	common "bluebell/controller/Common"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/logic"
+	"bluebell/models"
+
+	bluebell "bluebell/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// UserLoginTwoFactorHandler 两步验证登录接口
+//
+//	@Summary		两步验证登录接口
+//	@Description	登录的第二步，使用登录接口返回的 challenge_token 与 TOTP code（或恢复码）换取 token
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			object	body		models.ParamUserLoginTwoFactor	false	"challenge_token 与 code"
+//	@Success		200		{object}	common.Response{data=common.ResponseUserLogin}
+//	@Router			/user/login/2fa [post]
+func UserLoginTwoFactorHandler(ctx *gin.Context) {
+	var params models.ParamUserLoginTwoFactor
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+
+	usr, access_token, refresh_token, err := logic.UserLoginTwoFactor(&params, ctx.ClientIP())
+	if err != nil {
+		if errors.Is(err, bluebell.ErrAccountLocked) {
+			common.ResponseError(ctx, common.CodeAccountLocked)
+		} else if errors.Is(err, bluebell.ErrInvalidToken) {
+			common.ResponseErrorWithMsg(ctx, common.CodeInvalidToken, "登录已过期，请重新登录")
+		} else if errors.Is(err, bluebell.ErrInvalidVerificationCode) {
+			common.ResponseError(ctx, common.CodeInvalidVerificationCode)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponseUserLogin{
+		UserName:     usr.UserName,
+		UserID:       usr.UserID,
+		Avatar:       usr.Avatar,
+		Email:        usr.Email,
+		Gender:       usr.Gender,
+		Intro:        usr.Intro,
+		AccessToken:  access_token,
+		RefreshToken: refresh_token,
+	})
+}
+
+// TwoFactorEnrollHandler 绑定两步验证接口
+//
+//	@Summary		绑定两步验证接口
+//	@Description	生成 TOTP 密钥，返回 otpauth URI，需要调用确认接口后才会生效
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string	false	"Bearer 用户令牌"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=common.ResponseTwoFactorEnroll}
+//	@Router			/user/2fa/enroll [post]
+func TwoFactorEnrollHandler(ctx *gin.Context) {
+	userID := ctx.GetInt64("user_id")
+
+	secret, uri, err := logic.TwoFactorEnroll(userID)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrTwoFactorEnabled) {
+			common.ResponseError(ctx, common.CodeTwoFactorEnabled)
+		} else if errors.Is(err, bluebell.ErrUserNotExist) {
+			common.ResponseError(ctx, common.CodeUserNotExist)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponseTwoFactorEnroll{
+		Secret: secret,
+		URI:    uri,
+	})
+}
+
+// TwoFactorConfirmHandler 确认绑定两步验证接口
+//
+//	@Summary		确认绑定两步验证接口
+//	@Description	使用验证器 App 生成的第一个 code 确认绑定，返回恢复码（只返回这一次）
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string						false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamTwoFactorCode	false	"TOTP code"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response{data=common.ResponseTwoFactorConfirm}
+//	@Router			/user/2fa/confirm [post]
+func TwoFactorConfirmHandler(ctx *gin.Context) {
+	var params models.ParamTwoFactorCode
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+	userID := ctx.GetInt64("user_id")
+
+	codes, err := logic.TwoFactorConfirm(userID, params.Code)
+	if err != nil {
+		if errors.Is(err, bluebell.ErrTwoFactorEnabled) {
+			common.ResponseError(ctx, common.CodeTwoFactorEnabled)
+		} else if errors.Is(err, bluebell.ErrTwoFactorNotEnabled) {
+			common.ResponseErrorWithMsg(ctx, common.CodeTwoFactorNotEnabled, "请先绑定两步验证")
+		} else if errors.Is(err, bluebell.ErrInvalidVerificationCode) {
+			common.ResponseError(ctx, common.CodeInvalidVerificationCode)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, common.ResponseTwoFactorConfirm{
+		RecoveryCodes: codes,
+	})
+}
+
+// TwoFactorDisableHandler 关闭两步验证接口
+//
+//	@Summary		关闭两步验证接口
+//	@Description	关闭两步验证，需要提供 TOTP code 或恢复码
+//	@Tags			用户相关接口
+//	@Accept			application/json
+//	@Produce		application/json
+//	@Param			Authorization	header	string						false	"Bearer 用户令牌"
+//	@Param			object			body	models.ParamTwoFactorCode	false	"TOTP code 或恢复码"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	common.Response
+//	@Router			/user/2fa/disable [post]
+func TwoFactorDisableHandler(ctx *gin.Context) {
+	var params models.ParamTwoFactorCode
+	if err := ctx.ShouldBindJSON(&params); err != nil {
+		common.ResponseErrorWithMsg(ctx, common.CodeInvalidParam, utils.ParseToValidationError(err))
+		return
+	}
+	userID := ctx.GetInt64("user_id")
+
+	if err := logic.TwoFactorDisable(userID, params.Code); err != nil {
+		if errors.Is(err, bluebell.ErrTwoFactorNotEnabled) {
+			common.ResponseError(ctx, common.CodeTwoFactorNotEnabled)
+		} else if errors.Is(err, bluebell.ErrInvalidVerificationCode) {
+			common.ResponseError(ctx, common.CodeInvalidVerificationCode)
+		} else {
+			common.ResponseError(ctx, common.CodeInternalErr)
+			logger.ErrorWithStack(err)
+		}
+		return
+	}
+
+	common.ResponseSuccess(ctx, nil)
+}
@@ -76,11 +76,11 @@ func UserRegisterHandler(ctx *gin.Context) {
 // UserRegisterHandler 用户登录接口
 //
 //	@Summary		用户登录接口
-//	@Description	用户登录接口
+//	@Description	用户登录接口，支持用户名或邮箱登录；开启了两步验证的用户返回 common.ResponseTwoFactorChallenge，需调用 /user/login/2fa 完成登录
 //	@Tags			用户相关接口
 //	@Accept			application/json
 //	@Produce		application/json
-//	@Param			usernameANDpassword	body		models.ParamUserLogin	false	"用户信息（包含用户名、密码）"
+//	@Param			usernameANDpassword	body		models.ParamUserLogin	false	"用户信息（包含用户名或邮箱、密码）"
 //	@Success		200					{object}	common.Response{data=common.ResponseUserLogin}
 //	@Router			/user/login [post]
 func UserLoginHandler(ctx *gin.Context) {
@@ -93,9 +93,11 @@ func UserLoginHandler(ctx *gin.Context) {
 	}
 
 	// 登录
-	usr, access_token, refresh_token, err := logic.UserLogin(&params)
+	usr, access_token, refresh_token, challenge_token, err := logic.UserLogin(&params, ctx.ClientIP())
 	if err != nil {
-		if errors.Is(err, bluebell.ErrUserNotExist) {
+		if errors.Is(err, bluebell.ErrAccountLocked) {
+			common.ResponseError(ctx, common.CodeAccountLocked)
+		} else if errors.Is(err, bluebell.ErrUserNotExist) {
 			common.ResponseError(ctx, common.CodeUserNotExist)
 		} else if errors.Is(err, bluebell.ErrWrongPassword) {
 			common.ResponseError(ctx, common.CodeWrongPassword)
@@ -107,6 +109,15 @@ func UserLoginHandler(ctx *gin.Context) {
 		return
 	}
 
+	// 开启了两步验证，需要再校验 TOTP code
+	if challenge_token != "" {
+		common.ResponseSuccess(ctx, common.ResponseTwoFactorChallenge{
+			NeedTwoFactor:  true,
+			ChallengeToken: challenge_token,
+		})
+		return
+	}
+
 	// 响应
 	common.ResponseSuccess(ctx, common.ResponseUserLogin{
 		UserName: usr.UserName,
@@ -0,0 +1,48 @@
+package bleve
+
+import (
+	"bluebell/models"
+	"context"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/pkg/errors"
+)
+
+func CreateComment(doc *models.CommentDoc) error {
+	return errors.Wrap(commentIndex.Index(strconv.FormatInt(doc.CommentID, 10), doc), "bleve:CreateComment: Index")
+}
+
+func DeleteComments(commentIDs []int64) error {
+	batch := commentIndex.NewBatch()
+	for _, commentID := range commentIDs {
+		batch.Delete(strconv.FormatInt(commentID, 10))
+	}
+	return errors.Wrap(commentIndex.Batch(batch), "bleve:DeleteComments: Batch")
+}
+
+func GetCommentIDsByKeyword(ctx context.Context, params *models.ParamCommentSearch) ([]string, int, error) {
+	from := (params.PageNum - 1) * params.PageSize
+
+	match := bleve.NewMatchQuery(params.Keyword)
+	match.SetField("message")
+	var q query.Query = match
+	if params.ObjID != 0 { // 只在指定帖子的评论中搜索
+		term := bleve.NewTermQuery(strconv.FormatInt(params.ObjID, 10))
+		term.SetField("obj_id")
+		q = bleve.NewConjunctionQuery(match, term)
+	}
+
+	search := bleve.NewSearchRequestOptions(q, int(params.PageSize), int(from), false)
+	searchResults, err := commentIndex.SearchInContext(ctx, search)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "bleve:GetCommentIDsByKeyword: Search")
+	}
+
+	commentIDs := make([]string, 0, len(searchResults.Hits))
+	for _, res := range searchResults.Hits {
+		commentIDs = append(commentIDs, res.ID)
+	}
+	return commentIDs, int(searchResults.Total), nil
+}
@@ -1,11 +1,17 @@
 package bleve
 
 import (
+	"context"
+
 	"github.com/blevesearch/bleve/v2"
-	"github.com/pkg/errors"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
 )
 
 var postIndex bleve.Index
+var commentIndex bleve.Index
+var userIndex bleve.Index
+var communityIndex bleve.Index
 
 func InitBleve()  {
 	var err error
@@ -14,38 +20,129 @@ func InitBleve()  {
 	if err != nil {
 		panic(err.Error())
 	}
+	// 创建 comment 的索引
+	commentIndex, err = createCommentIndex("bluebell_comment.bleve")
+	if err != nil {
+		panic(err.Error())
+	}
+	// 创建 user、community 的索引
+	userIndex, err = createTextIndex("bluebell_user.bleve", "username", "intro")
+	if err != nil {
+		panic(err.Error())
+	}
+	communityIndex, err = createTextIndex("bluebell_community.bleve", "community_name", "introduction")
+	if err != nil {
+		panic(err.Error())
+	}
 }
 
 func GetPostIndex() bleve.Index {
 	return postIndex
 }
 
-func matchQuerySearch(index bleve.Index, match string, size int, from int, useTime bool) (*bleve.SearchResult, error) {
-	query := bleve.NewMatchQuery(match)
-	search := bleve.NewSearchRequestOptions(query, size, from, false)
-	if useTime {
-		search.SortBy([]string{"-created_time"}) // 按 created_time 降序排序
-	}
-	searchResults, err := index.Search(search)
-	return searchResults, errors.Wrap(err, "bleve:matchQuerySearch: Search")
+func GetCommentIndex() bleve.Index {
+	return commentIndex
 }
 
 func createIndex(path string) (bleve.Index, error) {
 	// 定义映射
 	indexMapping := bleve.NewIndexMapping()
+	addTextAnalyzer(indexMapping)
+	// 整个标题作为一个词项，用于标题前缀补全
+	indexMapping.AddCustomAnalyzer("bluebell_prefix", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     "single",
+		"token_filters": []string{"cjk_width", "to_lower"},
+	})
 	textFieldMapping := bleve.NewTextFieldMapping()
-	textFieldMapping.Analyzer = "cjk" // 使用中文分词器
+	textFieldMapping.Analyzer = "bluebell_text"
+	prefixFieldMapping := bleve.NewTextFieldMapping()
+	prefixFieldMapping.Name = "title_prefix"
+	prefixFieldMapping.Analyzer = "bluebell_prefix"
+	prefixFieldMapping.Store = false
+	prefixFieldMapping.IncludeInAll = false
+	indexMapping.DefaultMapping.AddFieldMappingsAt("title", textFieldMapping, prefixFieldMapping)
+	indexMapping.DefaultMapping.AddFieldMappingsAt("content", textFieldMapping)
+	// 为创建时间创建索引，以实现按照时间排序
+	indexMapping.DefaultMapping.AddFieldMappingsAt("created_time", bleve.NewDateTimeFieldMapping())
+	// 用于过滤、分面统计的字段
+	indexMapping.DefaultMapping.AddFieldMappingsAt("community_id", bleve.NewKeywordFieldMapping())
+	indexMapping.DefaultMapping.AddFieldMappingsAt("author_id", bleve.NewKeywordFieldMapping())
+	indexMapping.DefaultMapping.AddFieldMappingsAt("vote_num", bleve.NewNumericFieldMapping())
+
+	// 打开或创建索引
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexMetaMissing || err == bleve.ErrorIndexPathDoesNotExist {
+		index, err = bleve.New(path, indexMapping)
+	}
+	return index, err
+}
+
+// 中文按二元组切分，英文转小写、去停用词后提取词干
+func addTextAnalyzer(indexMapping *mapping.IndexMappingImpl) {
+	indexMapping.AddCustomAnalyzer("bluebell_text", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     "unicode",
+		"token_filters": []string{"cjk_width", "to_lower", "cjk_bigram", "stop_en", "stemmer_en_snowball"},
+	})
+}
+
+// 创建只需要全文检索的索引，fields 使用 bluebell_text 分词
+func createTextIndex(path string, fields ...string) (bleve.Index, error) {
+	indexMapping := bleve.NewIndexMapping()
+	addTextAnalyzer(indexMapping)
+	for _, field := range fields {
+		textFieldMapping := bleve.NewTextFieldMapping()
+		textFieldMapping.Analyzer = "bluebell_text"
+		indexMapping.DefaultMapping.AddFieldMappingsAt(field, textFieldMapping)
+	}
+
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexMetaMissing || err == bleve.ErrorIndexPathDoesNotExist {
+		index, err = bleve.New(path, indexMapping)
+	}
+	return index, err
+}
+
+func createCommentIndex(path string) (bleve.Index, error) {
+	indexMapping := bleve.NewIndexMapping()
 	indexMapping.AddCustomAnalyzer("cjk", map[string]interface{}{
 		"type":   "cjk",
 		"locale": "zh",
 	})
-	// 为创建时间创建索引，以实现按照时间排序
+	messageFieldMapping := bleve.NewTextFieldMapping()
+	messageFieldMapping.Analyzer = "cjk" // 使用中文分词器
+	indexMapping.DefaultMapping.AddFieldMappingsAt("message", messageFieldMapping)
+	// obj_id 只做精确匹配，不分词
+	indexMapping.DefaultMapping.AddFieldMappingsAt("obj_id", bleve.NewKeywordFieldMapping())
 	indexMapping.DefaultMapping.AddFieldMappingsAt("created_time", bleve.NewDateTimeFieldMapping())
 
-	// 打开或创建索引
 	index, err := bleve.Open(path)
 	if err == bleve.ErrorIndexMetaMissing || err == bleve.ErrorIndexPathDoesNotExist {
 		index, err = bleve.New(path, indexMapping)
 	}
 	return index, err
 }
+
+// 关键字匹配 fields 中的任意一个字段，第一个字段的权重更高，返回分页后的文档 id 及总数
+func searchIDsByKeyword(ctx context.Context, index bleve.Index, keyword string, pageNum, pageSize int64, fields ...string) ([]string, int, error) {
+	queries := make([]query.Query, len(fields))
+	for i, field := range fields {
+		match := bleve.NewMatchQuery(keyword)
+		match.SetField(field)
+		if i == 0 {
+			match.SetBoost(2)
+		}
+		queries[i] = match
+	}
+	search := bleve.NewSearchRequestOptions(bleve.NewDisjunctionQuery(queries...), int(pageSize), int((pageNum-1)*pageSize), false)
+	searchResults, err := index.SearchInContext(ctx, search)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]string, 0, len(searchResults.Hits))
+	for _, hit := range searchResults.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, int(searchResults.Total), nil
+}
@@ -0,0 +1,19 @@
+package bleve
+
+import (
+	"bluebell/models"
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+func CreateCommunity(doc *models.CommunityDoc) error {
+	return errors.Wrap(communityIndex.Index(strconv.FormatInt(doc.CommunityID, 10), doc), "bleve:CreateCommunity: Index")
+}
+
+// GetCommunityIDsByKeyword 根据关键字匹配社区名称、简介，名称的权重更高
+func GetCommunityIDsByKeyword(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	communityIDs, total, err := searchIDsByKeyword(ctx, communityIndex, params.Keyword, params.PageNum, params.PageSize, "community_name", "introduction")
+	return communityIDs, total, errors.Wrap(err, "bleve:GetCommunityIDsByKeyword: Search")
+}
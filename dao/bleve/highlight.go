@@ -0,0 +1,57 @@
+package bleve
+
+import (
+	"html"
+
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	simpleFragmenter "github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
+)
+
+// 高亮样式，与 bleve 自带的 html 样式相同，但会对标题、内容做 HTML 转义，只保留 <mark></mark> 标签
+//
+// 注册在全局的 registry 中，不需要修改索引的 mapping，已有的索引也可以直接使用
+const escapedHTMLHighlighter = "bluebell_html"
+
+func init() {
+	registry.RegisterHighlighter(escapedHTMLHighlighter, func(config map[string]interface{}, cache *registry.Cache) (highlight.Highlighter, error) {
+		fragmenter, err := cache.FragmenterNamed(simpleFragmenter.Name)
+		if err != nil {
+			return nil, err
+		}
+		return simpleHighlighter.NewHighlighter(fragmenter, &escapedHTMLFormatter{before: "<mark>", after: "</mark>"}, simpleHighlighter.DefaultSeparator), nil
+	})
+}
+
+type escapedHTMLFormatter struct {
+	before string
+	after  string
+}
+
+// Format 与 bleve 的 html formatter 逻辑相同，只是原文在拼接前先做转义
+func (f *escapedHTMLFormatter) Format(fragment *highlight.Fragment, orderedTermLocations highlight.TermLocations) string {
+	rv := ""
+	curr := fragment.Start
+	for _, termLocation := range orderedTermLocations {
+		if termLocation == nil {
+			continue
+		}
+		if !termLocation.ArrayPositions.Equals(fragment.ArrayPositions) {
+			continue
+		}
+		if termLocation.Start < curr {
+			continue
+		}
+		if termLocation.End > fragment.End {
+			break
+		}
+		rv += html.EscapeString(string(fragment.Orig[curr:termLocation.Start]))
+		rv += f.before
+		rv += html.EscapeString(string(fragment.Orig[termLocation.Start:termLocation.End]))
+		rv += f.after
+		curr = termLocation.End
+	}
+	rv += html.EscapeString(string(fragment.Orig[curr:fragment.End]))
+	return rv
+}
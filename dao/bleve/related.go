@@ -0,0 +1,47 @@
+package bleve
+
+import (
+	"bluebell/models"
+	"context"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// 用于计算相似度的内容长度，内容过长时查询的词项过多，影响性能
+const relatedContentLen = 500
+
+// GetRelatedPosts 以 doc 的标题、内容作为查询，按词项匹配的得分查找相似的帖子，只在同一社区内查找，不包含 doc 本身
+func GetRelatedPosts(ctx context.Context, doc *models.PostDoc, size int) ([]models.PostSimilarity, error) {
+	content := []rune(doc.Content)
+	if len(content) > relatedContentLen {
+		content = content[:relatedContentLen]
+	}
+	title := bleve.NewMatchQuery(doc.Title)
+	title.SetField("title")
+	title.SetBoost(viper.GetFloat64("search.boost.title"))
+	body := bleve.NewMatchQuery(string(content))
+	body.SetField("content")
+	body.SetBoost(viper.GetFloat64("search.boost.content"))
+	community := bleve.NewTermQuery(doc.CommunityID)
+	community.SetField("community_id")
+
+	// 多取一条，结果中可能包含 doc 本身
+	search := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(community, bleve.NewDisjunctionQuery(title, body)), size+1, 0, false)
+	searchResults, err := postIndex.SearchInContext(ctx, search)
+	if err != nil {
+		return nil, errors.Wrap(err, "bleve:GetRelatedPosts: Search")
+	}
+
+	self := strconv.FormatInt(doc.PostID, 10)
+	posts := make([]models.PostSimilarity, 0, len(searchResults.Hits))
+	for _, hit := range searchResults.Hits {
+		if hit.ID == self || len(posts) == size {
+			continue
+		}
+		posts = append(posts, models.PostSimilarity{PostID: hit.ID, Score: hit.Score})
+	}
+	return posts, nil
+}
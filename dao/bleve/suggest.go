@@ -0,0 +1,36 @@
+package bleve
+
+import (
+	"bluebell/models"
+	"context"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/pkg/errors"
+)
+
+// SuggestPostTitles 标题前缀补全
+//
+// 整个标题以 prefix 开头的帖子排在前面，其次是标题中某个词以 prefix 开头的帖子
+func SuggestPostTitles(ctx context.Context, prefix string, size int) ([]models.PostTitleSuggestionDTO, error) {
+	prefix = strings.ToLower(prefix) // 与索引时的 to_lower 保持一致
+	whole := bleve.NewPrefixQuery(prefix)
+	whole.SetField("title_prefix")
+	whole.SetBoost(2)
+	word := bleve.NewPrefixQuery(prefix)
+	word.SetField("title")
+
+	search := bleve.NewSearchRequestOptions(bleve.NewDisjunctionQuery(whole, word), size, 0, false)
+	search.Fields = []string{"title"}
+	searchResults, err := postIndex.SearchInContext(ctx, search)
+	if err != nil {
+		return nil, errors.Wrap(err, "bleve:SuggestPostTitles: Search")
+	}
+
+	titles := make([]models.PostTitleSuggestionDTO, 0, len(searchResults.Hits))
+	for _, hit := range searchResults.Hits {
+		title, _ := hit.Fields["title"].(string)
+		titles = append(titles, models.PostTitleSuggestionDTO{PostID: hit.ID, Title: title})
+	}
+	return titles, nil
+}
@@ -0,0 +1,23 @@
+package bleve
+
+import (
+	"bluebell/models"
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+func CreateUser(doc *models.UserDoc) error {
+	return errors.Wrap(userIndex.Index(strconv.FormatInt(doc.UserID, 10), doc), "bleve:CreateUser: Index")
+}
+
+func DeleteUser(userID int64) error {
+	return errors.Wrap(userIndex.Delete(strconv.FormatInt(userID, 10)), "bleve:DeleteUser: Delete")
+}
+
+// GetUserIDsByKeyword 根据关键字匹配用户名、简介，用户名的权重更高
+func GetUserIDsByKeyword(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	userIDs, total, err := searchIDsByKeyword(ctx, userIndex, params.Keyword, params.PageNum, params.PageSize, "username", "intro")
+	return userIDs, total, errors.Wrap(err, "bleve:GetUserIDsByKeyword: Search")
+}
@@ -0,0 +1,100 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const commentIndexName = "bluebell_comment_index"
+
+func createCommentIndexIfNotExists() error {
+	// obj_id 使用 keyword 类型，只做精确匹配
+	return createIndexIfNotExists(commentIndexName, map[string]any{
+		"comment_id":   map[string]any{"type": "long"},
+		"obj_id":       map[string]any{"type": "keyword"},
+		"message":      map[string]any{"type": "text"},
+		"created_time": map[string]any{"type": "date", "format": "yyyy-MM-dd HH:mm:ss"},
+	})
+}
+
+func CreateComment(doc *models.CommentDoc) error {
+	ctx := context.Background()
+	_, err := clnt.Index(commentIndexName).
+		Id(strconv.FormatInt(doc.CommentID, 10)).
+		Document(doc).
+		Do(ctx)
+	return errors.Wrap(err, "elasticsearch: create comment failed")
+}
+
+func DeleteComments(commentIDs []int64) error {
+	ctx := context.Background()
+	for _, commentID := range commentIDs {
+		// 不存在的文档直接忽略（例如索引功能上线前创建的评论）
+		if _, err := clnt.Delete(commentIndexName, strconv.FormatInt(commentID, 10)).Do(ctx); err != nil {
+			return errors.Wrap(err, "elasticsearch: delete comment failed")
+		}
+	}
+	return nil
+}
+
+func GetCommentIDsByKeyword(ctx context.Context, params *models.ParamCommentSearch) ([]string, int, error) {
+	// 使用 json 序列化构造查询，避免关键字破坏查询结构
+	boolQuery := map[string]any{
+		"must": []any{
+			map[string]any{"match": map[string]any{"message": params.Keyword}},
+		},
+	}
+	if params.ObjID != 0 { // 只在指定帖子的评论中搜索
+		boolQuery["filter"] = []any{
+			map[string]any{"term": map[string]any{"obj_id": strconv.FormatInt(params.ObjID, 10)}},
+		}
+	}
+	query := map[string]any{
+		"query":   map[string]any{"bool": boolQuery},
+		"from":    (params.PageNum - 1) * params.PageSize,
+		"size":    params.PageSize,
+		"_source": false,
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "json: marshal comment query failed")
+	}
+
+	res, err := lowlevelClnt.Search(
+		lowlevelClnt.Search.WithIndex(commentIndexName),
+		lowlevelClnt.Search.WithBody(bytes.NewReader(body)),
+		lowlevelClnt.Search.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "elasticsearch: search comment failed")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, 0, errors.WithStack(&StatusError{Op: "search comment", StatusCode: res.StatusCode})
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, 0, errors.Wrap(err, "json: decoding the response failed")
+	}
+
+	commentIDs := make([]string, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		commentIDs = append(commentIDs, hit.ID)
+	}
+	return commentIDs, resp.Hits.Total.Value, nil
+}
@@ -1,10 +1,16 @@
 package elasticsearch
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
@@ -41,11 +47,32 @@ func Init() {
 		panic("elasticsearch: bluebell_post_index has not been created yet")
 	}
 
+	// 评论、用户、社区索引不存在时自动创建
+	if err := createCommentIndexIfNotExists(); err != nil {
+		panic(err.Error())
+	}
+	if err := createUserIndexIfNotExists(); err != nil {
+		panic(err.Error())
+	}
+	if err := createCommunityIndexIfNotExists(); err != nil {
+		panic(err.Error())
+	}
+
 	// 读取配置
 	activeSecond := viper.GetInt64("service.post.active_time")
 	postActiveDay = int64(math.Ceil(float64(activeSecond) / 86400.0))
 }
 
+// StatusError 请求 es 成功，但返回了错误的状态码
+type StatusError struct {
+	Op         string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("elasticsearch: %v failed, status: %v", e.Op, e.StatusCode)
+}
+
 func GetClnt() *elasticsearch.TypedClient {
 	return clnt
 }
@@ -53,3 +80,70 @@ func GetClnt() *elasticsearch.TypedClient {
 func GetLowLevelClnt() *elasticsearch.Client {
 	return lowlevelClnt
 }
+
+func createIndexIfNotExists(index string, properties map[string]any) error {
+	resp, err := lowlevelClnt.Indices.Exists([]string{index})
+	if err != nil {
+		return errors.Wrapf(err, "elasticsearch: check index %v failed", index)
+	}
+	if resp.StatusCode != 404 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"mappings": map[string]any{"properties": properties},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "json: marshal index %v mapping failed", index)
+	}
+	resp, err = lowlevelClnt.Indices.Create(index, lowlevelClnt.Indices.Create.WithBody(bytes.NewReader(body)))
+	if err != nil {
+		return errors.Wrapf(err, "elasticsearch: create index %v failed", index)
+	}
+	if resp.IsError() {
+		return errors.Errorf("elasticsearch: create index %v failed, status: %v", index, resp.StatusCode)
+	}
+	return nil
+}
+
+// 关键字匹配 fields 中的任意一个字段（字段可以使用 field^boost 指定权重），返回分页后的文档 id 及总数
+func searchIDsByKeyword(ctx context.Context, index, keyword string, pageNum, pageSize int64, fields ...string) ([]string, int, error) {
+	from, size := int((pageNum-1)*pageSize), int(pageSize)
+	req := &search.Request{
+		From:    &from,
+		Size:    &size,
+		Source_: false,
+		Query:   &types.Query{MultiMatch: &types.MultiMatchQuery{Query: keyword, Fields: fields}},
+	}
+	res, err := clnt.Search().
+		Index(index).
+		Request(req).
+		Perform(ctx)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "elasticsearch: search %v failed", index)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return nil, 0, errors.WithStack(&StatusError{Op: "search " + index, StatusCode: res.StatusCode})
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, 0, errors.Wrap(err, "json: decoding the response failed")
+	}
+
+	ids := make([]string, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids, resp.Hits.Total.Value, nil
+}
@@ -0,0 +1,32 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const communityIndexName = "bluebell_community_index"
+
+func createCommunityIndexIfNotExists() error {
+	return createIndexIfNotExists(communityIndexName, map[string]any{
+		"community_id":   map[string]any{"type": "long", "index": false},
+		"community_name": map[string]any{"type": "text"},
+		"introduction":   map[string]any{"type": "text"},
+	})
+}
+
+func CreateCommunity(doc *models.CommunityDoc) error {
+	_, err := clnt.Index(communityIndexName).
+		Id(strconv.FormatInt(doc.CommunityID, 10)).
+		Document(doc).
+		Do(context.Background())
+	return errors.Wrap(err, "elasticsearch: create community failed")
+}
+
+// GetCommunityIDsByKeyword 根据关键字匹配社区名称、简介，名称的权重更高
+func GetCommunityIDsByKeyword(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	return searchIDsByKeyword(ctx, communityIndexName, params.Keyword, params.PageNum, params.PageSize, "community_name^2", "introduction")
+}
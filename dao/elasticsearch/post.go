@@ -7,10 +7,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"strings"
 
 	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/calendarinterval"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/functionboostmode"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/highlighterencoder"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/sortorder"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 )
 
 func CreatePost(doc *models.PostDoc) error {
@@ -42,171 +47,213 @@ func GetPostDetail(PostID int64) (*models.PostDoc, error) {
 	return doc, nil
 }
 
-func GetPostIDsByKeywordOrderByCorrelation(params *models.ParamPostListByKeyword) ([]string, int, error) {
-	query := fmt.Sprintf(`{
-		"query": {
-		  "function_score": {
-			"query": {
-			  "bool": {
-				"should": [
-				  {
-					"match": {
-					  "title": "%s"
-					}
-				  },
-				  {
-					"match": {
-					  "content": "%s"
-					}
-				  }
-				]
-			  }
+// SearchPosts 根据关键字及过滤条件搜索帖子，返回高亮片段，按需返回分面统计
+func SearchPosts(ctx context.Context, params *models.ParamPostListByKeyword) (*models.PostSearchResult, error) {
+	res, err := clnt.Search().
+		Index("bluebell_post_index").
+		Request(buildPostSearchRequest(params)).
+		Perform(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "elasticsearch: search post failed")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return nil, errors.WithStack(&StatusError{Op: "search post", StatusCode: res.StatusCode})
+	}
+
+	resp := new(postSearchResponse)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "json: decoding the response failed")
+	}
+	return resp.toResult(params.WithFacets), nil
+}
+
+// 使用 typed API 构造查询，关键字只作为字段值序列化，不会破坏查询结构
+func buildPostSearchRequest(params *models.ParamPostListByKeyword) *search.Request {
+	// 关键字匹配标题或内容，各字段的权重由配置决定，过滤条件不参与评分
+	titleBoost := float32(viper.GetFloat64("search.boost.title"))
+	contentBoost := float32(viper.GetFloat64("search.boost.content"))
+	boolQuery := &types.BoolQuery{
+		Should: []types.Query{
+			{Match: map[string]types.MatchQuery{"title": {Query: params.Keyword, Boost: &titleBoost}}},
+			{Match: map[string]types.MatchQuery{"content": {Query: params.Keyword, Boost: &contentBoost}}},
+		},
+		MinimumShouldMatch: 1,
+		Filter:             buildPostFilters(params),
+	}
+
+	from := int((params.PageNum - 1) * params.PageSize)
+	size := int(params.PageSize)
+	req := &search.Request{
+		From: &from,
+		Size: &size,
+		Highlight: &types.Highlight{
+			Encoder:  &highlighterencoder.Html, // 转义标题、内容，只保留 <mark></mark> 标签
+			PreTags:  []string{"<mark>"},
+			PostTags: []string{"</mark>"},
+			Fields: map[string]types.HighlightField{
+				"title":   {},
+				"content": {},
 			},
-			"functions": [
-			  {
-				"filter": {
-				  "range": {
-					"created_time": {
-					  "gte": "now-%dd/d",
-					  "lte": "now/d"
-					}
-				  }
-				},
-				"weight": 3
-			  }
-			],
-			"boost_mode": "multiply"
-		  }
 		},
-		"size": 0,
-		"aggs": {
-		  "post_ids": {
-			"terms": {
-			  "field": "post_id",
-			  "order": {
-				"max_score": "desc"
-			  }
+		Source_: false,
+	}
+	if params.OrderBy == "time" {
+		req.Query = &types.Query{Bool: boolQuery}
+		req.Sort = []types.SortCombinations{
+			types.SortOptions{SortOptions: map[string]types.FieldSort{"created_time": {Order: &sortorder.Desc}}},
+			"_score",
+		}
+	} else { // 按相关性排序，活跃期内的帖子权重更高
+		gte := fmt.Sprintf("now-%dd/d", postActiveDay)
+		lte := "now/d"
+		weight := types.Float64(3)
+		req.Query = &types.Query{
+			FunctionScore: &types.FunctionScoreQuery{
+				Query: &types.Query{Bool: boolQuery},
+				Functions: []types.FunctionScore{
+					{
+						Filter: &types.Query{Range: map[string]types.RangeQuery{
+							"created_time": types.DateRangeQuery{Gte: &gte, Lte: &lte},
+						}},
+						Weight: &weight,
+					},
+				},
+				BoostMode: &functionboostmode.Multiply,
 			},
-			"aggs": {
-			  "max_score": {
-				"max": {
-				  "script": {
-					"source": "_score"
-				  }
-				}
-			  }
-			}
-		  }
 		}
-	  }`, params.Keyword, params.Keyword, postActiveDay)
-	return getPostIDsHelper(query, params.PageNum, params.PageSize)
-}
-
-func GetPostIDsByKeywordOrderByTime(params *models.ParamPostListByKeyword) ([]string, int, error) {
-	query := fmt.Sprintf(`{
-		"query": {
-		  "bool": {
-			"should": [
-			  {"match": {"title": "%s"}},
-			  {"match": {"content": "%s"}}
-			]
-		  }
-		},
-		"size": 0,
-		"aggs": {
-		  "post_ids": {
-			"terms": {
-			  "field": "post_id",
-			  "order": [
-				{"_created_time": "desc"},
-				{"max_score": "desc"}
-			  ]
+	}
+	if params.WithFacets {
+		communityField, communitySize := "community_id", 10
+		monthField, monthFormat, minDocCount := "created_time", "yyyy-MM", 1
+		req.Aggregations = map[string]types.Aggregations{
+			"communities": {
+				Terms: &types.TermsAggregation{Field: &communityField, Size: &communitySize},
+			},
+			"months": {
+				DateHistogram: &types.DateHistogramAggregation{
+					Field:            &monthField,
+					CalendarInterval: &calendarinterval.Month,
+					Format:           &monthFormat,
+					MinDocCount:      &minDocCount,
+					Order:            map[string]sortorder.SortOrder{"_key": sortorder.Desc},
+					HardBounds:       &types.ExtendedBoundsFieldDateMath{Min: fmt.Sprintf("now-%dM/M", models.FacetMonthNum-1)}, // 只统计最近的月份，与 bleve 保持一致
+				},
 			},
-			"aggs": {
-			  "max_score": {
-				"max": {
-				  "script": {
-					"source": "_score"
-				  }
-				}
-			  },
-			  "_created_time": {
-				"max": {
-				  "field": "created_time"
-				}
-			  }
-			}
-		  }
 		}
-	  }`, params.Keyword, params.Keyword)
-	return getPostIDsHelper(query, params.PageNum, params.PageSize)
+	}
+	return req
 }
 
-func getPostIDsHelper(query string, pageNum, pageSize int64) ([]string, int, error) {
-	ctx := context.Background()
-
-	res, err := lowlevelClnt.Search(
-		lowlevelClnt.Search.WithIndex("bluebell_post_index"),
-		// lowlevelClnt.Search.WithFrom((int(pageNum - 1) * int(pageSize))), 	// 使用的是聚合桶内的数据，对查询进行分页，没有意义（因为根本就不需要使用查询的数据）
-		// lowlevelClnt.Search.WithSize(int(pageSize)),						    // 在查询体内（RAW Json），会限制 size 为 0，提高性能
-		lowlevelClnt.Search.WithBody(strings.NewReader(query)),
-		lowlevelClnt.Search.WithContext(ctx),
-		// lowlevelClnt.Search.WithFilterPath("aggregations.post_ids.buckets"), // 而不是使用 filterPath
-	)
-	if err != nil {
-		return nil, 0, errors.Wrap(err, "elasticsearch: search post failed")
+func buildPostFilters(params *models.ParamPostListByKeyword) []types.Query {
+	filters := make([]types.Query, 0)
+	if params.CommunityID != 0 {
+		filters = append(filters, types.Query{Term: map[string]types.TermQuery{
+			"community_id": {Value: strconv.FormatInt(params.CommunityID, 10)},
+		}})
 	}
-
-	// 转换为 *search.Response 类型
-	resp := new(search.Response)
-	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
-		return nil, 0, errors.Wrap(err, "json: decoding the response failed")
+	if params.AuthorID != 0 {
+		filters = append(filters, types.Query{Term: map[string]types.TermQuery{
+			"author_id": {Value: strconv.FormatInt(params.AuthorID, 10)},
+		}})
 	}
+	if start, end := params.DateRange(); !start.IsZero() || !end.IsZero() {
+		dateRange := types.DateRangeQuery{}
+		if !start.IsZero() {
+			gte := models.Time(start).String()
+			dateRange.Gte = &gte
+		}
+		if !end.IsZero() {
+			lt := models.Time(end).String()
+			dateRange.Lt = &lt
+		}
+		filters = append(filters, types.Query{Range: map[string]types.RangeQuery{"created_time": dateRange}})
+	}
+	if params.MinVotes > 0 {
+		gte := types.Float64(params.MinVotes)
+		filters = append(filters, types.Query{Range: map[string]types.RangeQuery{
+			"vote_num": types.NumberRangeQuery{Gte: &gte},
+		}})
+	}
+	return filters
+}
 
-	// 解析聚合结果
-	aggregations, found := resp.Aggregations["post_ids"]
-	if !found {
-		return nil, 0, errors.Wrap(bluebell.ErrInternal, "elasticsearch: post_ids aggregation not found")
-	}
-	// 将聚合结果转换为 map
-	postIDsAgg := aggregations.(map[string]any)
-	// 获取桶（buckets）列表
-	buckets := postIDsAgg["buckets"]
+type postSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID        string              `json:"_id"`
+			Highlight map[string][]string `json:"highlight"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Communities struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount int    `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"communities"`
+		Months struct {
+			Buckets []struct {
+				KeyAsString string `json:"key_as_string"`
+				DocCount    int    `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"months"`
+	} `json:"aggregations"`
+}
 
-	// 解析桶数据
-	postIDs := make([]string, 0)
-	for _, bucket := range buckets.([]any) {
-		bucketData := bucket.(map[string]any)
-		key, found := bucketData["key"]
-		if !found {
-			return nil, 0, errors.Wrap(bluebell.ErrInternal, "elasticsearch: key not found in post_ids bucket")
+func (resp *postSearchResponse) toResult(withFacets bool) *models.PostSearchResult {
+	res := &models.PostSearchResult{
+		PostIDs:    make([]string, 0, len(resp.Hits.Hits)),
+		Total:      resp.Hits.Total.Value,
+		Highlights: make(map[string]models.PostHighlightDTO, len(resp.Hits.Hits)),
+	}
+	for _, hit := range resp.Hits.Hits {
+		res.PostIDs = append(res.PostIDs, hit.ID)
+		var highlight models.PostHighlightDTO
+		if fragments := hit.Highlight["title"]; len(fragments) > 0 {
+			highlight.Title = fragments[0]
+		}
+		if fragments := hit.Highlight["content"]; len(fragments) > 0 {
+			highlight.Content = fragments[0]
 		}
-		postID := key.(float64)
-		postIDs = append(postIDs, strconv.FormatInt(int64(postID), 10))
+		if highlight.Title != "" || highlight.Content != "" {
+			res.Highlights[hit.ID] = highlight
+		}
+	}
+	if !withFacets {
+		return res
 	}
 
-	start := (pageNum - 1) * pageSize
-	end := start + pageSize
-	if start != 0 && int(start) >= len(postIDs) { // 错误的分页参数（注意排除搜索结果为空的情况）
-		return nil, 0, errors.Wrap(bluebell.ErrInvalidParam, "elasticsearch: wrong paging parameters")
+	res.Facets = &models.PostFacetsDTO{
+		Communities: make([]models.FacetBucketDTO, 0, len(resp.Aggregations.Communities.Buckets)),
+		Months:      make([]models.FacetBucketDTO, 0, models.FacetMonthNum),
 	}
-	if int(end) > len(postIDs) {
-		end = int64(len(postIDs))
+	for _, bucket := range resp.Aggregations.Communities.Buckets {
+		res.Facets.Communities = append(res.Facets.Communities, models.FacetBucketDTO{Key: bucket.Key, Count: bucket.DocCount})
 	}
-	return postIDs[start:end], len(postIDs), nil
+	for _, bucket := range resp.Aggregations.Months.Buckets {
+		res.Facets.Months = append(res.Facets.Months, models.FacetBucketDTO{Key: bucket.KeyAsString, Count: bucket.DocCount})
+	}
+	return res
 }
 
 func UpdatePost(doc *models.PostDoc) error {
 	ctx := context.Background()
-	_, err := clnt.Update("bluebell_post_index", strconv.FormatInt(doc.PostID, 10)).Doc(doc).Do(ctx)
+	_, err := clnt.Update("bluebell_post_index", strconv.FormatInt(doc.PostID, 10)).Doc(doc).DocAsUpsert(true).Do(ctx) // 文档不存在时直接创建
 	return errors.Wrap(err, "elasticsearch: update failed")
 }
 
 func DeletePost(postID int64) error {
 	ctx := context.Background()
 	resp, err := clnt.Delete("bluebell_post_index", strconv.FormatInt(postID, 10)).Do(ctx)
+	if err != nil {
+		return errors.Wrap(err, "elasticsearch: delete failed")
+	}
 	if resp.Result.Name == "not_found" {
 		return errors.Wrap(bluebell.ErrInternal, "elasticsearch: no such post")
 	}
-	return errors.Wrap(err, "elasticsearch: delete failed")
+	return nil
 }
@@ -0,0 +1,189 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/pkg/errors"
+)
+
+// 包含引号、反斜杠、右括号的关键字，拼接 JSON 时会破坏查询结构或注入新的子句
+const injectionKeyword = `go" } }, { "match_all": {} } ] } }, "x": "\ }`
+
+// fakeES 记录收到的请求，并返回固定的状态码和响应
+type fakeES struct {
+	path string
+	body []byte
+}
+
+func newFakeES(t *testing.T, status int, response string) *fakeES {
+	t.Helper()
+	fake := &fakeES{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read request body failed: %v", err)
+		}
+		fake.path, fake.body = r.URL.Path, body
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Elastic-Product", "Elasticsearch") // 客户端会校验该响应头
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	old := clnt
+	c, err := elasticsearch.NewTypedClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("create client failed: %v", err)
+	}
+	clnt = c
+	t.Cleanup(func() { clnt = old })
+	return fake
+}
+
+// decodeBody 将请求体解析为通用的 map，请求体不是合法的 JSON 时测试失败
+func (fake *fakeES) decodeBody(t *testing.T) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(fake.body, &body); err != nil {
+		t.Fatalf("request body is not valid JSON: %v\n%s", err, fake.body)
+	}
+	return body
+}
+
+// lookup 按路径取出嵌套的字段，路径中的 int 表示数组下标
+func lookup(t *testing.T, v any, path ...any) any {
+	t.Helper()
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				t.Fatalf("lookup %v: %v is not an object", path, v)
+			}
+			v = m[key]
+		case int:
+			a, ok := v.([]any)
+			if !ok || key >= len(a) {
+				t.Fatalf("lookup %v: %v is not an array or index out of range", path, v)
+			}
+			v = a[key]
+		}
+	}
+	return v
+}
+
+func TestSearchPosts(t *testing.T) {
+	const response = `{
+		"hits": {
+			"total": {"value": 2},
+			"hits": [
+				{"_id": "2", "highlight": {"title": ["&lt;b&gt;<mark>go</mark>"]}},
+				{"_id": "1", "highlight": {"content": ["<mark>go</mark>"]}}
+			]
+		}
+	}`
+
+	tests := []struct {
+		name    string
+		orderBy string
+		// 关键字所在的 bool 查询的路径
+		boolPath []any
+	}{
+		{name: "correlation", orderBy: "correlation", boolPath: []any{"query", "function_score", "query", "bool"}},
+		{name: "time", orderBy: "time", boolPath: []any{"query", "bool"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeES(t, http.StatusOK, response)
+			params := &models.ParamPostListByKeyword{
+				PageNum:  2,
+				PageSize: 10,
+				OrderBy:  tt.orderBy,
+				Keyword:  injectionKeyword,
+			}
+			res, err := SearchPosts(context.Background(), params)
+			if err != nil {
+				t.Fatalf("SearchPosts failed: %v", err)
+			}
+
+			if fake.path != "/bluebell_post_index/_search" {
+				t.Errorf("path = %q, want /bluebell_post_index/_search", fake.path)
+			}
+			body := fake.decodeBody(t)
+			boolQuery := lookup(t, body, tt.boolPath...)
+			should := lookup(t, boolQuery, "should").([]any)
+			if len(should) != 2 {
+				t.Fatalf("len(should) = %d, want 2: %s", len(should), fake.body)
+			}
+			for i, field := range []string{"title", "content"} {
+				if got := lookup(t, should[i], "match", field, "query"); got != injectionKeyword {
+					t.Errorf("should[%d].match.%v.query = %q, want %q", i, field, got, injectionKeyword)
+				}
+			}
+			if got := lookup(t, body, "from"); got != float64(10) {
+				t.Errorf("from = %v, want 10", got)
+			}
+			if got := lookup(t, body, "highlight", "encoder"); got != "html" {
+				t.Errorf("highlight.encoder = %v, want html", got)
+			}
+			if tt.orderBy == "time" {
+				if got := lookup(t, body, "sort", 0, "created_time", "order"); got != "desc" {
+					t.Errorf("sort[0].created_time.order = %v, want desc", got)
+				}
+			} else if _, ok := body["sort"]; ok {
+				t.Errorf("sort should not be set when ordering by correlation: %s", fake.body)
+			}
+
+			if len(res.PostIDs) != 2 || res.PostIDs[0] != "2" || res.PostIDs[1] != "1" {
+				t.Errorf("PostIDs = %v, want [2 1]", res.PostIDs)
+			}
+			if res.Total != 2 {
+				t.Errorf("Total = %d, want 2", res.Total)
+			}
+			if got := res.Highlights["2"].Title; got != "&lt;b&gt;<mark>go</mark>" {
+				t.Errorf("Highlights[2].Title = %q", got)
+			}
+		})
+	}
+}
+
+func TestSearchPostsErrorStatus(t *testing.T) {
+	newFakeES(t, http.StatusBadRequest, `{"error": {"type": "parsing_exception"}}`)
+	params := &models.ParamPostListByKeyword{PageNum: 1, PageSize: 10, OrderBy: "time", Keyword: injectionKeyword}
+	_, err := SearchPosts(context.Background(), params)
+	if err == nil {
+		t.Fatal("SearchPosts should fail when elasticsearch returns 400")
+	}
+	// 熔断器根据状态码区分查询错误和引擎故障
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want StatusError with status 400", err)
+	}
+}
+
+func TestSuggestPostTitles(t *testing.T) {
+	fake := newFakeES(t, http.StatusOK, `{"hits": {"hits": [{"_id": "3", "_source": {"title": "golang"}}]}}`)
+	titles, err := SuggestPostTitles(context.Background(), injectionKeyword, 5)
+	if err != nil {
+		t.Fatalf("SuggestPostTitles failed: %v", err)
+	}
+
+	body := fake.decodeBody(t)
+	if got := lookup(t, body, "query", "match", "title.suggest", "query"); got != injectionKeyword {
+		t.Errorf("query.match.title.suggest.query = %q, want %q", got, injectionKeyword)
+	}
+	if got := lookup(t, body, "size"); got != float64(5) {
+		t.Errorf("size = %v, want 5", got)
+	}
+	if len(titles) != 1 || titles[0].PostID != "3" || titles[0].Title != "golang" {
+		t.Errorf("titles = %+v", titles)
+	}
+}
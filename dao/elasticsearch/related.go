@@ -0,0 +1,65 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/pkg/errors"
+)
+
+// GetRelatedPosts 使用 more_like_this 查找与 doc 内容相似的帖子，只在同一社区内查找，不包含 doc 本身
+func GetRelatedPosts(ctx context.Context, doc *models.PostDoc, size int) ([]models.PostSimilarity, error) {
+	index, id := "bluebell_post_index", strconv.FormatInt(doc.PostID, 10)
+	minFreq, maxQueryTerms := 1, 25 // 帖子数量较少时，默认的 min_doc_freq(5) 会过滤掉大部分词
+	req := &search.Request{
+		Size:    &size,
+		Source_: false,
+		Query: &types.Query{Bool: &types.BoolQuery{
+			Must: []types.Query{
+				{MoreLikeThis: &types.MoreLikeThisQuery{
+					Fields:        []string{"title", "content"},
+					Like:          []types.Like{types.LikeDocument{Index_: &index, Id_: &id}},
+					MinTermFreq:   &minFreq,
+					MinDocFreq:    &minFreq,
+					MaxQueryTerms: &maxQueryTerms,
+				}},
+			},
+			Filter: []types.Query{
+				{Term: map[string]types.TermQuery{"community_id": {Value: doc.CommunityID}}},
+			},
+		}},
+	}
+	res, err := clnt.Search().
+		Index(index).
+		Request(req).
+		Perform(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "elasticsearch: search related post failed")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return nil, errors.WithStack(&StatusError{Op: "search related post", StatusCode: res.StatusCode})
+	}
+
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				ID    string  `json:"_id"`
+				Score float64 `json:"_score"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "json: decoding the response failed")
+	}
+
+	posts := make([]models.PostSimilarity, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		posts = append(posts, models.PostSimilarity{PostID: hit.ID, Score: hit.Score})
+	}
+	return posts, nil
+}
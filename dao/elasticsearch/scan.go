@@ -0,0 +1,132 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const scrollKeepAlive = time.Minute
+
+type idsResponse struct {
+	ScrollID string `json:"_scroll_id"`
+	Hits     struct {
+		Hits []struct {
+			ID string `json:"_id"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// CreatePosts 批量写入帖子
+func CreatePosts(docs []*models.PostDoc) error {
+	for _, doc := range docs {
+		if err := CreatePost(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetExistingPostIDs 返回 postIDs 中已被索引的帖子
+func GetExistingPostIDs(postIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(postIDs))
+	if len(postIDs) == 0 {
+		return existing, nil
+	}
+	body, err := json.Marshal(map[string]any{
+		"query":   map[string]any{"ids": map[string]any{"values": postIDs}},
+		"size":    len(postIDs),
+		"_source": false,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "json: marshal ids query failed")
+	}
+
+	res, err := lowlevelClnt.Search(
+		lowlevelClnt.Search.WithIndex("bluebell_post_index"),
+		lowlevelClnt.Search.WithBody(bytes.NewReader(body)),
+		lowlevelClnt.Search.WithContext(context.Background()),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "elasticsearch: search post ids failed")
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, errors.Errorf("elasticsearch: search post ids failed, status: %v", res.StatusCode)
+	}
+
+	resp := new(idsResponse)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "json: decoding the response failed")
+	}
+	for _, hit := range resp.Hits.Hits {
+		existing[hit.ID] = true
+	}
+	return existing, nil
+}
+
+// ScanPostIDs 使用 scroll 分批遍历索引中的所有帖子
+func ScanPostIDs(batchSize int, fn func(postIDs []string) error) error {
+	body, err := json.Marshal(map[string]any{
+		"query":   map[string]any{"match_all": map[string]any{}},
+		"size":    batchSize,
+		"sort":    []string{"_doc"}, // 不需要排序，遍历效率最高
+		"_source": false,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json: marshal scan query failed")
+	}
+
+	ctx := context.Background()
+	res, err := lowlevelClnt.Search(
+		lowlevelClnt.Search.WithIndex("bluebell_post_index"),
+		lowlevelClnt.Search.WithBody(bytes.NewReader(body)),
+		lowlevelClnt.Search.WithScroll(scrollKeepAlive),
+		lowlevelClnt.Search.WithContext(ctx),
+	)
+	scrollID := ""
+	defer func() {
+		if scrollID != "" {
+			if res, err := lowlevelClnt.ClearScroll(lowlevelClnt.ClearScroll.WithScrollID(scrollID)); err == nil {
+				res.Body.Close()
+			}
+		}
+	}()
+	for {
+		if err != nil {
+			return errors.Wrap(err, "elasticsearch: scroll post ids failed")
+		}
+		resp := new(idsResponse)
+		if res.IsError() {
+			res.Body.Close()
+			return errors.Errorf("elasticsearch: scroll post ids failed, status: %v", res.StatusCode)
+		}
+		err = json.NewDecoder(res.Body).Decode(resp)
+		res.Body.Close()
+		if err != nil {
+			return errors.Wrap(err, "json: decoding the response failed")
+		}
+		scrollID = resp.ScrollID
+		if len(resp.Hits.Hits) == 0 {
+			return nil
+		}
+
+		postIDs := make([]string, 0, len(resp.Hits.Hits))
+		for _, hit := range resp.Hits.Hits {
+			postIDs = append(postIDs, hit.ID)
+		}
+		if err := fn(postIDs); err != nil {
+			return err
+		}
+
+		res, err = lowlevelClnt.Scroll(
+			lowlevelClnt.Scroll.WithScrollID(scrollID),
+			lowlevelClnt.Scroll.WithScroll(scrollKeepAlive),
+			lowlevelClnt.Scroll.WithContext(ctx),
+		)
+	}
+}
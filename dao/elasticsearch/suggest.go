@@ -0,0 +1,62 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"context"
+	"encoding/json"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/search"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/operator"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/sortorder"
+	"github.com/pkg/errors"
+)
+
+// SuggestPostTitles 标题前缀补全
+//
+// title.suggest 使用 edge_ngram 分词，标题中每个词的前缀都会被索引，
+// 查询时 prefix 的每个词都要命中，得分相同时赞成票数多的帖子排在前面
+func SuggestPostTitles(ctx context.Context, prefix string, size int) ([]models.PostTitleSuggestionDTO, error) {
+	req := &search.Request{
+		Size:    &size,
+		Source_: []string{"title"},
+		Query: &types.Query{Match: map[string]types.MatchQuery{
+			"title.suggest": {Query: prefix, Operator: &operator.And},
+		}},
+		Sort: []types.SortCombinations{
+			"_score",
+			types.SortOptions{SortOptions: map[string]types.FieldSort{"vote_num": {Order: &sortorder.Desc}}},
+		},
+	}
+	res, err := clnt.Search().
+		Index("bluebell_post_index").
+		Request(req).
+		Perform(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "elasticsearch: suggest post failed")
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return nil, errors.WithStack(&StatusError{Op: "suggest post", StatusCode: res.StatusCode})
+	}
+
+	var resp struct {
+		Hits struct {
+			Hits []struct {
+				ID     string `json:"_id"`
+				Source struct {
+					Title string `json:"title"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "json: decoding the response failed")
+	}
+
+	titles := make([]models.PostTitleSuggestionDTO, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		titles = append(titles, models.PostTitleSuggestionDTO{PostID: hit.ID, Title: hit.Source.Title})
+	}
+	return titles, nil
+}
@@ -0,0 +1,38 @@
+package elasticsearch
+
+import (
+	"bluebell/models"
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const userIndexName = "bluebell_user_index"
+
+func createUserIndexIfNotExists() error {
+	return createIndexIfNotExists(userIndexName, map[string]any{
+		"user_id":  map[string]any{"type": "long", "index": false},
+		"username": map[string]any{"type": "text"},
+		"intro":    map[string]any{"type": "text"},
+	})
+}
+
+func CreateUser(doc *models.UserDoc) error {
+	_, err := clnt.Index(userIndexName).
+		Id(strconv.FormatInt(doc.UserID, 10)).
+		Document(doc).
+		Do(context.Background())
+	return errors.Wrap(err, "elasticsearch: create user failed")
+}
+
+func DeleteUser(userID int64) error {
+	// 不存在的文档直接忽略（例如索引功能上线前注册的用户）
+	_, err := clnt.Delete(userIndexName, strconv.FormatInt(userID, 10)).Do(context.Background())
+	return errors.Wrap(err, "elasticsearch: delete user failed")
+}
+
+// GetUserIDsByKeyword 根据关键字匹配用户名、简介，用户名的权重更高
+func GetUserIDsByKeyword(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	return searchIDsByKeyword(ctx, userIndexName, params.Keyword, params.PageNum, params.PageSize, "username^2", "intro")
+}
@@ -0,0 +1,25 @@
+package email
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+const accountLockedBody = `<p>%s，您好：</p>
+<p>您的账户在短时间内多次登录失败，为保护账户安全，已被锁定 %v。</p>
+<p>锁定时间：%s</p>
+<p>如果不是您本人的操作，建议尽快修改密码。</p>`
+
+func GetAccountLockedMailBody(to, userName string, lockDuration time.Duration, lockedAt time.Time) *gomail.Message {
+	body := fmt.Sprintf(accountLockedBody, userName, lockDuration, lockedAt.Format("2006-01-02 15:04:05"))
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", username)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", "Account Locked")
+	m.SetBody("text/html", body)
+
+	return m
+}
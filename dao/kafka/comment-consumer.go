@@ -5,11 +5,13 @@ import (
 	"bluebell/dao/mysql"
 	"bluebell/dao/rebuild"
 	"bluebell/dao/redis"
+	"bluebell/dao/search"
 	"bluebell/internal/utils"
 	"bluebell/logger"
 	"bluebell/models"
 	"bluebell/objects"
 	"fmt"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -111,6 +113,13 @@ func createComment(tx *gorm.DB, params CommentCreate) (res Result) {
 
 	if err = mysql.CreateCommentIndex(tx, index); err != nil {
 		res.Err = errors.Wrap(err, "kafka:CreateComment: CreateCommentIndex")
+		return
+	}
+
+	// 递增评论者的评论数
+	if err = mysql.IncrUserStatField(tx, params.UserID, models.UserStatCommentCount, 1); err != nil {
+		res.Err = errors.Wrap(err, "kafka:CreateComment: IncrUserStatField")
+		return
 	}
 
 	// 写缓存
@@ -131,6 +140,16 @@ func createComment(tx *gorm.DB, params CommentCreate) (res Result) {
 	if err = redis.AddCommentContents([]int64{params.CommentID}, []string{params.Message}); err != nil {
 		logger.Warnf("kafka:CreateComment: AddCommentContent, reason: %v", err.Error())
 	}
+
+	// 写搜索引擎（失败不影响评论的创建）
+	doc := &models.CommentDoc{
+		CommentID: params.CommentID,
+		ObjID:     strconv.FormatInt(params.ObjID, 10),
+		Message:   params.Message,
+	}
+	if err = search.IndexComment(doc); err != nil {
+		logger.Warnf("kafka:CreateComment: IndexComment, reason: %v", err.Error())
+	}
 	return
 }
 
@@ -167,10 +186,20 @@ func removeComment(tx *gorm.DB, params CommentRemove) (res Result) {
 		return
 	}
 
+	// 删除前按评论者统计评论数，用于递减 comment_count
+	userCommentCounts, err := mysql.SelectCommentCountGroupByUserID(tx, commentIDs)
+	if err != nil {
+		res.Err = errors.Wrap(err, "kafka:RemoveComment: SelectCommentCountGroupByUserID")
+		return
+	}
 	if err := mysql.DeleteCommentIndexByCommentIDs(tx, commentIDs); err != nil {
 		res.Err = errors.Wrap(err, "kafka:RemoveComment: DeleteCommentIndexByCommentIDs")
 		return
 	}
+	if err := decrUserCommentCount(tx, userCommentCounts); err != nil {
+		res.Err = errors.Wrap(err, "kafka:RemoveComment: decrUserCommentCount")
+		return
+	}
 	if err := mysql.DeleteCommentContentByCommentIDs(tx, commentIDs); err != nil {
 		res.Err = errors.Wrap(err, "kafka:RemoveComment: DeleteCommentContentByCommentIDs")
 		return
@@ -201,6 +230,11 @@ func removeComment(tx *gorm.DB, params CommentRemove) (res Result) {
 	localcache.GetLocalCache().Remove(cacheKey)
 	localcache.RemoveObjectView(objects.ObjComment, params.CommentID)
 
+	// 删搜索引擎中的索引
+	if err := search.DeleteComments(commentIDs); err != nil {
+		logger.Warnf("kafka:RemoveComment: DeleteComments, reason: %v", err.Error())
+	}
+
 	return
 }
 
@@ -218,11 +252,21 @@ func removeCommentsByObjID(tx *gorm.DB, params CommentRemoveByObjID) (res Result
 		res.Err = errors.Wrap(err, "kafka:removeCommentsByObjID: DeleteCommentSubjectByObjID")
 		return
 	}
+	// 删除前按评论者统计评论数，用于递减 comment_count
+	userCommentCounts, err := mysql.SelectCommentCountGroupByUserID(tx, commentIDs)
+	if err != nil {
+		res.Err = errors.Wrap(err, "kafka:removeCommentsByObjID: SelectCommentCountGroupByUserID")
+		return
+	}
 	// 删除 comment_indices 表
 	if err := mysql.DeleteCommentIndexByObjID(tx, params.ObjID, params.ObjType); err != nil {
 		res.Err = errors.Wrap(err, "kafka:removeCommentsByObjID: DeleteCommentIndexByObjID")
 		return
 	}
+	if err := decrUserCommentCount(tx, userCommentCounts); err != nil {
+		res.Err = errors.Wrap(err, "kafka:removeCommentsByObjID: decrUserCommentCount")
+		return
+	}
 	// 删除 comment_content 表
 	if err := mysql.DeleteCommentContentByCommentIDs(tx, commentIDs); err != nil {
 		res.Err = errors.Wrap(err, "kafka:removeCommentsByObjID: DeleteCommentContentByObjID")
@@ -254,5 +298,65 @@ func removeCommentsByObjID(tx *gorm.DB, params CommentRemoveByObjID) (res Result
 		localcache.RemoveObjectView(objects.ObjComment, commentIDs[i])
 	}
 
+	// 删搜索引擎中的索引
+	if err := search.DeleteComments(commentIDs); err != nil {
+		logger.Warnf("kafka:removeCommentsByObjID: DeleteComments, reason: %v", err.Error())
+	}
+
 	return
-}
\ No newline at end of file
+}
+
+// 评论创建后，通知被回复的评论的作者，或帖子的作者（根评论），返回的函数在事务提交后发送通知，不需要通知时返回 nil
+func notifyCommentCreated(tx *gorm.DB, params CommentCreate) afterCommitFunc {
+	notification := NotificationCreate{
+		ActorID: params.UserID,
+		PostID:  params.ObjID,
+		Content: utils.Substr(params.Message, 0, 64),
+	}
+
+	var err error
+	if params.Parent != 0 {
+		notification.Type = models.NotificationReplyComment
+		notification.ObjID = params.Parent
+		notification.UserID, err = mysql.SelectUserIDByCommentID(tx, params.Parent)
+	} else if params.ObjType == objects.ObjPost {
+		notification.Type = models.NotificationReplyPost
+		notification.ObjID = params.ObjID
+		var post *models.Post
+		if post, err = mysql.SelectPostByID(params.ObjID); err == nil {
+			notification.UserID = post.AuthorID
+		}
+	} else {
+		return nil
+	}
+	if err != nil {
+		logger.Warnf("kafka:notifyCommentCreated: get receiver failed, reason: %v", err.Error())
+		return nil
+	}
+	if notification.UserID == 0 || notification.UserID == params.UserID { // 自己回复自己，不通知
+		return nil
+	}
+
+	return func() {
+		go func() {
+			if err := SendNotification(notification); err != nil {
+				logger.Warnf("kafka:notifyCommentCreated: SendNotification, reason: %v", err.Error())
+			}
+		}()
+	}
+}
+
+// 实时推送给订阅了该帖子的客户端
+func publishCommentCreated(params CommentCreate) {
+	if params.ObjType != objects.ObjPost {
+		return
+	}
+	err := redis.PublishStreamEvent(models.StreamEvent{
+		Type:   models.StreamEventComment,
+		PostID: params.ObjID,
+		Data:   params,
+	})
+	if err != nil {
+		logger.Warnf("kafka:publishCommentCreated: PublishStreamEvent, reason: %v", err.Error())
+	}
+}
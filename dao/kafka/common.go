@@ -20,6 +20,10 @@ const (
 	TypeLikeOrHateMappingCreate
 	TypeLikeOrHateMappingRemove
 	TypeEmailSendVerificationCode
+	TypeEmailSendAccountLocked
+	TypeNotificationCreate
+	TypeDirectMessageCreate
+	TypeUserStatIncr
 )
 
 const (
@@ -29,29 +33,37 @@ const (
 )
 
 const (
-	TopicComment = "topic-comment"
-	TopicLike    = "topic-like"
-	TopicEmail   = "topic-email"
+	TopicComment       = "topic-comment"
+	TopicLike          = "topic-like"
+	TopicEmail         = "topic-email"
+	TopicNotification  = "topic-notification"
+	TopicDirectMessage = "topic-direct-message"
 )
 
 const (
-	GroupComment = "group-comment"
-	GroupLike    = "group-like"
-	GroupEmail   = "group-email"
+	GroupComment       = "group-comment"
+	GroupLike          = "group-like"
+	GroupEmail         = "group-email"
+	GroupNotification  = "group-notification"
+	GroupDirectMessage = "group-direct-message"
 )
 
 var addr []string
 
 var (
-	PartitionNumOfComment = 6
-	PartitionNumOfLike    = 6
-	PartitionNumOfEmail   = 2
+	PartitionNumOfComment       = 6
+	PartitionNumOfLike          = 6
+	PartitionNumOfEmail         = 2
+	PartitionNumOfNotification  = 6
+	PartitionNumOfDirectMessage = 6
 )
 
 var (
-	ReplicationFactorOfComment = 1
-	ReplicationFactorOfLike    = 1
-	ReplicationFactorOfEmail   = 1
+	ReplicationFactorOfComment       = 1
+	ReplicationFactorOfLike          = 1
+	ReplicationFactorOfEmail         = 1
+	ReplicationFactorOfNotification  = 1
+	ReplicationFactorOfDirectMessage = 1
 )
 
 var (
@@ -72,6 +84,8 @@ type Result struct {
 var commentWriter *kafka.Writer
 var likeWriter *kafka.Writer
 var emailWriter *kafka.Writer
+var notificationWriter *kafka.Writer
+var directMessageWriter *kafka.Writer
 
 var notifyList []chan int
 
@@ -97,18 +111,32 @@ func InitKafka() {
 		Balancer: &kafka.RoundRobin{},
 	}
 
+	notificationWriter = &kafka.Writer{
+		Addr:     kafka.TCP(addr...),
+		Balancer: &kafka.Hash{}, // 哈希，保证同一个用户的通知在同一个 partition，串行聚合
+	}
+
+	directMessageWriter = &kafka.Writer{
+		Addr:     kafka.TCP(addr...),
+		Balancer: &kafka.Hash{}, // 哈希，保证同一个会话的私信在同一个 partition，有序写入
+	}
+
 	// 初始化通知列表
-	notifyList = make([]chan int, 0, PartitionNumOfComment+PartitionNumOfLike+PartitionNumOfEmail)
+	notifyList = make([]chan int, 0, PartitionNumOfComment+PartitionNumOfLike+PartitionNumOfEmail+PartitionNumOfNotification+PartitionNumOfDirectMessage)
 
 	// 创建主题
 	createTopic(TopicComment, PartitionNumOfComment, ReplicationFactorOfComment)
 	createTopic(TopicLike, PartitionNumOfLike, ReplicationFactorOfLike)
 	createTopic(TopicEmail, PartitionNumOfEmail, ReplicationFactorOfEmail)
+	createTopic(TopicNotification, PartitionNumOfNotification, ReplicationFactorOfNotification)
+	createTopic(TopicDirectMessage, PartitionNumOfDirectMessage, ReplicationFactorOfDirectMessage)
 
 	// 初始化 consumer
 	initConsumer(PartitionNumOfComment, TopicComment, GroupComment)
 	initConsumer(PartitionNumOfLike, TopicLike, GroupLike)
 	initConsumer(PartitionNumOfEmail, TopicEmail, GroupEmail)
+	initConsumer(PartitionNumOfNotification, TopicNotification, GroupNotification)
+	initConsumer(PartitionNumOfDirectMessage, TopicDirectMessage, GroupDirectMessage)
 }
 
 func Wait() {
@@ -147,10 +175,14 @@ func initConfig() {
 	PartitionNumOfComment = viper.GetInt("kafka.partition.comment")
 	PartitionNumOfLike = viper.GetInt("kafka.partition.like")
 	PartitionNumOfLike = viper.GetInt("kafka.partition.email")
+	PartitionNumOfNotification = viper.GetInt("kafka.partition.notification")
+	PartitionNumOfDirectMessage = viper.GetInt("kafka.partition.direct_message")
 
 	ReplicationFactorOfComment = viper.GetInt("kafka.replication_factor.comment")
 	ReplicationFactorOfLike = viper.GetInt("kafka.replication_factor.like")
 	ReplicationFactorOfLike = viper.GetInt("kafka.replication_factor.email")
+	ReplicationFactorOfNotification = viper.GetInt("kafka.replication_factor.notification")
+	ReplicationFactorOfDirectMessage = viper.GetInt("kafka.replication_factor.direct_message")
 
 	KafkaProducerRetryTime = viper.GetInt("kafka.retry.producer")
 	KafkaConsumerRetryTime = viper.GetInt("kafka.retry.consumer")
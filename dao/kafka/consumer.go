@@ -3,6 +3,7 @@ package kafka
 import (
 	"bluebell/dao/localcache"
 	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
 	"bluebell/logger"
 	"context"
 	"encoding/json"
@@ -18,6 +19,9 @@ import (
 	kafka-consumer 的基本操作
 */
 
+// 事务提交后才能执行的操作（发送通知、实时推送、删除缓存等），事务回滚时丢弃，避免读到未提交的数据
+type afterCommitFunc func()
+
 // 串行消费模型
 func basicSerialConsumerWork(ch chan int, consumer *kafka.Reader) {
 	defer wg.Done()
@@ -50,10 +54,14 @@ rootloop:
 			err = nil
 			successKeys := make([]string, 0, len(msgs))
 			failedKeys := make([]string, 0, len(msgs)) // 保存因 conver error 造成失败的 commentID
-			tx := mysql.GetDB().Begin()                // 一批消息一个大的事务，整体成功或失败
+			afterCommits := make([]afterCommitFunc, 0)
+			tx := mysql.GetDB().Begin() // 一批消息一个大的事务，整体成功或失败
 
 			task := func(_msg kafka.Message) {
-				uniqueKey, errorType, err1 := convertAndConsume(tx, _msg)
+				uniqueKey, errorType, afterCommit, err1 := convertAndConsume(tx, _msg)
+				if afterCommit != nil && err1 == nil {
+					afterCommits = append(afterCommits, afterCommit)
+				}
 				if err1 != nil {
 					if errorType == ErrTypeTransaction {
 						err = errors.Wrap(err1, "kafka:CommentConsumer: convertAndConsume") // 保存事务中产生的错误
@@ -84,9 +92,18 @@ rootloop:
 				continue
 			}
 
-			tx.Commit()
+			if err = tx.Commit().Error; err != nil {
+				logger.Errorf("kafka:CommentConsumer: commit error: %v", err.Error())
+				time.Sleep(time.Second)
+				continue
+			}
 			// logger.Debugf("事务提交")
 
+			// 事务提交成功后，才执行通知、推送等操作
+			for _, afterCommit := range afterCommits {
+				afterCommit()
+			}
+
 			// 添加状态信息到 localcache 中
 			for _, key := range successKeys {
 				localcache.SetStatus(key, localcache.StatusSuccess)
@@ -113,12 +130,12 @@ rootloop:
 	}
 }
 
-// 返回 uniqueKey、error_type、error （可能是 convert，也可能是 consume）
-func convertAndConsume(tx *gorm.DB, msg kafka.Message) (string, int, error) {
+// 返回 uniqueKey、error_type、事务提交后执行的操作（可能为 nil）、error （可能是 convert，也可能是 consume）
+func convertAndConsume(tx *gorm.DB, msg kafka.Message) (string, int, afterCommitFunc, error) {
 	var metadata Message
 	err := json.Unmarshal(msg.Value, &metadata)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:convertAndConsume: Unmarshal(metadata)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:convertAndConsume: Unmarshal(metadata)")
 	}
 	// tmp := metadata.Data.(map[string]any)
 	// logger.Debugf("comment_id in Message.Data: %v", tmp["comment_id"])
@@ -146,9 +163,21 @@ func convertAndConsume(tx *gorm.DB, msg kafka.Message) (string, int, error) {
 
 	case TypeEmailSendVerificationCode:
 		return handleEmailSendVerificationCode(data)
+
+	case TypeEmailSendAccountLocked:
+		return handleEmailSendAccountLocked(data)
+
+	case TypeNotificationCreate:
+		return handleNotificationCreate(tx, data)
+
+	case TypeDirectMessageCreate:
+		return handleDirectMessageCreate(tx, data)
+
+	case TypeUserStatIncr:
+		return handleUserStatIncr(tx, data)
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, nil, nil
 }
 
 // sync
@@ -177,103 +206,191 @@ func fetchMessages(ctx context.Context, reader *kafka.Reader, n int) ([]kafka.Me
 	return list, nil
 }
 
-func handleCommentCreate(tx *gorm.DB, data []byte) (string, int, error) {
+func handleCommentCreate(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
 	var params CommentCreate
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleCommentCreate: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleCommentCreate: Unmarshal(params)")
 	}
 
 	res := createComment(tx, params)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleCommentCreate: createComment")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleCommentCreate: createComment")
 	}
+	notify := notifyCommentCreated(tx, params)
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, func() {
+		if notify != nil {
+			notify()
+		}
+		publishCommentCreated(params)
+	}, nil
 }
 
-func handleCommentRemove(tx *gorm.DB, data []byte) (string, int, error) {
+func handleCommentRemove(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
 	var params CommentRemove
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleCommentRemove: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleCommentRemove: Unmarshal(params)")
 	}
 	res := removeComment(tx, params)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleCommentRemove: removeComment")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleCommentRemove: removeComment")
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, nil, nil
 }
 
-func handleCommentRemoveByObjID(tx *gorm.DB, data []byte) (string, int, error) {
+func handleCommentRemoveByObjID(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
 	var params CommentRemoveByObjID
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleCommentRemoveByObjID: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleCommentRemoveByObjID: Unmarshal(params)")
 	}
 	res := removeCommentsByObjID(tx, params)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleCommentRemoveByObjID: removeCommentsByObjID")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleCommentRemoveByObjID: removeCommentsByObjID")
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, nil, nil
 }
 
-func handleLikeOrHateIncr(tx *gorm.DB, data []byte) (string, int, error) {
+func handleLikeOrHateIncr(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
 	var params LikeOrHateIncr
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleLikeOrHateIncr: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleLikeOrHateIncr: Unmarshal(params)")
 	}
 	res := incrCommentIndexCountField(tx, params.Field, params.CommentID, params.Offset)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleLikeOrHateIncr: incrCommentIndexCountField")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleLikeOrHateIncr: incrCommentIndexCountField")
+	}
+	if params.Field == "`like`" {
+		res = incrCommentAuthorLikeCount(tx, params)
+		if res.Err != nil {
+			return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleLikeOrHateIncr: incrCommentAuthorLikeCount")
+		}
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, nil, nil
 }
 
-func handleLikeOrHateMappingCreate(tx *gorm.DB, data []byte) (string, int, error) {
+func handleLikeOrHateMappingCreate(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
 	var params LikeOrHateMappingCreate
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleLikeOrHateMappingCreate: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleLikeOrHateMappingCreate: Unmarshal(params)")
 	}
 	res := createCommentLikeOrHateUser(tx, params.CommentID, params.UserID, params.ObjID, params.ObjType, params.Like)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleLikeOrHateMappingCreate: incrCommentIndexCountField")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleLikeOrHateMappingCreate: incrCommentIndexCountField")
+	}
+	var notify afterCommitFunc
+	if params.Like {
+		notify = notifyCommentLiked(tx, params)
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, notify, nil
 }
 
-func handleLikeOrHateMappingRemove(tx *gorm.DB, data []byte) (string, int, error) {
+func handleLikeOrHateMappingRemove(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
 	var params LikeOrHateMappingRemove
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleLikeOrHateMappingRemoveByCommentIDs: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleLikeOrHateMappingRemoveByCommentIDs: Unmarshal(params)")
 	}
 
 	res := removeCommentUserLikeMappingByCommentIDs(tx, params.CommentID)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleLikeOrHateMappingRemoveByCommentIDs: incrCommentIndexCountField")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleLikeOrHateMappingRemoveByCommentIDs: incrCommentIndexCountField")
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, nil, nil
 }
 
-func handleEmailSendVerificationCode(data []byte) (string, int, error) {
+func handleEmailSendVerificationCode(data []byte) (string, int, afterCommitFunc, error) {
 	var params EmailSendVerificationCode
 	err := json.Unmarshal(data, &params)
 	if err != nil {
-		return "", ErrTypeConvert, errors.Wrap(err, "kafka:handleEmailSend: Unmarshal(params)")
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleEmailSend: Unmarshal(params)")
 	}
 
 	res := sendEmailVerificationCode(params)
 	if res.Err != nil {
-		return "", ErrTypeTransaction, errors.Wrap(res.Err, "kafka:handleEmailSend: sendEmail")
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleEmailSend: sendEmail")
+	}
+
+	return res.UniqueKey, ErrTypeNoError, nil, nil
+}
+
+func handleEmailSendAccountLocked(data []byte) (string, int, afterCommitFunc, error) {
+	var params EmailSendAccountLocked
+	err := json.Unmarshal(data, &params)
+	if err != nil {
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleEmailSendAccountLocked: Unmarshal(params)")
+	}
+
+	res := sendAccountLockedEmail(params)
+	if res.Err != nil {
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleEmailSendAccountLocked: sendAccountLockedEmail")
+	}
+
+	return res.UniqueKey, ErrTypeNoError, nil, nil
+}
+
+func handleNotificationCreate(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
+	var params NotificationCreate
+	err := json.Unmarshal(data, &params)
+	if err != nil {
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleNotificationCreate: Unmarshal(params)")
+	}
+
+	res, created := createNotification(tx, params)
+	if res.Err != nil {
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleNotificationCreate: createNotification")
+	}
+
+	return res.UniqueKey, ErrTypeNoError, func() {
+		if created { // 新建了通知，未读数发生变化
+			if err := redis.DelNotificationUnreadCount(params.UserID); err != nil {
+				logger.Warnf("kafka:handleNotificationCreate: DelNotificationUnreadCount, reason: %v", err.Error())
+			}
+		}
+		publishNotificationCreated(params)
+	}, nil
+}
+
+func handleDirectMessageCreate(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
+	var params DirectMessageCreate
+	err := json.Unmarshal(data, &params)
+	if err != nil {
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleDirectMessageCreate: Unmarshal(params)")
+	}
+
+	res, created := createDirectMessage(tx, params)
+	if res.Err != nil {
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleDirectMessageCreate: createDirectMessage")
+	}
+	if !created { // 重复消费，不再推送
+		return res.UniqueKey, ErrTypeNoError, nil, nil
+	}
+
+	return res.UniqueKey, ErrTypeNoError, func() {
+		publishDirectMessageCreated(params)
+	}, nil
+}
+
+func handleUserStatIncr(tx *gorm.DB, data []byte) (string, int, afterCommitFunc, error) {
+	var params UserStatIncr
+	err := json.Unmarshal(data, &params)
+	if err != nil {
+		return "", ErrTypeConvert, nil, errors.Wrap(err, "kafka:handleUserStatIncr: Unmarshal(params)")
+	}
+
+	res := incrUserStatField(tx, params)
+	if res.Err != nil {
+		return "", ErrTypeTransaction, nil, errors.Wrap(res.Err, "kafka:handleUserStatIncr: incrUserStatField")
 	}
 
-	return res.UniqueKey, ErrTypeNoError, nil
+	return res.UniqueKey, ErrTypeNoError, nil, nil
 }
@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const conversationPreviewLength = 64 // 会话列表中最后一条私信的预览长度
+
+func GetDirectMessageCreateUniqueKey(params DirectMessageCreate) string {
+	return fmt.Sprintf("direct_message_%v", params.MessageID)
+}
+
+// 写入私信，并更新双方的会话；bool：是否新增了私信（重复消费返回 false）
+func createDirectMessage(tx *gorm.DB, params DirectMessageCreate) (res Result, created bool) {
+	res.UniqueKey = GetDirectMessageCreateUniqueKey(params)
+
+	created, err := mysql.CreateDirectMessage(tx, &models.DirectMessage{
+		MessageID:       params.MessageID,
+		ConversationKey: params.ConversationKey,
+		SenderID:        params.SenderID,
+		ReceiverID:      params.ReceiverID,
+		Content:         params.Content,
+	})
+	if err != nil {
+		res.Err = errors.Wrap(err, "kafka:createDirectMessage: CreateDirectMessage")
+		return
+	}
+	if !created { // 重复消费
+		return
+	}
+
+	preview := params.Content
+	if utf8.RuneCountInString(preview) > conversationPreviewLength {
+		preview = string([]rune(preview)[:conversationPreviewLength])
+	}
+	// 发送者的会话，未读数不变
+	if err := mysql.UpsertConversation(tx, params.SenderID, params.ReceiverID, params.MessageID, preview, 0); err != nil {
+		res.Err = errors.Wrap(err, "kafka:createDirectMessage: UpsertConversation(sender)")
+		return
+	}
+	// 接收者的会话，未读数 + 1
+	if err := mysql.UpsertConversation(tx, params.ReceiverID, params.SenderID, params.MessageID, preview, 1); err != nil {
+		res.Err = errors.Wrap(err, "kafka:createDirectMessage: UpsertConversation(receiver)")
+	}
+	return
+}
+
+// 实时推送给私信的接收者
+func publishDirectMessageCreated(params DirectMessageCreate) {
+	err := redis.PublishStreamEvent(models.StreamEvent{
+		Type:   models.StreamEventDirectMessage,
+		UserID: params.ReceiverID,
+		Data:   params,
+	})
+	if err != nil {
+		logger.Warnf("kafka:publishDirectMessageCreated: PublishStreamEvent, reason: %v", err.Error())
+	}
+}
@@ -0,0 +1,9 @@
+package kafka
+
+type DirectMessageCreate struct {
+	MessageID       int64  `json:"message_id,string"`
+	ConversationKey string `json:"conversation_key"`
+	SenderID        int64  `json:"sender_id,string"`
+	ReceiverID      int64  `json:"receiver_id,string"`
+	Content         string `json:"content"`
+}
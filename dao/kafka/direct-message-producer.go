@@ -0,0 +1,12 @@
+package kafka
+
+import (
+	"github.com/pkg/errors"
+)
+
+func SendDirectMessage(params DirectMessageCreate) error {
+	// 以会话作为 key，同一个会话的私信有序写入
+	err := writeMessage(directMessageWriter, TopicDirectMessage, params.ConversationKey, TypeDirectMessageCreate, params)
+
+	return errors.Wrap(err, "kafka-producer:SendDirectMessage: writeMessage")
+}
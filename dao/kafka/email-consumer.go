@@ -27,3 +27,11 @@ func sendEmailVerificationCode(params EmailSendVerificationCode) (res Result) {
 	}
 	return
 }
+
+func sendAccountLockedEmail(params EmailSendAccountLocked) (res Result) {
+	m := email.GetAccountLockedMailBody(params.To, params.UserName, params.LockDuration, params.LockedAt)
+	if err := email.SendEmail(m); err != nil {
+		res.Err = errors.Wrap(err, "kafka:sendAccountLockedEmail: SendEmail")
+	}
+	return
+}
@@ -9,3 +9,10 @@ type EmailSendVerificationCode struct {
 	Code           string        `json:"code"`
 	ExpireDuration time.Duration `json:"expire_duration"`
 }
+
+type EmailSendAccountLocked struct {
+	To           string        `json:"to"`
+	UserName     string        `json:"user_name"`
+	LockDuration time.Duration `json:"lock_duration"`
+	LockedAt     time.Time     `json:"locked_at"`
+}
@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"bluebell/dao/email"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -15,3 +16,14 @@ func SendEmailVerificationCode(to, code string) error {
 
 	return errors.Wrap(err, "kafka-producer:SendEmail: writeMessage")
 }
+
+func SendAccountLockedEmail(to, userName string, lockDuration time.Duration) error {
+	err := writeMessage(emailWriter, TopicEmail, to, TypeEmailSendAccountLocked, EmailSendAccountLocked{
+		To:           to,
+		UserName:     userName,
+		LockDuration: lockDuration,
+		LockedAt:     time.Now(),
+	})
+
+	return errors.Wrap(err, "kafka-producer:SendAccountLockedEmail: writeMessage")
+}
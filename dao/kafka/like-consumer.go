@@ -2,6 +2,8 @@ package kafka
 
 import (
 	"bluebell/dao/mysql"
+	"bluebell/logger"
+	"bluebell/models"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -30,6 +32,24 @@ func incrCommentIndexCountField(tx *gorm.DB, field string, commentID int64, offs
 	return
 }
 
+// 递增评论作者收到的点赞总数
+func incrCommentAuthorLikeCount(tx *gorm.DB, params LikeOrHateIncr) (res Result) {
+	res.UniqueKey = GetIncrCommentIndexCountFieldUniqueKey(params.Field, params.CommentID)
+
+	authorID, err := mysql.SelectUserIDByCommentID(tx, params.CommentID)
+	if err != nil {
+		res.Err = errors.Wrap(err, "kafka:incrCommentAuthorLikeCount: SelectUserIDByCommentID")
+		return
+	}
+	if authorID == 0 { // 评论已被删除
+		return
+	}
+	if err := mysql.IncrUserStatField(tx, authorID, models.UserStatCommentLikeCount, int64(params.Offset)); err != nil {
+		res.Err = errors.Wrap(err, "kafka:incrCommentAuthorLikeCount: IncrUserStatField")
+	}
+	return
+}
+
 func createCommentLikeOrHateUser(tx *gorm.DB, commentID, userID, objID int64, objType int8, like bool) (res Result) {
 	res.UniqueKey = GetCreateCommentLikeOrHateUserUniqueKey(like, commentID)
 
@@ -49,3 +69,30 @@ func removeCommentUserLikeMappingByCommentIDs(tx *gorm.DB, commentID int64) (res
 
 	return
 }
+
+// 点赞评论后，通知评论的作者，返回的函数在事务提交后发送通知，不需要通知时返回 nil
+func notifyCommentLiked(tx *gorm.DB, params LikeOrHateMappingCreate) afterCommitFunc {
+	author, err := mysql.SelectUserIDByCommentID(tx, params.CommentID)
+	if err != nil {
+		logger.Warnf("kafka:notifyCommentLiked: SelectUserIDByCommentID, reason: %v", err.Error())
+		return nil
+	}
+	if author == 0 || author == params.UserID {
+		return nil
+	}
+
+	return func() {
+		go func() {
+			err := SendNotification(NotificationCreate{
+				UserID:  author,
+				ActorID: params.UserID,
+				Type:    models.NotificationLikeComment,
+				ObjID:   params.CommentID,
+				PostID:  params.ObjID,
+			})
+			if err != nil {
+				logger.Warnf("kafka:notifyCommentLiked: SendNotification, reason: %v", err.Error())
+			}
+		}()
+	}
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetNotificationCreateUniqueKey(params NotificationCreate) string {
+	return fmt.Sprintf("notification_%v_%v_%v_%v", params.UserID, params.Type, params.ObjID, params.ActorID)
+}
+
+// 创建通知，存在未读的同类通知时聚合，created 表示是否新建了通知
+func createNotification(tx *gorm.DB, params NotificationCreate) (res Result, created bool) {
+	res.UniqueKey = GetNotificationCreateUniqueKey(params)
+
+	notification, err := mysql.SelectUnreadNotification(tx, params.UserID, params.Type, params.ObjID)
+	if err != nil {
+		res.Err = errors.Wrap(err, "kafka:createNotification: SelectUnreadNotification")
+		return
+	}
+
+	// 不存在，新建一条通知，未读数发生变化
+	if notification == nil {
+		notification = &models.Notification{
+			UserID:      params.UserID,
+			Type:        params.Type,
+			ObjID:       params.ObjID,
+			PostID:      params.PostID,
+			LastActorID: params.ActorID,
+			ActorCount:  1,
+			Content:     params.Content,
+		}
+		if err := mysql.CreateNotification(tx, notification); err != nil {
+			res.Err = errors.Wrap(err, "kafka:createNotification: CreateNotification")
+			return
+		}
+		if _, err := mysql.CreateNotificationActor(tx, notification.ID, params.ActorID); err != nil {
+			res.Err = errors.Wrap(err, "kafka:createNotification: CreateNotificationActor")
+			return
+		}
+		created = true
+		return
+	}
+
+	// 存在，聚合；同一个用户重复触发（如重复消费、取消点赞后再点赞）不递增参与者数量
+	added, err := mysql.CreateNotificationActor(tx, notification.ID, params.ActorID)
+	if err != nil {
+		res.Err = errors.Wrap(err, "kafka:createNotification: CreateNotificationActor")
+		return
+	}
+	incr := 0
+	if added {
+		incr = 1
+	}
+	if err := mysql.UpdateNotificationAggregation(tx, notification.ID, params.ActorID, params.Content, incr); err != nil {
+		res.Err = errors.Wrap(err, "kafka:createNotification: UpdateNotificationAggregation")
+	}
+	return
+}
+
+// 实时推送给通知的接收者
+func publishNotificationCreated(params NotificationCreate) {
+	err := redis.PublishStreamEvent(models.StreamEvent{
+		Type:   models.StreamEventNotification,
+		UserID: params.UserID,
+		Data:   params,
+	})
+	if err != nil {
+		logger.Warnf("kafka:publishNotificationCreated: PublishStreamEvent, reason: %v", err.Error())
+	}
+}
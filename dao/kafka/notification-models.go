@@ -0,0 +1,10 @@
+package kafka
+
+type NotificationCreate struct {
+	UserID  int64  `json:"user_id,string"` // 接收者
+	ActorID int64  `json:"actor_id,string"`
+	Type    int8   `json:"type"`
+	ObjID   int64  `json:"obj_id,string"`
+	PostID  int64  `json:"post_id,string"`
+	Content string `json:"content"`
+}
@@ -0,0 +1,14 @@
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+func SendNotification(params NotificationCreate) error {
+	// 以接收者作为 key，同一个用户的通知串行聚合
+	err := writeMessage(notificationWriter, TopicNotification, strconv.FormatInt(params.UserID, 10), TypeNotificationCreate, params)
+
+	return errors.Wrap(err, "kafka-producer:SendNotification: writeMessage")
+}
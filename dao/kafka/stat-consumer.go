@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/models"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetUserStatIncrUniqueKey(params UserStatIncr) string {
+	return fmt.Sprintf("user_stat_%v_%v", params.UserID, params.Field)
+}
+
+func incrUserStatField(tx *gorm.DB, params UserStatIncr) (res Result) {
+	res.UniqueKey = GetUserStatIncrUniqueKey(params)
+
+	if err := mysql.IncrUserStatField(tx, params.UserID, params.Field, params.Offset); err != nil {
+		res.Err = errors.Wrap(err, "kafka:incrUserStatField: IncrUserStatField")
+	}
+	return
+}
+
+// 评论被删除后，递减评论者的 comment_count
+func decrUserCommentCount(tx *gorm.DB, counts map[int64]int64) error {
+	for userID, count := range counts {
+		if err := mysql.IncrUserStatField(tx, userID, models.UserStatCommentCount, -count); err != nil {
+			return errors.Wrap(err, "kafka:decrUserCommentCount: IncrUserStatField")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package kafka
+
+type UserStatIncr struct {
+	UserID int64  `json:"user_id,string"`
+	Field  string `json:"field"`
+	Offset int64  `json:"offset"`
+}
@@ -0,0 +1,18 @@
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// 与点赞数一样，投递到 topic-like，以用户作为 key
+func IncrUserStatField(userID int64, field string, offset int64) error {
+	err := writeMessage(likeWriter, TopicLike, strconv.FormatInt(userID, 10), TypeUserStatIncr, UserStatIncr{
+		UserID: userID,
+		Field:  field,
+		Offset: offset,
+	})
+
+	return errors.Wrap(err, "kafka-producer:IncrUserStatField: writeMessage")
+}
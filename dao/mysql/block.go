@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateUserBlock(userID, blockedID int64) error {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserBlock{
+		UserID:    userID,
+		BlockedID: blockedID,
+	})
+	return errors.Wrap(res.Error, "mysql:CreateUserBlock: Create")
+}
+
+func DeleteUserBlock(userID, blockedID int64) error {
+	res := db.Where("user_id = ? AND blocked_id = ?", userID, blockedID).Delete(&models.UserBlock{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserBlock: Delete")
+}
+
+// 删除用户拉黑别人的记录
+func DeleteUserBlocksByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.UserBlock{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserBlocksByUserID: Delete")
+}
+
+// 拉黑的用户的 user_id，按拉黑时间倒序
+func SelectBlockedIDs(userID int64) ([]int64, error) {
+	var ids []int64
+	res := db.Model(&models.UserBlock{}).Where("user_id = ?", userID).Order("id DESC").Pluck("blocked_id", &ids)
+	return ids, errors.Wrap(res.Error, "mysql:SelectBlockedIDs: Pluck")
+}
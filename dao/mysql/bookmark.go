@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 已经收藏在 folder 中则忽略；收藏在其他收藏夹中则删除后重新插入，
+// 使 id（分页游标）和收藏时间更新，移动后排在收藏列表的最前面
+func CreateUserBookmark(userID, postID int64, folder string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND post_id = ? AND folder <> ?", userID, postID, folder).Delete(&models.UserBookmark{})
+		if res.Error != nil {
+			return res.Error
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserBookmark{
+			UserID: userID,
+			PostID: postID,
+			Folder: folder,
+		}).Error
+	})
+	return errors.Wrap(err, "mysql:CreateUserBookmark: Transaction")
+}
+
+func DeleteUserBookmark(userID, postID int64) error {
+	res := db.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.UserBookmark{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserBookmark: Delete")
+}
+
+// 帖子被删除后，删除对应的收藏
+func DeleteUserBookmarksByPostID(tx *gorm.DB, postID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("post_id = ?", postID).Delete(&models.UserBookmark{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserBookmarksByPostID: Delete")
+}
+
+func DeleteUserBookmarksByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.UserBookmark{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserBookmarksByUserID: Delete")
+}
+
+// 基于游标的分页，按收藏时间倒序，cursor 为 0 表示从头开始
+//
+// folder 为 nil 表示所有收藏夹
+func SelectUserBookmarks(userID, cursor int64, folder *string, size int) ([]models.UserBookmark, error) {
+	var bookmarks []models.UserBookmark
+	query := db.Where("user_id = ?", userID)
+	if cursor > 0 {
+		query = query.Where("id < ?", cursor)
+	}
+	if folder != nil {
+		query = query.Where("folder = ?", *folder)
+	}
+	res := query.Order("id DESC").Limit(size).Find(&bookmarks)
+	return bookmarks, errors.Wrap(res.Error, "mysql:SelectUserBookmarks: Find")
+}
+
+// 返回 postIDs 中被用户收藏了的帖子
+func SelectBookmarkedPostIDs(userID int64, postIDs []int64) ([]int64, error) {
+	var ids []int64
+	res := db.Model(&models.UserBookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids)
+	return ids, errors.Wrap(res.Error, "mysql:SelectBookmarkedPostIDs: Pluck")
+}
@@ -203,6 +203,16 @@ func SelectUserIDByCommentID(tx *gorm.DB, commentID int64) (int64, error) {
 	return userID, errors.Wrap(res.Error, "mysql: SelectUserIDByCommentID")
 }
 
+// 获取评论的作者和所属主题
+func SelectCommentIndexByCommentID(tx *gorm.DB, commentID int64) (*models.CommentIndex, error) {
+	useDB := getUseDB(tx)
+
+	index := &models.CommentIndex{}
+	res := useDB.Select("id", "obj_id", "obj_type", "user_id").Where("id = ?", commentID).First(index)
+
+	return index, errors.Wrap(res.Error, "mysql:SelectCommentIndexByCommentID")
+}
+
 func SelectCommentIDsByObjID(tx *gorm.DB, objID int64, objType int8) ([]int64, error) {
 	useDB := getUseDB(tx)
 
@@ -312,3 +322,39 @@ func SelectCommentUserLikeOrHateList(tx *gorm.DB, userID, ObjID int64, ObjType i
 	}
 	return commentIDs, nil
 }
+
+// 获取用户发布的所有评论的元数据
+func SelectCommentIndicesByUserID(tx *gorm.DB, userID int64) ([]models.CommentIndex, error) {
+	useDB := getUseDB(tx)
+	indices := make([]models.CommentIndex, 0)
+	res := useDB.Where("user_id = ?", userID).Find(&indices)
+
+	return indices, errors.Wrap(res.Error, "mysql:SelectCommentIndicesByUserID")
+}
+
+// 获取用户发布的所有评论（包含内容，用于数据导出）
+func SelectCommentExportsByUserID(userID int64) ([]models.CommentExport, error) {
+	comments := make([]models.CommentExport, 0)
+	sqlStr := "SELECT i.id comment_id, i.obj_id, i.obj_type, i.root, i.parent, c.message, i.`like`, i.hate, i.created_at " +
+		"FROM comment_indices i " +
+		"JOIN comment_contents c ON c.comment_id = i.id " +
+		"WHERE i.user_id = ? " +
+		"ORDER BY i.created_at"
+	res := db.Raw(sqlStr, userID).Scan(&comments)
+
+	return comments, errors.Wrap(res.Error, "mysql:SelectCommentExportsByUserID")
+}
+
+func SelectCommentUserLikeMappingsByUserID(userID int64) ([]models.CommentUserLikeMapping, error) {
+	mappings := make([]models.CommentUserLikeMapping, 0)
+	res := db.Where("user_id = ?", userID).Find(&mappings)
+
+	return mappings, errors.Wrap(res.Error, "mysql:SelectCommentUserLikeMappingsByUserID")
+}
+
+func SelectCommentUserHateMappingsByUserID(userID int64) ([]models.CommentUserHateMapping, error) {
+	mappings := make([]models.CommentUserHateMapping, 0)
+	res := db.Where("user_id = ?", userID).Find(&mappings)
+
+	return mappings, errors.Wrap(res.Error, "mysql:SelectCommentUserHateMappingsByUserID")
+}
@@ -4,12 +4,14 @@ import (
 	"bluebell/models"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func SelectCommunityList() ([]models.CommunityDTO, error) {
 	var communityList []models.CommunityDTO
 	res := db.Model(&models.Community{}).
-		Select("community_id", "community_name").
+		Select("community_id", "community_name", "member_count").
 		Find(&communityList)
 
 	if res.Error != nil {
@@ -46,4 +48,96 @@ func CreateCommunity(communtiyID int64, communityName, intro string) error {
 	})
 
 	return errors.Wrap(res.Error, "mysql:CreateCommunity: Create")
-}
\ No newline at end of file
+}
+
+// 加入社区，同时递增社区成员数
+//
+// bool：是否新加入（已经是成员返回 false）
+func CreateCommunityMember(userID, communityID int64) (bool, error) {
+	created := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CommunityMember{
+			UserID:      userID,
+			CommunityID: communityID,
+		})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		created = true
+		return tx.Model(&models.Community{}).
+			Where("community_id = ?", communityID).
+			Update("member_count", gorm.Expr("member_count + 1")).Error
+	})
+	return created, errors.Wrap(err, "mysql:CreateCommunityMember: Transaction")
+}
+
+// 退出社区，同时递减社区成员数
+//
+// bool：是否退出（不是成员返回 false）
+func DeleteCommunityMember(userID, communityID int64) (bool, error) {
+	deleted := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND community_id = ?", userID, communityID).Delete(&models.CommunityMember{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		deleted = true
+		return tx.Model(&models.Community{}).
+			Where("community_id = ? AND member_count > 0", communityID).
+			Update("member_count", gorm.Expr("member_count - 1")).Error
+	})
+	return deleted, errors.Wrap(err, "mysql:DeleteCommunityMember: Transaction")
+}
+
+// 退出用户加入的所有社区
+func DeleteCommunityMembersByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.Community{}).
+		Where("community_id IN (?) AND member_count > 0", db.Model(&models.CommunityMember{}).Select("community_id").Where("user_id = ?", userID)).
+		Update("member_count", gorm.Expr("member_count - 1"))
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "mysql:DeleteCommunityMembersByUserID: Update")
+	}
+	res = useDB.Where("user_id = ?", userID).Delete(&models.CommunityMember{})
+	return errors.Wrap(res.Error, "mysql:DeleteCommunityMembersByUserID: Delete")
+}
+
+func SelectJoinedCommunityIDs(userID int64) ([]int64, error) {
+	var ids []int64
+	res := db.Model(&models.CommunityMember{}).Where("user_id = ?", userID).Pluck("community_id", &ids)
+	return ids, errors.Wrap(res.Error, "mysql:SelectJoinedCommunityIDs: Pluck")
+}
+
+// 用户加入的社区列表（不包含 intro）
+func SelectJoinedCommunityList(userID int64) ([]models.CommunityDTO, error) {
+	var communityList []models.CommunityDTO
+	res := db.Model(&models.Community{}).
+		Select("communities.community_id", "communities.community_name", "communities.member_count").
+		Joins("JOIN community_members ON community_members.community_id = communities.community_id").
+		Where("community_members.user_id = ?", userID).
+		Order("community_members.created_at DESC").
+		Find(&communityList)
+	return communityList, errors.Wrap(res.Error, "mysql:SelectJoinedCommunityList: Find")
+}
+
+// 批量获取社区（用于搜索结果）
+func SelectCommunityListByIDs(communityIDs []int64) ([]models.CommunityDTO, error) {
+	communityList := make([]models.CommunityDTO, 0, len(communityIDs))
+	if len(communityIDs) == 0 {
+		return communityList, nil
+	}
+	res := db.Model(&models.Community{}).
+		Select("community_id", "community_name", "introduction", "member_count").
+		Where("community_id in ?", communityIDs).
+		Find(&communityList)
+
+	return communityList, errors.Wrap(res.Error, "mysql:SelectCommunityListByIDs")
+}
+
+// 获取所有社区（用于重建搜索引擎索引）
+func SelectAllCommunities() ([]*models.Community, error) {
+	communities := make([]*models.Community, 0)
+	res := db.Find(&communities)
+
+	return communities, errors.Wrap(res.Error, "mysql:SelectAllCommunities")
+}
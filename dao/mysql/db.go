@@ -44,6 +44,20 @@ func initTables() {
 	db.AutoMigrate(&models.CommentContent{})
 	db.AutoMigrate(&models.CommentUserLikeMapping{})
 	db.AutoMigrate(&models.CommentUserHateMapping{})
+	db.AutoMigrate(&models.UserTOTP{})
+	db.AutoMigrate(&models.UserRecoveryCode{})
+	db.AutoMigrate(&models.UserIdentity{})
+	db.AutoMigrate(&models.UserRole{})
+	db.AutoMigrate(&models.UserFollow{})
+	db.AutoMigrate(&models.CommunityMember{})
+	db.AutoMigrate(&models.Notification{})
+	db.AutoMigrate(&models.NotificationActor{})
+	db.AutoMigrate(&models.UserBookmark{})
+	db.AutoMigrate(&models.UserBlock{})
+	db.AutoMigrate(&models.DirectMessage{})
+	db.AutoMigrate(&models.Conversation{})
+	db.AutoMigrate(&models.UserStat{})
+	db.AutoMigrate(&models.UserKarma{})
 }
 
 func initIndices()  {
@@ -52,6 +66,19 @@ func initIndices()  {
 	createUnionIndexIfNotExists("idx_uid_oid_otype", "comment_user_like_mappings", "user_id, obj_id, obj_type", false)
 	createUnionIndexIfNotExists("idx_uid_oid_otype", "comment_user_like_mappings", "user_id, obj_id, obj_type", false)
 	createUnionIndexIfNotExists("idx_oid_otype", "comment_subjects", "obj_id, obj_type", true)
+	createUnionIndexIfNotExists("idx_provider_subject", "user_identities", "provider, subject", true)
+	createUnionIndexIfNotExists("idx_uid_role_cid", "user_roles", "user_id, role, community_id", true)
+	createUnionIndexIfNotExists("idx_user_id", "comment_indices", "user_id", false)
+	createUnionIndexIfNotExists("idx_uid_fid", "user_follows", "user_id, followed_id", true)
+	createUnionIndexIfNotExists("idx_uid_cid", "community_members", "user_id, community_id", true)
+	createUnionIndexIfNotExists("idx_uid_type_oid", "notifications", "user_id, type, obj_id", false)
+	createUnionIndexIfNotExists("idx_uid_updated", "notifications", "user_id, updated_at", false)
+	createUnionIndexIfNotExists("idx_nid_aid", "notification_actors", "notification_id, actor_id", true)
+	createUnionIndexIfNotExists("idx_uid_pid", "user_bookmarks", "user_id, post_id", true)
+	createUnionIndexIfNotExists("idx_uid_bid", "user_blocks", "user_id, blocked_id", true)
+	createUnionIndexIfNotExists("idx_ckey_mid", "direct_messages", "conversation_key, message_id", false)
+	createUnionIndexIfNotExists("idx_uid_peer", "conversations", "user_id, peer_id", true)
+	createUnionIndexIfNotExists("idx_uid_updated", "conversations", "user_id, updated_at", false)
 }
 
 func createUnionIndexIfNotExists(indexName, tableName, columns string, unique bool) {
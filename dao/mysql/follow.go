@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bool：是否新建了关注关系（已经关注过返回 false）
+func CreateUserFollow(userID, followedID int64) (bool, error) {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserFollow{
+		UserID:     userID,
+		FollowedID: followedID,
+	})
+	return res.RowsAffected > 0, errors.Wrap(res.Error, "mysql:CreateUserFollow: Create")
+}
+
+// bool：是否删除了关注关系（没有关注过返回 false）
+func DeleteUserFollow(userID, followedID int64) (bool, error) {
+	res := db.Where("user_id = ? AND followed_id = ?", userID, followedID).Delete(&models.UserFollow{})
+	return res.RowsAffected > 0, errors.Wrap(res.Error, "mysql:DeleteUserFollow: Delete")
+}
+
+// 删除用户关注的、关注用户的所有关系
+func DeleteUserFollowsByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ? OR followed_id = ?", userID, userID).Delete(&models.UserFollow{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserFollowsByUserID: Delete")
+}
+
+// 粉丝的 user_id
+func SelectFollowerIDs(userID int64) ([]int64, error) {
+	var ids []int64
+	res := db.Model(&models.UserFollow{}).Where("followed_id = ?", userID).Pluck("user_id", &ids)
+	return ids, errors.Wrap(res.Error, "mysql:SelectFollowerIDs: Pluck")
+}
+
+// 关注的用户的 user_id
+func SelectFollowingIDs(userID int64) ([]int64, error) {
+	var ids []int64
+	res := db.Model(&models.UserFollow{}).Where("user_id = ?", userID).Pluck("followed_id", &ids)
+	return ids, errors.Wrap(res.Error, "mysql:SelectFollowingIDs: Pluck")
+}
+
+func SelectFollowerCount(userID int64) (int, error) {
+	var count int64
+	res := db.Model(&models.UserFollow{}).Where("followed_id = ?", userID).Count(&count)
+	return int(count), errors.Wrap(res.Error, "mysql:SelectFollowerCount: Count")
+}
+
+func SelectFollowingCount(userID int64) (int, error) {
+	var count int64
+	res := db.Model(&models.UserFollow{}).Where("user_id = ?", userID).Count(&count)
+	return int(count), errors.Wrap(res.Error, "mysql:SelectFollowingCount: Count")
+}
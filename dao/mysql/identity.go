@@ -0,0 +1,35 @@
+package mysql
+
+import (
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func SelectUserIdentity(provider, subject string) (*models.UserIdentity, error) {
+	identity := new(models.UserIdentity)
+	res := db.First(identity, "provider = ? AND subject = ?", provider, subject)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "mysql:SelectUserIdentity: First")
+	}
+	return identity, nil
+}
+
+// 新建用户，同时绑定第三方身份
+func CreateUserWithIdentity(usr *models.User, identity *models.UserIdentity) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(usr).Error; err != nil {
+			return err
+		}
+		identity.UserID = usr.UserID
+		return tx.Create(identity).Error
+	})
+	return errors.Wrap(err, "mysql:CreateUserWithIdentity: Transaction")
+}
+
+func DeleteUserIdentitiesByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.UserIdentity{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserIdentitiesByUserID: Delete")
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+const karmaBatchSize = 500
+
+// 过期帖子的赞成票、反对票，按作者聚合
+func SelectExpiredPostVoteSumGroupByAuthor() ([]models.KarmaVoteSum, error) {
+	var list []models.KarmaVoteSum
+	res := db.Model(&models.ExpiredPostScore{}).
+		Select("posts.author_id AS user_id, SUM(expired_post_scores.up_vote_num) AS up, SUM(expired_post_scores.down_vote_num) AS down").
+		Joins("JOIN posts ON posts.post_id = expired_post_scores.post_id").
+		Group("posts.author_id").
+		Scan(&list)
+	return list, errors.Wrap(res.Error, "mysql:SelectExpiredPostVoteSumGroupByAuthor: Scan")
+}
+
+// 评论的点赞、点踩数，按评论者聚合
+func SelectCommentLikeSumGroupByUserID() ([]models.KarmaVoteSum, error) {
+	var list []models.KarmaVoteSum
+	res := db.Model(&models.CommentIndex{}).
+		Select("user_id, SUM(`like`) AS up, SUM(hate) AS down").
+		Group("user_id").
+		Scan(&list)
+	return list, errors.Wrap(res.Error, "mysql:SelectCommentLikeSumGroupByUserID: Scan")
+}
+
+// 作者收到的赞成票总数（过期帖子）
+func SelectExpiredPostUpVoteSumByAuthorID(authorID int64) (int64, error) {
+	var sum int64
+	res := db.Model(&models.ExpiredPostScore{}).
+		Select("COALESCE(SUM(expired_post_scores.up_vote_num), 0)").
+		Joins("JOIN posts ON posts.post_id = expired_post_scores.post_id").
+		Where("posts.author_id = ?", authorID).
+		Scan(&sum)
+	return sum, errors.Wrap(res.Error, "mysql:SelectExpiredPostUpVoteSumByAuthorID: Scan")
+}
+
+// key: post_id, value: author_id
+func SelectAuthorIDsByPostIDs(postIDs []string) (map[int64]int64, error) {
+	authors := make(map[int64]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return authors, nil
+	}
+	var posts []models.Post
+	res := db.Model(&models.Post{}).Select("post_id, author_id").Where("post_id IN ?", postIDs).Find(&posts)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "mysql:SelectAuthorIDsByPostIDs: Find")
+	}
+	for _, post := range posts {
+		authors[post.PostID] = post.AuthorID
+	}
+	return authors, nil
+}
+
+func UpsertUserKarmas(karmas []models.UserKarma) error {
+	if len(karmas) == 0 {
+		return nil
+	}
+	res := db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"karma", "updated_at"}),
+	}).CreateInBatches(karmas, karmaBatchSize)
+	return errors.Wrap(res.Error, "mysql:UpsertUserKarmas: CreateInBatches")
+}
+
+// 本轮没有计算到的用户（如内容全部被删除），karma 清零
+func ResetStaleUserKarmas(before time.Time) error {
+	res := db.Model(&models.UserKarma{}).Where("updated_at < ? AND karma != 0", before).Updates(map[string]any{
+		"karma":      0,
+		"updated_at": time.Now(),
+	})
+	return errors.Wrap(res.Error, "mysql:ResetStaleUserKarmas: Updates")
+}
+
+// 没有记录返回 0
+func SelectUserKarma(userID int64) (int64, error) {
+	var karmas []int64
+	res := db.Model(&models.UserKarma{}).Where("user_id = ?", userID).Limit(1).Pluck("karma", &karmas)
+	if res.Error != nil || len(karmas) == 0 {
+		return 0, errors.Wrap(res.Error, "mysql:SelectUserKarma: Pluck")
+	}
+	return karmas[0], nil
+}
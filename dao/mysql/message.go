@@ -0,0 +1,92 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bool：是否新增了私信（重复消费返回 false）
+func CreateDirectMessage(tx *gorm.DB, message *models.DirectMessage) (bool, error) {
+	useDB := getUseDB(tx)
+	res := useDB.Clauses(clause.OnConflict{DoNothing: true}).Create(message)
+	return res.RowsAffected > 0, errors.Wrap(res.Error, "mysql:CreateDirectMessage: Create")
+}
+
+// 更新会话的最后一条私信，不存在则创建
+func UpsertConversation(tx *gorm.DB, userID, peerID, lastMessageID int64, lastMessage string, unreadIncr int) error {
+	useDB := getUseDB(tx)
+	res := useDB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"last_message_id": lastMessageID,
+			"last_message":    lastMessage,
+			"unread_count":    gorm.Expr("unread_count + ?", unreadIncr),
+			"updated_at":      time.Now(),
+		}),
+	}).Create(&models.Conversation{
+		UserID:        userID,
+		PeerID:        peerID,
+		LastMessageID: lastMessageID,
+		LastMessage:   lastMessage,
+		UnreadCount:   unreadIncr,
+	})
+	return errors.Wrap(res.Error, "mysql:UpsertConversation: Create")
+}
+
+// 按最后一条私信的时间倒序
+func SelectConversationsByUserID(userID int64, start, size int) ([]*models.ConversationDTO, error) {
+	var list []*models.ConversationDTO
+	res := db.Model(&models.Conversation{}).
+		Select("conversations.peer_id, users.user_name AS peer_name, users.avatar AS peer_avatar, "+
+			"conversations.last_message_id, conversations.last_message, conversations.unread_count, conversations.updated_at").
+		Joins("LEFT JOIN users ON users.user_id = conversations.peer_id").
+		Where("conversations.user_id = ?", userID).
+		Order("conversations.updated_at DESC").
+		Offset(start).
+		Limit(size).
+		Scan(&list)
+	return list, errors.Wrap(res.Error, "mysql:SelectConversationsByUserID: Scan")
+}
+
+func SelectConversationCountByUserID(userID int64) (int, error) {
+	var count int64
+	res := db.Model(&models.Conversation{}).Where("user_id = ?", userID).Count(&count)
+	return int(count), errors.Wrap(res.Error, "mysql:SelectConversationCountByUserID: Count")
+}
+
+// 所有会话的未读数之和
+func SelectConversationUnreadSum(userID int64) (int, error) {
+	var sum int64
+	res := db.Model(&models.Conversation{}).Select("COALESCE(SUM(unread_count), 0)").Where("user_id = ?", userID).Scan(&sum)
+	return int(sum), errors.Wrap(res.Error, "mysql:SelectConversationUnreadSum: Scan")
+}
+
+func UpdateConversationRead(userID, peerID int64) error {
+	res := db.Model(&models.Conversation{}).
+		Where("user_id = ? AND peer_id = ? AND unread_count > 0", userID, peerID).
+		Update("unread_count", 0)
+	return errors.Wrap(res.Error, "mysql:UpdateConversationRead: Update")
+}
+
+// 基于游标的分页，按发送时间倒序，cursor 为 0 表示从最新的私信开始
+func SelectDirectMessages(conversationKey string, cursor int64, size int) ([]*models.DirectMessageDTO, error) {
+	var list []*models.DirectMessageDTO
+	query := db.Model(&models.DirectMessage{}).
+		Select("message_id, sender_id, receiver_id, content, created_at").
+		Where("conversation_key = ?", conversationKey)
+	if cursor > 0 {
+		query = query.Where("message_id < ?", cursor)
+	}
+	res := query.Order("message_id DESC").Limit(size).Scan(&list)
+	return list, errors.Wrap(res.Error, "mysql:SelectDirectMessages: Scan")
+}
+
+// 删除用户的会话，对方的会话与私信记录保留
+func DeleteConversationsByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.Conversation{})
+	return errors.Wrap(res.Error, "mysql:DeleteConversationsByUserID: Delete")
+}
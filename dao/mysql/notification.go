@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 查询可以聚合的（未读的）通知，不存在返回 nil
+func SelectUnreadNotification(tx *gorm.DB, userID int64, _type int8, objID int64) (*models.Notification, error) {
+	useDB := getUseDB(tx)
+
+	var notifications []models.Notification
+	res := useDB.Where("user_id = ? AND type = ? AND obj_id = ? AND is_read = ?", userID, _type, objID, false).
+		Limit(1).
+		Find(&notifications)
+	if res.Error != nil || len(notifications) == 0 {
+		return nil, errors.Wrap(res.Error, "mysql:SelectUnreadNotification: Find")
+	}
+	return &notifications[0], nil
+}
+
+func CreateNotification(tx *gorm.DB, notification *models.Notification) error {
+	useDB := getUseDB(tx)
+	res := useDB.Create(notification)
+	return errors.Wrap(res.Error, "mysql:CreateNotification: Create")
+}
+
+// bool：是否新增了参与者（同一个用户重复触发返回 false）
+func CreateNotificationActor(tx *gorm.DB, notificationID, actorID int64) (bool, error) {
+	useDB := getUseDB(tx)
+	res := useDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.NotificationActor{
+		NotificationID: notificationID,
+		ActorID:        actorID,
+	})
+	return res.RowsAffected > 0, errors.Wrap(res.Error, "mysql:CreateNotificationActor: Create")
+}
+
+// 聚合：递增参与者数量，更新最近的参与者
+func UpdateNotificationAggregation(tx *gorm.DB, id, actorID int64, content string, incr int) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]any{
+		"actor_count":   gorm.Expr("actor_count + ?", incr),
+		"last_actor_id": actorID,
+		"content":       content,
+		"updated_at":    time.Now(),
+	})
+	return errors.Wrap(res.Error, "mysql:UpdateNotificationAggregation: Updates")
+}
+
+// 按更新时间倒序
+func SelectNotificationsByUserID(userID int64, start, size int) ([]*models.NotificationDTO, error) {
+	var list []*models.NotificationDTO
+	res := db.Model(&models.Notification{}).
+		Select("notifications.id, notifications.type, notifications.obj_id, notifications.post_id, "+
+			"notifications.last_actor_id, users.user_name AS last_actor_name, notifications.actor_count, "+
+			"notifications.content, notifications.is_read, notifications.updated_at").
+		Joins("LEFT JOIN users ON users.user_id = notifications.last_actor_id").
+		Where("notifications.user_id = ?", userID).
+		Order("notifications.updated_at DESC").
+		Offset(start).
+		Limit(size).
+		Scan(&list)
+	return list, errors.Wrap(res.Error, "mysql:SelectNotificationsByUserID: Scan")
+}
+
+func SelectNotificationCountByUserID(userID int64, onlyUnread bool) (int, error) {
+	var count int64
+	query := db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if onlyUnread {
+		query = query.Where("is_read = ?", false)
+	}
+	res := query.Count(&count)
+	return int(count), errors.Wrap(res.Error, "mysql:SelectNotificationCountByUserID: Count")
+}
+
+// ids 为空时，标记所有通知为已读
+func UpdateNotificationsRead(userID int64, ids []int64) error {
+	query := db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false)
+	if len(ids) != 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	res := query.Update("is_read", true)
+	return errors.Wrap(res.Error, "mysql:UpdateNotificationsRead: Update")
+}
+
+func DeleteNotificationsByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("notification_id IN (?)", db.Model(&models.Notification{}).Select("id").Where("user_id = ?", userID)).
+		Delete(&models.NotificationActor{})
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "mysql:DeleteNotificationsByUserID: Delete(actors)")
+	}
+	res = useDB.Where("user_id = ?", userID).Delete(&models.Notification{})
+	return errors.Wrap(res.Error, "mysql:DeleteNotificationsByUserID: Delete")
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"bluebell/models"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -49,6 +50,8 @@ func SelectPostDetailByID(postID int64) (*models.PostDTO, error) {
 				p.status,
 				p.title,
 				p.content,
+				p.original_post_id,
+				p.repost_count,
 				p.created_at,
 				p.updated_at
 			FROM posts p
@@ -74,6 +77,8 @@ func SelectPostListByPostIDs(postIDs []string) ([]*models.PostDTO, error) {
 				p.status,
 				p.title,
 				substr(p.content, 1, ?) content,
+				p.original_post_id,
+				p.repost_count,
 				p.created_at,
 				p.updated_at
 			FROM posts p
@@ -117,7 +122,7 @@ func SelectPostVoteNumsByIDs(postIDs []string) ([]int64, error) {
 func SelectPostsByAuthorID(authorID int64, start, size int) ([]*models.PostDTO, error) {
 	postList := make([]*models.PostDTO, 0)
 	contentLength := viper.GetInt64("service.post.content_max_length")
-	sqlStr := `select post_id, status, title, created_at, updated_at, substr(content, 1, ?) as content
+	sqlStr := `select post_id, status, title, original_post_id, repost_count, created_at, updated_at, substr(content, 1, ?) as content
 	from posts
 	where author_id = ?
 	limit ? offset ?`
@@ -141,6 +146,14 @@ func UpdatePostStatusByPostIDs(tx *gorm.DB, status int8, postIDs []string) error
 	return errors.Wrap(res.Error, "update post status by post_ids")
 }
 
+// 递增（减）帖子被转发的次数
+func IncrPostRepostCount(tx *gorm.DB, postID int64, offset int) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.Post{}).Where("post_id = ?", postID).UpdateColumn("repost_count", gorm.Expr("repost_count + ?", offset))
+
+	return errors.Wrap(res.Error, "mysql:IncrPostRepostCount")
+}
+
 func DeletePostDetailByPostID(tx *gorm.DB, postID int64) error {
 	useDB := getUseDB(tx)
 	res := useDB.Delete(&models.Post{}, "post_id = ?", postID)
@@ -155,3 +168,105 @@ func DeletePostExpiredScoresByPostID(tx *gorm.DB, postID int64) error {
 	return errors.Wrap(res.Error, "mysql:DeletePostExpiredScoresByPostID")
 }
 
+// 获取作者的所有帖子（完整内容，用于数据导出、注销账户）
+func SelectAllPostsByAuthorID(authorID int64) ([]*models.Post, error) {
+	posts := make([]*models.Post, 0)
+	res := db.Where("author_id = ?", authorID).Order("created_at").Find(&posts)
+
+	return posts, errors.Wrap(res.Error, "mysql:SelectAllPostsByAuthorID")
+}
+
+// 按主键顺序分批获取帖子（完整内容，用于重建搜索引擎索引）
+func SelectPostsAfterID(afterID int64, limit int) ([]*models.Post, error) {
+	posts := make([]*models.Post, 0, limit)
+	res := db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&posts)
+
+	return posts, errors.Wrap(res.Error, "mysql:SelectPostsAfterID")
+}
+
+// 按主键顺序分批获取帖子的 id、post_id（用于校验搜索引擎索引）
+func SelectPostIDsAfterID(afterID int64, limit int) ([]*models.Post, error) {
+	posts := make([]*models.Post, 0, limit)
+	res := db.Select("id", "post_id").Where("id > ?", afterID).Order("id").Limit(limit).Find(&posts)
+
+	return posts, errors.Wrap(res.Error, "mysql:SelectPostIDsAfterID")
+}
+
+func SelectPostCountAfterID(afterID int64) (int64, error) {
+	var total int64
+	res := db.Model(&models.Post{}).Where("id > ?", afterID).Count(&total)
+
+	return total, errors.Wrap(res.Error, "mysql:SelectPostCountAfterID")
+}
+
+// 返回 postIDs 中仍然存在的帖子
+func SelectExistingPostIDs(postIDs []int64) ([]int64, error) {
+	existing := make([]int64, 0, len(postIDs))
+	if len(postIDs) == 0 {
+		return existing, nil
+	}
+	res := db.Model(&models.Post{}).Where("post_id in ?", postIDs).Pluck("post_id", &existing)
+
+	return existing, errors.Wrap(res.Error, "mysql:SelectExistingPostIDs")
+}
+
+// 获取过期帖子的赞成票数，key 为 post_id
+func SelectExpiredPostUpVoteNums(postIDs []int64) (map[int64]int64, error) {
+	scores := make([]models.ExpiredPostScore, 0, len(postIDs))
+	res := db.Select("post_id", "up_vote_num").Where("post_id in ?", postIDs).Find(&scores)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "mysql:SelectExpiredPostUpVoteNums")
+	}
+
+	upVoteNums := make(map[int64]int64, len(scores))
+	for _, score := range scores {
+		upVoteNums[score.PostID] = score.UpVoteNum
+	}
+	return upVoteNums, nil
+}
+
+// 使用 LIKE 按关键字匹配标题或内容，按发布时间降序分页，搜索引擎不可用时兜底使用
+//
+// 不支持按赞成票数过滤（活跃帖子的票数只保存在 redis 中）
+func SelectPostIDsByKeyword(params *models.ParamPostListByKeyword) ([]string, int64, error) {
+	pattern := "%" + escapeLike(params.Keyword) + "%"
+	query := db.Model(&models.Post{}).Where("(title LIKE ? OR content LIKE ?)", pattern, pattern)
+	if params.CommunityID != 0 {
+		query = query.Where("community_id = ?", params.CommunityID)
+	}
+	if params.AuthorID != 0 {
+		query = query.Where("author_id = ?", params.AuthorID)
+	}
+	start, end := params.DateRange()
+	if !start.IsZero() {
+		query = query.Where("created_at >= ?", start)
+	}
+	if !end.IsZero() {
+		query = query.Where("created_at < ?", end)
+	}
+	query = query.Session(&gorm.Session{}) // 统计总数、分页查询复用同一组条件
+
+	var total int64
+	if res := query.Count(&total); res.Error != nil {
+		return nil, 0, errors.Wrap(res.Error, "mysql:SelectPostIDsByKeyword: Count")
+	}
+	ids := make([]int64, 0, params.PageSize)
+	res := query.Order("created_at DESC").
+		Offset(int((params.PageNum-1)*params.PageSize)).
+		Limit(int(params.PageSize)).
+		Pluck("post_id", &ids)
+	if res.Error != nil {
+		return nil, 0, errors.Wrap(res.Error, "mysql:SelectPostIDsByKeyword: Pluck")
+	}
+
+	postIDs := make([]string, len(ids))
+	for i, id := range ids {
+		postIDs[i] = strconv.FormatInt(id, 10)
+	}
+	return postIDs, total, nil
+}
+
+// 转义 LIKE 中的通配符，关键字按字面匹配
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
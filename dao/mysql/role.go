@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func SelectUserRolesByUserID(userID int64) ([]models.UserRole, error) {
+	var roles []models.UserRole
+	res := db.Where("user_id = ?", userID).Find(&roles)
+	return roles, errors.Wrap(res.Error, "mysql:SelectUserRolesByUserID: Find")
+}
+
+// 已经存在则忽略
+func CreateUserRole(userID int64, role string, communityID int64) error {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserRole{
+		UserID:      userID,
+		Role:        role,
+		CommunityID: communityID,
+	})
+	return errors.Wrap(res.Error, "mysql:CreateUserRole: Create")
+}
+
+func DeleteUserRole(userID int64, role string, communityID int64) error {
+	res := db.Where("user_id = ? AND role = ? AND community_id = ?", userID, role, communityID).Delete(&models.UserRole{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserRole: Delete")
+}
+
+func DeleteUserRolesByUserID(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.UserRole{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserRolesByUserID: Delete")
+}
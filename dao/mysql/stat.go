@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"bluebell/models"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 不存在返回 nil
+func SelectUserStat(userID int64) (*models.UserStat, error) {
+	var stats []models.UserStat
+	res := db.Where("user_id = ?", userID).Limit(1).Find(&stats)
+	if res.Error != nil || len(stats) == 0 {
+		return nil, errors.Wrap(res.Error, "mysql:SelectUserStat: Find")
+	}
+	return &stats[0], nil
+}
+
+// 已经存在则忽略，返回是否创建了记录
+//
+// 在事务中创建时，提交前这一行被锁住，并发的 IncrUserStatField 和 CreateUserStat 会等待事务结束
+func CreateUserStat(tx *gorm.DB, stat *models.UserStat) (bool, error) {
+	useDB := getUseDB(tx)
+	res := useDB.Clauses(clause.OnConflict{DoNothing: true}).Create(stat)
+	return res.RowsAffected != 0, errors.Wrap(res.Error, "mysql:CreateUserStat: Create")
+}
+
+// 写入全量统计的结果
+func UpdateUserStat(tx *gorm.DB, stat *models.UserStat) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.UserStat{}).Where("user_id = ?", stat.UserID).Updates(map[string]any{
+		models.UserStatPostCount:        stat.PostCount,
+		models.UserStatVoteCount:        stat.VoteCount,
+		models.UserStatCommentCount:     stat.CommentCount,
+		models.UserStatCommentLikeCount: stat.CommentLikeCount,
+		"updated_at":                    time.Now(),
+	})
+	return errors.Wrap(res.Error, "mysql:UpdateUserStat: Updates")
+}
+
+// 只更新已经存在的记录，不存在的记录在第一次查询时统计全量数据
+func IncrUserStatField(tx *gorm.DB, userID int64, field string, offset int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.UserStat{}).Where("user_id = ?", userID).Updates(map[string]any{
+		field:        gorm.Expr(field+" + ?", offset),
+		"updated_at": time.Now(),
+	})
+	return errors.Wrap(res.Error, "mysql:IncrUserStatField: Updates")
+}
+
+// 评论数、评论的点赞数，用于初始化 user_stats
+func SelectCommentStatByUserID(userID int64) (count, likeCount int64, err error) {
+	var stat struct {
+		Count     int64
+		LikeCount int64
+	}
+	res := db.Model(&models.CommentIndex{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(`like`), 0) AS like_count").
+		Where("user_id = ?", userID).
+		Scan(&stat)
+	return stat.Count, stat.LikeCount, errors.Wrap(res.Error, "mysql:SelectCommentStatByUserID: Scan")
+}
+
+// 按评论者分组统计评论数，用于删除评论时递减 comment_count
+func SelectCommentCountGroupByUserID(tx *gorm.DB, commentIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64)
+	if len(commentIDs) == 0 {
+		return counts, nil
+	}
+	useDB := getUseDB(tx)
+	var rows []struct {
+		UserID int64
+		Count  int64
+	}
+	res := useDB.Model(&models.CommentIndex{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("id IN ?", commentIDs).
+		Group("user_id").
+		Scan(&rows)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "mysql:SelectCommentCountGroupByUserID: Scan")
+	}
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
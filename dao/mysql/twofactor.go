@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func SelectUserTOTPByUserID(userID int64) (*models.UserTOTP, error) {
+	totp := new(models.UserTOTP)
+	res := db.First(totp, "user_id = ?", userID)
+	if res.Error != nil {
+		return nil, errors.Wrap(res.Error, "mysql:SelectUserTOTPByUserID: First")
+	}
+	return totp, nil
+}
+
+// 是否开启了两步验证
+func SelectUserTOTPEnabled(userID int64) (bool, error) {
+	var count int64
+	res := db.Model(&models.UserTOTP{}).Where("user_id = ? AND enabled = ?", userID, true).Count(&count)
+	return count > 0, errors.Wrap(res.Error, "mysql:SelectUserTOTPEnabled: Count")
+}
+
+// 保存（覆盖）未确认的密钥
+func SaveUserTOTPSecret(userID int64, secret string) error {
+	res := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"secret": secret, "enabled": false}),
+	}).Create(&models.UserTOTP{
+		UserID: userID,
+		Secret: secret,
+	})
+	return errors.Wrap(res.Error, "mysql:SaveUserTOTPSecret: Create")
+}
+
+func EnableUserTOTP(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.UserTOTP{}).Where("user_id = ?", userID).Update("enabled", true)
+	return errors.Wrap(res.Error, "mysql:EnableUserTOTP: Update")
+}
+
+func DeleteUserTOTP(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.UserTOTP{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserTOTP: Delete")
+}
+
+// 重新生成恢复码，旧的恢复码全部失效
+func CreateUserRecoveryCodes(tx *gorm.DB, userID int64, hashes []string) error {
+	useDB := getUseDB(tx)
+	if err := DeleteUserRecoveryCodes(useDB, userID); err != nil {
+		return errors.Wrap(err, "mysql:CreateUserRecoveryCodes: DeleteUserRecoveryCodes")
+	}
+
+	codes := make([]models.UserRecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, models.UserRecoveryCode{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+	}
+	res := useDB.Create(&codes)
+	return errors.Wrap(res.Error, "mysql:CreateUserRecoveryCodes: Create")
+}
+
+func SelectUnusedRecoveryCodesByUserID(userID int64) ([]models.UserRecoveryCode, error) {
+	var codes []models.UserRecoveryCode
+	res := db.Where("user_id = ? AND used = ?", userID, false).Find(&codes)
+	return codes, errors.Wrap(res.Error, "mysql:SelectUnusedRecoveryCodesByUserID: Find")
+}
+
+// 标记恢复码已使用，返回是否标记成功（并发使用同一个恢复码时，只有一个会成功）
+func UpdateRecoveryCodeUsed(id int64) (bool, error) {
+	res := db.Model(&models.UserRecoveryCode{}).Where("id = ? AND used = ?", id, false).Update("used", true)
+	return res.RowsAffected == 1, errors.Wrap(res.Error, "mysql:UpdateRecoveryCodeUsed: Update")
+}
+
+func DeleteUserRecoveryCodes(tx *gorm.DB, userID int64) error {
+	useDB := getUseDB(tx)
+	res := useDB.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{})
+	return errors.Wrap(res.Error, "mysql:DeleteUserRecoveryCodes: Delete")
+}
@@ -4,6 +4,7 @@ import (
 	"bluebell/models"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func SelectUserByUserID(userID int64) (usr *models.User, err error) {
@@ -48,4 +49,44 @@ func UpdateUserInfo(userID int64, params models.ParamUserUpdate) error {
 
 	res := db.Model(&models.User{}).Where("user_id = ?", userID).Updates(user)
 	return errors.Wrap(res.Error, "mysql: UpdateUserInfo")
-}
\ No newline at end of file
+}
+// 注销账户：抹去用户的个人信息，保留 user_id（已发布的内容仍然关联该 user_id）
+func AnonymizeUser(tx *gorm.DB, userID int64, userName, email, password string) error {
+	useDB := getUseDB(tx)
+	res := useDB.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]any{
+		"user_name": userName,
+		"email":     email,
+		"password":  password,
+		"gender":    2,
+		"avatar":    "",
+		"intro":     "",
+	})
+	return errors.Wrap(res.Error, "mysql:AnonymizeUser: Updates")
+}
+
+// 批量获取用户的公开信息（用于搜索结果）
+func SelectUsersByUserIDs(userIDs []int64) ([]*models.User, error) {
+	users := make([]*models.User, 0, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	res := db.Select("user_id", "user_name", "avatar", "intro").Where("user_id in ?", userIDs).Find(&users)
+
+	return users, errors.Wrap(res.Error, "mysql:SelectUsersByUserIDs")
+}
+
+// 按主键顺序分批获取用户（用于重建搜索引擎索引）
+func SelectUsersAfterID(afterID int64, limit int) ([]*models.User, error) {
+	users := make([]*models.User, 0, limit)
+	res := db.Select("id", "user_id", "user_name", "email", "intro").Where("id > ?", afterID).Order("id").Limit(limit).Find(&users)
+
+	return users, errors.Wrap(res.Error, "mysql:SelectUsersAfterID")
+}
+
+// 获取用户总数（用于重建搜索引擎索引时报告进度）
+func SelectUserCount() (int64, error) {
+	var total int64
+	res := db.Model(&models.User{}).Count(&total)
+
+	return total, errors.Wrap(res.Error, "mysql:SelectUserCount")
+}
@@ -0,0 +1,75 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	第三方登录（OAuth2 / OIDC），provider 在配置文件 oauth.providers 中声明，例如：
+	"github": {"type": "github", "client_id": "", "client_secret": "", "redirect_url": ""}
+	"google": {"type": "oidc", "issuer": "https://accounts.google.com", "client_id": "", ...}
+*/
+
+const (
+	TypeGitHub = "github"
+	TypeOIDC   = "oidc"
+)
+
+// 第三方平台返回的用户身份
+type Identity struct {
+	Provider      string
+	Subject       string // 用户在第三方平台的唯一标识
+	Name          string // 用户名，用于生成本站的用户名
+	Email         string
+	EmailVerified bool
+	Avatar        string
+}
+
+type Provider interface {
+	Name() string
+	// 生成跳转到第三方平台的授权地址
+	AuthCodeURL(state, nonce string) string
+	// 使用授权码换取用户身份
+	Exchange(ctx context.Context, code, nonce string) (*Identity, error)
+}
+
+var providers map[string]Provider
+
+var requestTimeout time.Duration
+
+func InitOAuth() {
+	requestTimeout = time.Second * time.Duration(viper.GetInt64("oauth.timeout"))
+	providers = make(map[string]Provider)
+
+	for name := range viper.GetStringMap("oauth.providers") {
+		conf := viper.Sub("oauth.providers." + name)
+
+		var provider Provider
+		var err error
+		switch conf.GetString("type") {
+		case TypeGitHub:
+			provider = newGitHubProvider(name, conf)
+		case TypeOIDC:
+			provider, err = newOIDCProvider(name, conf)
+		default:
+			err = fmt.Errorf("unsupported provider type %q", conf.GetString("type"))
+		}
+		if err != nil {
+			panic(fmt.Sprintf("oauth: init provider %v failed: %v", name, err.Error()))
+		}
+		providers[name] = provider
+	}
+}
+
+func GetProvider(name string) (Provider, bool) {
+	provider, ok := providers[name]
+	return provider, ok
+}
+
+func GetRequestTimeout() time.Duration {
+	return requestTimeout
+}
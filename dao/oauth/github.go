@@ -0,0 +1,109 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+type githubProvider struct {
+	name   string
+	config *oauth2.Config
+	apiURL string
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func newGitHubProvider(name string, conf *viper.Viper) *githubProvider {
+	conf.SetDefault("api_url", "https://api.github.com")
+	conf.SetDefault("auth_url", github.Endpoint.AuthURL)
+	conf.SetDefault("token_url", github.Endpoint.TokenURL)
+
+	return &githubProvider{
+		name: name,
+		config: &oauth2.Config{
+			ClientID:     conf.GetString("client_id"),
+			ClientSecret: conf.GetString("client_secret"),
+			RedirectURL:  conf.GetString("redirect_url"),
+			Scopes:       []string{"read:user", "user:email"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  conf.GetString("auth_url"),
+				TokenURL: conf.GetString("token_url"),
+			},
+		},
+		apiURL: conf.GetString("api_url"),
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return p.name
+}
+
+// GitHub 不支持 nonce，只使用 state
+func (p *githubProvider) AuthCodeURL(state, _ string) string {
+	return p.config.AuthCodeURL(state)
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, _ string) (*Identity, error) {
+	token, err := p.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth:githubProvider.Exchange: Exchange")
+	}
+	client := p.config.Client(ctx, token)
+
+	var user githubUser
+	if err := p.getJSON(client, "/user", &user); err != nil {
+		return nil, errors.Wrap(err, "oauth:githubProvider.Exchange: get user")
+	}
+	identity := &Identity{
+		Provider: p.name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Login,
+		Avatar:   user.AvatarURL,
+	}
+
+	// /user 返回的是公开邮箱，不一定存在，也不保证验证过，以 /user/emails 中的主邮箱为准
+	var emails []githubEmail
+	if err := p.getJSON(client, "/user/emails", &emails); err != nil {
+		return nil, errors.Wrap(err, "oauth:githubProvider.Exchange: get emails")
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return identity, nil
+}
+
+func (p *githubProvider) getJSON(client *http.Client, path string, v any) error {
+	resp, err := client.Get(p.apiURL + path)
+	if err != nil {
+		return errors.Wrap(err, "oauth:githubProvider.getJSON: Get")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("oauth:githubProvider.getJSON: unexpected status %v", resp.Status)
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(v), "oauth:githubProvider.getJSON: Decode")
+}
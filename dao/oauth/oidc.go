@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+// 通用的 OIDC provider，通过 issuer 的 discovery 文档获取端点，校验 id_token
+type oidcProvider struct {
+	name     string
+	config   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oidcClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	Picture           string `json:"picture"`
+	Nonce             string `json:"nonce"`
+}
+
+func newOIDCProvider(name string, conf *viper.Viper) (*oidcProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	provider, err := oidc.NewProvider(ctx, conf.GetString("issuer"))
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth:newOIDCProvider: NewProvider")
+	}
+
+	clientID := conf.GetString("client_id")
+	scopes := []string{oidc.ScopeOpenID, "profile", "email"}
+	if conf.IsSet("scopes") {
+		scopes = conf.GetStringSlice("scopes")
+	}
+
+	return &oidcProvider{
+		name: name,
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: conf.GetString("client_secret"),
+			RedirectURL:  conf.GetString("redirect_url"),
+			Scopes:       scopes,
+			Endpoint:     provider.Endpoint(),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+	}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, nonce string) string {
+	return p.config.AuthCodeURL(state, oidc.Nonce(nonce))
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce string) (*Identity, error) {
+	token, err := p.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth:oidcProvider.Exchange: Exchange")
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("oauth:oidcProvider.Exchange: id_token not found")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauth:oidcProvider.Exchange: Verify")
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, errors.Wrap(err, "oauth:oidcProvider.Exchange: Claims")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("oauth:oidcProvider.Exchange: nonce mismatch")
+	}
+
+	name := claims.PreferredUsername
+	if name == "" {
+		name = claims.Name
+	}
+	return &Identity{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Name:          name,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Avatar:        claims.Picture,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 占位成员，用于区分 "缓存不存在" 与 "没有拉黑任何人"
+const blockPlaceholder = "0"
+
+// 获取拉黑列表的缓存，缓存不存在返回 redis.Nil
+func GetBlockedIDs(userID int64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	members, err := rdb.SMembers(ctx, KeyUserBlockSetPF+strconv.FormatInt(userID, 10)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "redis:GetBlockedIDs: SMembers")
+	}
+	if len(members) == 0 {
+		return nil, Nil
+	}
+
+	ids := make([]string, 0, len(members)-1)
+	for _, member := range members {
+		if member != blockPlaceholder {
+			ids = append(ids, member)
+		}
+	}
+	return ids, nil
+}
+
+func SetBlockedIDs(userID int64, blockedIDs []int64, expireDuration time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := KeyUserBlockSetPF + strconv.FormatInt(userID, 10)
+	members := make([]any, 0, len(blockedIDs)+1)
+	members = append(members, blockPlaceholder)
+	for _, id := range blockedIDs {
+		members = append(members, id)
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.SAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, expireDuration)
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:SetBlockedIDs: SAdd")
+}
+
+// 拉黑列表发生变化时，删除缓存
+func DelBlockedIDs(userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = KeyUserBlockSetPF + strconv.FormatInt(userID, 10)
+	}
+	return DelKeys(keys)
+}
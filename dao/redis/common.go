@@ -22,6 +22,7 @@ const (
 	KeyPostCommunityZsetPF = "bluebell:post:community:" // member: post_id, score: 0
 	KeyPostVotedZsetPF     = "bluebell:post:voted:"     // parma: post_id, member: user_id, score: opinion
 	KeyCachePF             = "bluebell:cache:"
+	KeyPostSearchHashHash  = "bluebell:post:search_hash" // field: post_id, value: 已写入搜索引擎的文档的 hash
 
 	// comment
 	KeyCommentIndexZSetPF     = "bluebell:comment:index:"       // param:otype_oid, member:comment_id, score:floor
@@ -34,6 +35,37 @@ const (
 
 	// email
 	KeyEmailVerificationCodeStringPF = "bluebell:email:verification:" // param: user_email, value: verification_code
+
+	// login
+	KeyLoginFailStringPF      = "bluebell:login:fail:"       // param: account_uid | ip_addr, value: failed_count
+	KeyLoginLockStringPF      = "bluebell:login:lock:"       // param: account_uid | ip_addr, value: lock_level
+	KeyLoginLockLevelStringPF = "bluebell:login:lock_level:" // param: account_uid | ip_addr, value: lock_level
+
+	// 2fa
+	KeyTwoFactorChallengeStringPF = "bluebell:2fa:challenge:" // param: challenge_token, value: user_id
+	KeyTwoFactorUsedStringPF      = "bluebell:2fa:used:"      // param: user_id_step, value: 1，防止同一个 TOTP code 被重复使用
+
+	// oauth
+	KeyOAuthStateStringPF  = "bluebell:oauth:state:"  // param: state, value: provider nonce user_id（user_id 为 0 表示登录）
+	KeyOAuthReauthStringPF = "bluebell:oauth:reauth:" // param: reauth_token, value: user_id
+
+	// feed
+	KeyFeedInboxZSetPF  = "bluebell:feed:inbox:"     // param: user_id, member: post_id, score: time，写扩散
+	KeyFeedOutboxZSetPF = "bluebell:feed:outbox:"    // param: author_id, member: post_id, score: time，读扩散
+	KeyFeedBigAuthorSet = "bluebell:feed:big_author" // member: author_id，粉丝数超过阈值的作者
+
+	// notification
+	KeyNotificationUnreadStringPF = "bluebell:notification:unread:" // param: user_id, value: 未读通知数
+
+	// block
+	KeyUserBlockSetPF = "bluebell:user:block:" // param: user_id, member: blocked_id，包含占位成员 0，用于缓存空列表
+
+	// search
+	KeySearchReindexCursorStringPF = "bluebell:search:reindex_cursor:" // param: engine, value: 已重建索引的最后一个帖子的主键 id
+	KeySearchTermZSetPF            = "bluebell:search:term:"           // param: date(20060102), member: 搜索词, score: 当天的搜索次数
+
+	// stream
+	KeyStreamEventChannel = "bluebell:stream:event" // pub/sub channel，实时推送的事件
 )
 
 var Nil = redis.Nil
@@ -0,0 +1,191 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+	关注流（timeline）
+	1. 普通作者发帖时，写扩散：将 post_id 写入每个粉丝的 inbox
+	2. 粉丝数超过阈值的作者（大 V），读扩散：只写入作者自己的 outbox，粉丝读取时再合并
+*/
+
+// 发布帖子，写入作者的 outbox，outbox 最多保留 size 个帖子
+func AddPostToOutbox(authorID, postID int64, timestamp float64, size int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := KeyFeedOutboxZSetPF + strconv.FormatInt(authorID, 10)
+	pipe := rdb.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{
+		Member: postID,
+		Score:  timestamp,
+	})
+	pipe.ZRemRangeByRank(ctx, key, 0, -size-1)
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:AddPostToOutbox: ZAdd")
+}
+
+// 写扩散，将帖子写入粉丝的 inbox，inbox 最多保留 size 个帖子
+func AddPostToInboxes(userIDs []int64, postID int64, timestamp float64, size int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout*2)
+	defer cancel()
+
+	pipe := rdb.Pipeline()
+	for _, userID := range userIDs {
+		key := KeyFeedInboxZSetPF + strconv.FormatInt(userID, 10)
+		pipe.ZAdd(ctx, key, redis.Z{
+			Member: postID,
+			Score:  timestamp,
+		})
+		pipe.ZRemRangeByRank(ctx, key, 0, -size-1)
+	}
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:AddPostToInboxes: ZAdd")
+}
+
+// 删除帖子，从作者的 outbox、粉丝的 inbox 中移除
+func RemPostFromFeeds(authorID int64, userIDs []int64, postID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout*2)
+	defer cancel()
+
+	member := strconv.FormatInt(postID, 10)
+	pipe := rdb.Pipeline()
+	pipe.ZRem(ctx, KeyFeedOutboxZSetPF+strconv.FormatInt(authorID, 10), member)
+	for _, userID := range userIDs {
+		pipe.ZRem(ctx, KeyFeedInboxZSetPF+strconv.FormatInt(userID, 10), member)
+	}
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:RemPostFromFeeds: ZRem")
+}
+
+// 关注普通作者后，将作者 outbox 中的帖子合并到 inbox
+func MergeOutboxToInbox(userID, authorID, size int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := KeyFeedInboxZSetPF + strconv.FormatInt(userID, 10)
+	pipe := rdb.TxPipeline()
+	pipe.ZUnionStore(ctx, key, &redis.ZStore{
+		Keys:      []string{key, KeyFeedOutboxZSetPF + strconv.FormatInt(authorID, 10)},
+		Aggregate: "MAX",
+	})
+	pipe.ZRemRangeByRank(ctx, key, 0, -size-1)
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:MergeOutboxToInbox: ZUnionStore")
+}
+
+// 取消关注后，将作者的帖子从 inbox 中移除
+func RemOutboxFromInbox(userID, authorID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	postIDs, err := rdb.ZRange(ctx, KeyFeedOutboxZSetPF+strconv.FormatInt(authorID, 10), 0, -1).Result()
+	if err != nil {
+		return errors.Wrap(err, "redis:RemOutboxFromInbox: ZRange")
+	}
+	if len(postIDs) == 0 {
+		return nil
+	}
+	cmd := rdb.ZRem(ctx, KeyFeedInboxZSetPF+strconv.FormatInt(userID, 10), postIDs)
+	return errors.Wrap(cmd.Err(), "redis:RemOutboxFromInbox: ZRem")
+}
+
+func SetBigAuthor(authorID int64, big bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	var cmd *redis.IntCmd
+	if big {
+		cmd = rdb.SAdd(ctx, KeyFeedBigAuthorSet, authorID)
+	} else {
+		cmd = rdb.SRem(ctx, KeyFeedBigAuthorSet, authorID)
+	}
+	return errors.Wrap(cmd.Err(), "redis:SetBigAuthor: SAdd/SRem")
+}
+
+func IsBigAuthor(authorID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.SIsMember(ctx, KeyFeedBigAuthorSet, authorID)
+	return cmd.Val(), errors.Wrap(cmd.Err(), "redis:IsBigAuthor: SIsMember")
+}
+
+// 从 authorIDs 中过滤出大 V
+func FilterBigAuthors(authorIDs []int64) ([]int64, error) {
+	if len(authorIDs) == 0 {
+		return nil, nil
+	}
+	members := make([]any, len(authorIDs))
+	for i, id := range authorIDs {
+		members[i] = id
+	}
+	isBig, err := SetIsMembers(KeyFeedBigAuthorSet, members)
+	if err != nil {
+		return nil, errors.Wrap(err, "redis:FilterBigAuthors: SetIsMembers")
+	}
+
+	bigAuthorIDs := make([]int64, 0)
+	for i, big := range isBig {
+		if big {
+			bigAuthorIDs = append(bigAuthorIDs, authorIDs[i])
+		}
+	}
+	return bigAuthorIDs, nil
+}
+
+// 获取用户 timeline 中的 post_id（按时间倒序）
+//
+// 合并 inbox 与关注的大 V 的 outbox
+func GetFeedPostIDs(userID int64, bigAuthorIDs []int64, pageNum, pageSize int64) ([]string, int, error) {
+	inboxKey := KeyFeedInboxZSetPF + strconv.FormatInt(userID, 10)
+	if len(bigAuthorIDs) == 0 {
+		return getPostIDHelper(inboxKey, pageNum, pageSize)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout*2)
+	defer cancel()
+
+	keys := make([]string, 0, len(bigAuthorIDs)+1)
+	keys = append(keys, inboxKey)
+	for _, authorID := range bigAuthorIDs {
+		keys = append(keys, KeyFeedOutboxZSetPF+strconv.FormatInt(authorID, 10))
+	}
+
+	// 临时 key，读完即删，避免删帖后读到脏数据
+	tmpKey := KeyCachePF + "feed:" + strconv.FormatInt(userID, 10)
+	start := (pageNum - 1) * pageSize
+	stop := start + pageSize - 1
+
+	pipe := rdb.TxPipeline()
+	pipe.ZUnionStore(ctx, tmpKey, &redis.ZStore{
+		Keys:      keys,
+		Aggregate: "MAX",
+	})
+	rangeCmd := pipe.ZRevRange(ctx, tmpKey, start, stop)
+	cardCmd := pipe.ZCard(ctx, tmpKey)
+	pipe.Del(ctx, tmpKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, errors.Wrap(err, "redis:GetFeedPostIDs: ZUnionStore")
+	}
+
+	return rangeCmd.Val(), int(cardCmd.Val()), nil
+}
+
+// 注销账户，删除 timeline 相关数据
+func DelUserFeed(userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	uid := strconv.FormatInt(userID, 10)
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, KeyFeedInboxZSetPF+uid, KeyFeedOutboxZSetPF+uid)
+	pipe.SRem(ctx, KeyFeedBigAuthorSet, userID)
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:DelUserFeed: Del")
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+	登录失败计数与锁定
+	target 形如 account_<user_id> 或 ip_<ip_addr>
+*/
+
+// 获取 target 剩余的锁定时间，未锁定返回 0
+func GetLoginLockTTL(target string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.TTL(ctx, KeyLoginLockStringPF+target)
+	if cmd.Err() != nil {
+		return 0, errors.Wrap(cmd.Err(), "redis:GetLoginLockTTL: TTL")
+	}
+	if cmd.Val() < 0 { // -2: key 不存在；-1: 没有过期时间（不应该出现）
+		return 0, nil
+	}
+	return cmd.Val(), nil
+}
+
+// 失败次数 +1，返回当前窗口内的失败次数
+//
+// 第一次失败时通过 SET NX EX 创建带过期时间的 key，再 INCR（不会改变过期时间），两条命令在同一个事务中执行，
+// 避免 INCR 成功而 EXPIRE 没有执行，key 永不过期、账户一直被锁定
+func IncrLoginFailCount(target string, window time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := KeyLoginFailStringPF + target
+	pipe := rdb.TxPipeline()
+	pipe.SetNX(ctx, key, 0, window)
+	incrCmd := pipe.Incr(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errors.Wrap(err, "redis:IncrLoginFailCount: Exec")
+	}
+	return incrCmd.Val(), nil
+}
+
+func ClearLoginFailCount(target string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.Del(ctx, KeyLoginFailStringPF+target)
+	return errors.Wrap(cmd.Err(), "redis:ClearLoginFailCount: Del")
+}
+
+// 锁定 target，锁定时间随锁定次数指数增长：base * 2^(level-1)，不超过 max
+//
+// levelExpire 控制锁定次数的记忆时间，超过该时间没有再次被锁定，锁定时间重新从 base 开始
+func LockLogin(target string, base, max, levelExpire time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	levelKey := KeyLoginLockLevelStringPF + target
+	pipe := rdb.TxPipeline()
+	levelCmd := pipe.Incr(ctx, levelKey)
+	pipe.Expire(ctx, levelKey, levelExpire)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errors.Wrap(err, "redis:LockLogin: Incr")
+	}
+
+	duration := base
+	for i := int64(1); i < levelCmd.Val() && duration < max; i++ {
+		duration *= 2
+	}
+	if duration > max {
+		duration = max
+	}
+
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, KeyLoginLockStringPF+target, levelCmd.Val(), duration)
+		pipe.Del(ctx, KeyLoginFailStringPF+target) // 锁定后，重新计数
+		return nil
+	})
+	return duration, errors.Wrap(err, "redis:LockLogin: Set")
+}
@@ -0,0 +1,24 @@
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 未读通知数的缓存，不存在返回 redis.Nil
+func GetNotificationUnreadCount(userID int64) (int, error) {
+	count, err := get(KeyNotificationUnreadStringPF + strconv.FormatInt(userID, 10)).Int()
+	return count, errors.Wrap(err, "redis:GetNotificationUnreadCount: Get")
+}
+
+func SetNotificationUnreadCount(userID int64, count int, expireDuration time.Duration) error {
+	err := set(KeyNotificationUnreadStringPF+strconv.FormatInt(userID, 10), count, expireDuration)
+	return errors.Wrap(err, "redis:SetNotificationUnreadCount: Set")
+}
+
+// 未读通知数发生变化时，删除缓存
+func DelNotificationUnreadCount(userID int64) error {
+	return DelKeys([]string{KeyNotificationUnreadStringPF + strconv.FormatInt(userID, 10)})
+}
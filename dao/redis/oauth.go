@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// userID 不为 0 时，表示该授权用于已登录用户重新验证身份
+func SetOAuthState(state, provider, nonce string, userID int64, expireDuration time.Duration) error {
+	return errors.Wrap(
+		set(KeyOAuthStateStringPF+state, provider+" "+nonce+" "+strconv.FormatInt(userID, 10), expireDuration),
+		"redis:SetOAuthState")
+}
+
+// 获取并删除 state（state 只能使用一次），不存在返回空字符串
+func GetAndDelOAuthState(state string) (provider, nonce string, userID int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.GetDel(ctx, KeyOAuthStateStringPF+state)
+	if cmd.Err() != nil {
+		if errors.Is(cmd.Err(), redis.Nil) {
+			return "", "", 0, nil
+		}
+		return "", "", 0, errors.Wrap(cmd.Err(), "redis:GetAndDelOAuthState: GetDel")
+	}
+
+	provider, rest, _ := strings.Cut(cmd.Val(), " ")
+	nonce, userIDStr, _ := strings.Cut(rest, " ")
+	userID, _ = strconv.ParseInt(userIDStr, 10, 64)
+	return provider, nonce, userID, nil
+}
+
+func SetOAuthReauthToken(reauthToken string, userID int64, expireDuration time.Duration) error {
+	return errors.Wrap(
+		set(KeyOAuthReauthStringPF+reauthToken, userID, expireDuration),
+		"redis:SetOAuthReauthToken")
+}
+
+// 获取并删除 reauth_token 对应的 user_id（只能使用一次），不存在（过期）返回 0
+func GetAndDelOAuthReauthToken(reauthToken string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.GetDel(ctx, KeyOAuthReauthStringPF+reauthToken)
+	if cmd.Err() != nil {
+		if errors.Is(cmd.Err(), redis.Nil) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(cmd.Err(), "redis:GetAndDelOAuthReauthToken: GetDel")
+	}
+	userID, err := cmd.Int64()
+	return userID, errors.Wrap(err, "redis:GetAndDelOAuthReauthToken: Int64")
+}
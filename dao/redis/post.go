@@ -86,17 +86,23 @@ func SetPostScore(post_id int64, score float64) error {
 	return nil
 }
 
-func SetUserPostDirection(post_id, user_id int64, direction int8) error {
+// 返回用户原来的投票方向，没有投过票返回 0
+func SetUserPostDirection(post_id, user_id int64, direction int8) (int8, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
 	defer cancel()
-	cmd := rdb.ZAdd(ctx, KeyPostVotedZsetPF+strconv.FormatInt(post_id, 10), redis.Z{
+	key := KeyPostVotedZsetPF + strconv.FormatInt(post_id, 10)
+	member := strconv.FormatInt(user_id, 10)
+
+	pipe := rdb.TxPipeline()
+	oldCmd := pipe.ZScore(ctx, key, member)
+	pipe.ZAdd(ctx, key, redis.Z{
 		Member: user_id,
 		Score:  float64(direction),
 	})
-	if cmd.Err() != nil {
-		return errors.Wrap(cmd.Err(), "set user post direction")
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, errors.Wrap(err, "set user post direction")
 	}
-	return nil
+	return int8(oldCmd.Val()), nil
 }
 
 func GetPostIDs(pageNum, pageSize int64, orderBy string) ([]string, int, error) {
@@ -149,6 +155,57 @@ func GetPostIDsByCommunity(pageNum, pageSize int64, orderBy string, communityID
 	return getPostIDHelper(key, pageNum, pageSize)
 }
 
+// 查询多个社区（用户加入的社区）的 post_id
+//
+// 先对各社区的 ZSet 求并集，再与排序 ZSet 求交集（权重 [1, 0]，只保留排序 ZSet 的分数），
+// 结果缓存在 key 中，过期时间较短
+func GetPostIDsByCommunities(pageNum, pageSize int64, orderBy string, userID int64, communityIDs []int64) ([]string, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout*2)
+	defer cancel()
+
+	var oKey string // orderby key
+	if orderBy == "time" {
+		oKey = KeyPostTimeZset
+	} else {
+		oKey = KeyPostScoreZset
+	}
+
+	key := KeyCachePF + "post_joined:" + orderBy + ":" + strconv.FormatInt(userID, 10)
+	if rdb.Exists(ctx, key).Val() < 1 {
+		cKeys := make([]string, len(communityIDs))
+		for i, communityID := range communityIDs {
+			cKeys[i] = KeyPostCommunityZsetPF + strconv.FormatInt(communityID, 10)
+		}
+		unionKey := key + ":union"
+		tls := viper.GetInt("redis.cache_key_tls")
+
+		pipe := rdb.TxPipeline()
+		pipe.ZUnionStore(ctx, unionKey, &redis.ZStore{
+			Keys: cKeys,
+		})
+		pipe.ZInterStore(ctx, key, &redis.ZStore{
+			Keys:    []string{oKey, unionKey},
+			Weights: []float64{1, 0},
+		})
+		pipe.Del(ctx, unionKey)
+		pipe.Expire(ctx, key, time.Duration(tls)*time.Second)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, 0, errors.Wrap(err, "redis:GetPostIDsByCommunities: build cache")
+		}
+	}
+
+	return getPostIDHelper(key, pageNum, pageSize)
+}
+
+// 加入、退出社区后，删除缓存
+func DelJoinedPostCache(userID int64) error {
+	uid := strconv.FormatInt(userID, 10)
+	return DelKeys([]string{
+		KeyCachePF + "post_joined:time:" + uid,
+		KeyCachePF + "post_joined:score:" + uid,
+	})
+}
+
 func GetPostVoteNum(postID string) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
 	defer cancel()
@@ -224,6 +281,15 @@ func GetPostScores(postIDs []string) ([]int64, error) {
 	return postScores, nil
 }
 
+// 批量获取帖子的分数，不存在的帖子（例如已过期）分数为 0
+func MGetPostScores(postIDs []string) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.ZMScore(ctx, KeyPostScoreZset, postIDs...)
+	return cmd.Val(), errors.Wrap(cmd.Err(), "redis:MGetPostScores: ZMScore")
+}
+
 // 获取过期帖子的 ID
 func GetExpiredPostID(targetTimeStamp int64) ([]string, error) {
 	// postIDs 是按照发布时间降序排序的
@@ -364,3 +430,76 @@ func getPostIDHelper(key string, pageNum, pageSize int64) ([]string, int, error)
 // 	cmd := rdb.ZCount(ctx, KeyPostVotedZsetPF+strconv.FormatInt(post_id, 10), opinion, opinion)
 // 	return cmd.Val(), cmd.Err()
 // }
+
+// 遍历未过期帖子时，每批检查的帖子数量
+const votedPostScanBatch = 500
+
+// 所有未过期、且有人投过票的帖子 id
+//
+// 按发布时间分批遍历 KeyPostTimeZset，检查投票记录是否存在，避免使用会阻塞 redis 的 KEYS
+func GetVotedPostIDs() ([]string, error) {
+	postIDs := make([]string, 0)
+	for start := int64(0); ; start += votedPostScanBatch {
+		batch, done, err := getVotedPostIDsInRange(start, start+votedPostScanBatch-1)
+		if err != nil {
+			return nil, errors.Wrap(err, "redis:GetVotedPostIDs: getVotedPostIDsInRange")
+		}
+		postIDs = append(postIDs, batch...)
+		if done {
+			return postIDs, nil
+		}
+	}
+}
+
+// 检查排名在 [start, stop] 内的帖子是否有投票记录，done 表示已经遍历到最后
+func getVotedPostIDsInRange(start, stop int64) (postIDs []string, done bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	members, err := rdb.ZRange(ctx, KeyPostTimeZset, start, stop).Result()
+	if err != nil {
+		return nil, false, errors.Wrap(err, "ZRange")
+	}
+	if len(members) == 0 {
+		return nil, true, nil
+	}
+
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.IntCmd, len(members))
+	for i, postID := range members {
+		cmds[i] = pipe.Exists(ctx, KeyPostVotedZsetPF+postID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, false, errors.Wrap(err, "Exists")
+	}
+	postIDs = make([]string, 0, len(members))
+	for i, cmd := range cmds {
+		if cmd.Val() > 0 {
+			postIDs = append(postIDs, members[i])
+		}
+	}
+	return postIDs, int64(len(members)) < stop-start+1, nil
+}
+
+// 获取已写入搜索引擎的文档的 hash，不存在时返回空字符串
+func GetPostSearchHash(postID int64) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	hash, err := rdb.HGet(ctx, KeyPostSearchHashHash, strconv.FormatInt(postID, 10)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return hash, errors.Wrap(err, "redis:GetPostSearchHash: HGet")
+}
+
+func SetPostSearchHash(postID int64, hash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return errors.Wrap(rdb.HSet(ctx, KeyPostSearchHashHash, strconv.FormatInt(postID, 10), hash).Err(), "redis:SetPostSearchHash: HSet")
+}
+
+func DelPostSearchHash(postID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return errors.Wrap(rdb.HDel(ctx, KeyPostSearchHashHash, strconv.FormatInt(postID, 10)).Err(), "redis:DelPostSearchHash: HDel")
+}
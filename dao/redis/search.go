@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// 获取重建索引的进度，不存在时返回 0
+func GetReindexCursor(engine string) (int64, error) {
+	cursor, err := get(KeySearchReindexCursorStringPF + engine).Int64()
+	if err == Nil {
+		return 0, nil
+	}
+	return cursor, errors.Wrap(err, "redis:GetReindexCursor: Get")
+}
+
+func SetReindexCursor(engine string, cursor int64) error {
+	return errors.Wrap(set(KeySearchReindexCursorStringPF+engine, cursor, 0), "redis:SetReindexCursor: Set")
+}
+
+func DelReindexCursor(engine string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return errors.Wrap(rdb.Del(ctx, KeySearchReindexCursorStringPF+engine).Err(), "redis:DelReindexCursor: Del")
+}
+
+// 记录一次搜索词，按天分桶，每个桶只保留搜索次数最多的 maxTerms 个词
+func IncrSearchTerm(term string, day time.Time, expireDuration time.Duration, maxTerms int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := KeySearchTermZSetPF + day.Format("20060102")
+	pipe := rdb.Pipeline()
+	pipe.ZIncrBy(ctx, key, 1, term)
+	pipe.ZRemRangeByRank(ctx, key, 0, -maxTerms-1)
+	pipe.Expire(ctx, key, expireDuration)
+	_, err := pipe.Exec(ctx)
+	return errors.Wrap(err, "redis:IncrSearchTerm: Exec")
+}
+
+// 合并 days 内的搜索次数，返回以 prefix 开头、搜索次数最多的 size 个搜索词
+func GetPopularSearchTerms(days []time.Time, prefix string, size int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	keys := make([]string, len(days))
+	for i, day := range days {
+		keys[i] = KeySearchTermZSetPF + day.Format("20060102")
+	}
+	cmd := rdb.ZUnionWithScores(ctx, redis.ZStore{Keys: keys, Aggregate: "SUM"})
+	if cmd.Err() != nil {
+		return nil, errors.Wrap(cmd.Err(), "redis:GetPopularSearchTerms: ZUnionWithScores")
+	}
+
+	// 结果按 score 升序，倒序遍历
+	zs := cmd.Val()
+	terms := make([]string, 0, size)
+	for i := len(zs) - 1; i >= 0 && len(terms) < size; i-- {
+		if term, _ := zs[i].Member.(string); strings.HasPrefix(term, prefix) {
+			terms = append(terms, term)
+		}
+	}
+	return terms, nil
+}
@@ -0,0 +1,126 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis 用 miniredis 替换 rdb，测试结束后恢复
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	oldRDB, oldTimeout := rdb, redisTimeout
+	rdb = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	redisTimeout = time.Second
+	t.Cleanup(func() {
+		rdb.Close()
+		rdb, redisTimeout = oldRDB, oldTimeout
+	})
+	return mr
+}
+
+func TestGetPopularSearchTerms(t *testing.T) {
+	today := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+	lastWeek := today.AddDate(0, 0, -7)
+
+	// 每天的搜索记录，搜索词 -> 次数
+	searches := map[time.Time]map[string]int{
+		today:     {"golang": 3, "gorm": 1, "redis": 5},
+		yesterday: {"golang": 4, "gin": 2},
+		lastWeek:  {"gin": 10},
+	}
+
+	tests := []struct {
+		name   string
+		days   []time.Time
+		prefix string
+		size   int
+		want   []string
+	}{
+		{
+			name: "merge counts across days",
+			days: []time.Time{today, yesterday},
+			size: 10,
+			want: []string{"golang", "redis", "gin", "gorm"},
+		},
+		{
+			name:   "filter by prefix",
+			days:   []time.Time{today, yesterday},
+			prefix: "g",
+			size:   10,
+			want:   []string{"golang", "gin", "gorm"},
+		},
+		{
+			name:   "limit size",
+			days:   []time.Time{today, yesterday},
+			prefix: "g",
+			size:   2,
+			want:   []string{"golang", "gin"},
+		},
+		{
+			name: "days out of range are ignored",
+			days: []time.Time{today},
+			size: 10,
+			want: []string{"redis", "golang", "gorm"},
+		},
+		{
+			name:   "no matching term",
+			days:   []time.Time{today, yesterday},
+			prefix: "mysql",
+			size:   10,
+			want:   []string{},
+		},
+		{
+			name: "no search in range",
+			days: []time.Time{today.AddDate(0, 0, -30)},
+			size: 10,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRedis(t)
+			for day, terms := range searches {
+				for term, count := range terms {
+					for i := 0; i < count; i++ {
+						if err := IncrSearchTerm(term, day, time.Hour, 100); err != nil {
+							t.Fatalf("IncrSearchTerm() error = %v", err)
+						}
+					}
+				}
+			}
+
+			got, err := GetPopularSearchTerms(tt.days, tt.prefix, tt.size)
+			if err != nil {
+				t.Fatalf("GetPopularSearchTerms() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetPopularSearchTerms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncrSearchTermKeepsTopTerms(t *testing.T) {
+	newTestRedis(t)
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	for _, term := range []string{"golang", "golang", "golang", "gin", "gin", "gorm"} {
+		if err := IncrSearchTerm(term, day, time.Hour, 2); err != nil {
+			t.Fatalf("IncrSearchTerm() error = %v", err)
+		}
+	}
+
+	got, err := GetPopularSearchTerms([]time.Time{day}, "", 10)
+	if err != nil {
+		t.Fatalf("GetPopularSearchTerms() error = %v", err)
+	}
+	// 只保留搜索次数最多的 2 个词，gorm 写入后即被移除
+	if want := []string{"golang", "gin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPopularSearchTerms() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// 广播实时推送的事件，所有实例都会收到
+func PublishStreamEvent(event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "redis:PublishStreamEvent: Marshal")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	cmd := rdb.Publish(ctx, KeyStreamEventChannel, data)
+	return errors.Wrap(cmd.Err(), "redis:PublishStreamEvent: Publish")
+}
+
+// 订阅实时推送的事件，使用完毕后需要 Close
+func SubscribeStreamEvents() *redis.PubSub {
+	return rdb.Subscribe(context.Background(), KeyStreamEventChannel)
+}
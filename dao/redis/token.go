@@ -26,3 +26,15 @@ func GetUserRefreshToken(userID int64) (string, error) {
 	cmd := get(KeyRefreshTokenStringPF + strconv.FormatInt(userID, 10))
 	return cmd.Val(), errors.Wrap(cmd.Err(), "get refresh_token")
 }
+
+func DelUserAccessToken(userID int64) error {
+	err := DelKeys([]string{KeyAccessTokenStringPF + strconv.FormatInt(userID, 10)})
+	return errors.Wrap(err, "del access_token")
+}
+
+// 吊销用户的所有 token
+func DelUserTokens(userID int64) error {
+	userIDStr := strconv.FormatInt(userID, 10)
+	err := DelKeys([]string{KeyAccessTokenStringPF + userIDStr, KeyRefreshTokenStringPF + userIDStr})
+	return errors.Wrap(err, "del tokens")
+}
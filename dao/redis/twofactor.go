@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+func SetTwoFactorChallenge(challengeToken string, userID int64, expireDuration time.Duration) error {
+	return errors.Wrap(
+		set(KeyTwoFactorChallengeStringPF+challengeToken, userID, expireDuration),
+		"redis:SetTwoFactorChallenge")
+}
+
+// 获取 challenge 对应的 user_id，不存在（过期）返回 0
+func GetTwoFactorChallenge(challengeToken string) (int64, error) {
+	cmd := get(KeyTwoFactorChallengeStringPF + challengeToken)
+	if err := cmd.Err(); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "redis:GetTwoFactorChallenge")
+	}
+	userID, err := cmd.Int64()
+	return userID, errors.Wrap(err, "redis:GetTwoFactorChallenge: Int64")
+}
+
+func DelTwoFactorChallenge(challengeToken string) error {
+	return errors.Wrap(DelKeys([]string{KeyTwoFactorChallengeStringPF + challengeToken}), "redis:DelTwoFactorChallenge")
+}
+
+// 标记某个时间步的 code 已被使用，返回 false 表示已经被使用过
+func MarkTOTPStepUsed(userID, step int64, expireDuration time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	key := KeyTwoFactorUsedStringPF + strconv.FormatInt(userID, 10) + "_" + strconv.FormatInt(step, 10)
+	cmd := rdb.SetNX(ctx, key, 1, expireDuration)
+	return cmd.Val(), errors.Wrap(cmd.Err(), "redis:MarkTOTPStepUsed: SetNX")
+}
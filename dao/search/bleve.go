@@ -0,0 +1,100 @@
+package search
+
+import (
+	"bluebell/dao/bleve"
+	"bluebell/models"
+	"context"
+	"time"
+)
+
+type bleveEngine struct{}
+
+func (*bleveEngine) Name() string {
+	return EngineBleve
+}
+
+func (*bleveEngine) Index(doc *models.PostDoc) error {
+	return bleve.CreatePost(withCreatedAt(doc, func(t time.Time) any { return t }))
+}
+
+func (*bleveEngine) Delete(postID int64) error {
+	return bleve.DeletePost(postID)
+}
+
+func (*bleveEngine) Update(doc *models.PostDoc) error {
+	return bleve.UpdatePost(withCreatedAt(doc, func(t time.Time) any { return t }))
+}
+
+func (*bleveEngine) Query(ctx context.Context, params *models.ParamPostListByKeyword) (*models.PostSearchResult, error) {
+	return bleve.SearchPosts(ctx, params)
+}
+
+func (*bleveEngine) SuggestTitles(ctx context.Context, prefix string, size int) ([]models.PostTitleSuggestionDTO, error) {
+	return bleve.SuggestPostTitles(ctx, prefix, size)
+}
+
+func (*bleveEngine) RelatedPosts(ctx context.Context, doc *models.PostDoc, size int) ([]models.PostSimilarity, error) {
+	return bleve.GetRelatedPosts(ctx, doc, size)
+}
+
+func (*bleveEngine) IndexComment(doc *models.CommentDoc) error {
+	tmp := *doc
+	tmp.CreatedAt = time.Now()
+	return bleve.CreateComment(&tmp)
+}
+
+func (*bleveEngine) DeleteComments(commentIDs []int64) error {
+	return bleve.DeleteComments(commentIDs)
+}
+
+func (*bleveEngine) QueryComments(ctx context.Context, params *models.ParamCommentSearch) ([]string, int, error) {
+	return bleve.GetCommentIDsByKeyword(ctx, params)
+}
+
+func (*bleveEngine) IndexUser(doc *models.UserDoc) error {
+	return bleve.CreateUser(doc)
+}
+
+func (*bleveEngine) DeleteUser(userID int64) error {
+	return bleve.DeleteUser(userID)
+}
+
+func (*bleveEngine) QueryUsers(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	return bleve.GetUserIDsByKeyword(ctx, params)
+}
+
+func (*bleveEngine) IndexCommunity(doc *models.CommunityDoc) error {
+	return bleve.CreateCommunity(doc)
+}
+
+func (*bleveEngine) QueryCommunities(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	return bleve.GetCommunityIDsByKeyword(ctx, params)
+}
+
+func (*bleveEngine) IndexPosts(docs []*models.PostDoc) error {
+	tmp := make([]*models.PostDoc, len(docs))
+	for i, doc := range docs {
+		tmp[i] = withCreatedAt(doc, func(t time.Time) any { return t })
+	}
+	return bleve.CreatePosts(tmp)
+}
+
+func (*bleveEngine) PostIDsExist(postIDs []string) (map[string]bool, error) {
+	return bleve.GetExistingPostIDs(postIDs)
+}
+
+func (*bleveEngine) ScanPostIDs(batchSize int, fn func(postIDs []string) error) error {
+	return bleve.ScanPostIDs(batchSize, fn)
+}
+
+// withCreatedAt 各引擎对时间字段的类型要求不同，复制一份 doc 再转换，避免相互影响
+// doc.CreatedAt 为 time.Time，未设置时使用当前时间
+func withCreatedAt(doc *models.PostDoc, convert func(t time.Time) any) *models.PostDoc {
+	tmp := *doc
+	createdAt, ok := tmp.CreatedAt.(time.Time)
+	if !ok {
+		createdAt = time.Now()
+	}
+	tmp.CreatedAt = convert(createdAt)
+	return &tmp
+}
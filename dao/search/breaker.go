@@ -0,0 +1,109 @@
+package search
+
+import (
+	"bluebell/dao/elasticsearch"
+	"bluebell/models"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 熔断器状态
+const (
+	StateClosed   = "closed"    // 正常，请求直接交给引擎处理
+	StateOpen     = "open"      // 熔断中，请求直接跳过该引擎
+	StateHalfOpen = "half_open" // 熔断时间结束，放行一个请求探测引擎是否恢复
+)
+
+// breaker 引擎的熔断器，连续失败 threshold 次后熔断 openTime，之后放行一个探测请求，成功则恢复
+type breaker struct {
+	mu sync.Mutex
+
+	threshold int
+	openTime  time.Duration
+
+	state         string
+	failures      int  // 连续失败次数
+	probing       bool // 半开状态下，是否已经放行了探测请求
+	openedAt      time.Time
+	lastFailureAt time.Time
+}
+
+func newBreaker(threshold int, openTime time.Duration) *breaker {
+	return &breaker{threshold: threshold, openTime: openTime, state: StateClosed}
+}
+
+// allow 是否放行请求，放行后必须调用 record 记录结果
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.openTime {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return true
+	case StateHalfOpen:
+		if b.probing { // 同一时间只放行一个探测请求
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record 记录请求的结果，failed 表示引擎本身出错（见 isFailure）
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	b.lastFailureAt = time.Now()
+	if b.state == StateHalfOpen || b.failures >= b.threshold { // 探测失败，重新熔断
+		b.state = StateOpen
+		b.openedAt = b.lastFailureAt
+	}
+}
+
+// isFailure 是否计为引擎失败：网络错误、超时、引擎内部错误（5xx）计为失败，
+// 查询本身有误（4xx）说明引擎可以正常响应，不计为失败
+func isFailure(err error) bool {
+	var statusErr *elasticsearch.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	return err != nil
+}
+
+func (b *breaker) status(name string, isQueryEngine bool) *models.SearchEngineStatusDTO {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := &models.SearchEngineStatusDTO{
+		Name:          name,
+		State:         b.state,
+		IsQueryEngine: isQueryEngine,
+		Failures:      b.failures,
+	}
+	if !b.lastFailureAt.IsZero() {
+		lastFailureAt := models.Time(b.lastFailureAt)
+		status.LastFailureAt = &lastFailureAt
+	}
+	if b.state != StateClosed {
+		openedAt := models.Time(b.openedAt)
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
@@ -0,0 +1,263 @@
+package search
+
+import (
+	"bluebell/dao/elasticsearch"
+	"bluebell/logger"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// queryWithFallback 会记录降级日志，测试前把日志写到临时目录
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bluebell-search")
+	if err != nil {
+		panic(err)
+	}
+	viper.Set("logger.path", filepath.Join(dir, "bluebell.log"))
+	logger.InitLogger()
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// 熔断器的操作
+const (
+	opAllow   = "allow"   // 调用 allow，期望结果为 allowed
+	opSucceed = "succeed" // 记录一次成功
+	opFail    = "fail"    // 记录一次引擎失败
+	opElapse  = "elapse"  // 熔断时间结束
+)
+
+type breakerStep struct {
+	op        string
+	allowed   bool
+	wantState string
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{
+			name: "trip after threshold",
+			steps: []breakerStep{
+				{op: opFail, wantState: StateClosed},
+				{op: opFail, wantState: StateOpen},
+				{op: opAllow, allowed: false, wantState: StateOpen},
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []breakerStep{
+				{op: opFail, wantState: StateClosed},
+				{op: opSucceed, wantState: StateClosed},
+				{op: opFail, wantState: StateClosed},
+				{op: opAllow, allowed: true, wantState: StateClosed},
+			},
+		},
+		{
+			name: "half open lets one probe through and recovers",
+			steps: []breakerStep{
+				{op: opFail}, {op: opFail, wantState: StateOpen},
+				{op: opElapse},
+				{op: opAllow, allowed: true, wantState: StateHalfOpen},
+				{op: opAllow, allowed: false, wantState: StateHalfOpen},
+				{op: opSucceed, wantState: StateClosed},
+				{op: opAllow, allowed: true, wantState: StateClosed},
+			},
+		},
+		{
+			name: "failed probe opens again",
+			steps: []breakerStep{
+				{op: opFail}, {op: opFail, wantState: StateOpen},
+				{op: opElapse},
+				{op: opAllow, allowed: true, wantState: StateHalfOpen},
+				{op: opFail, wantState: StateOpen},
+				{op: opAllow, allowed: false, wantState: StateOpen},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(2, time.Minute)
+			for i, step := range tt.steps {
+				switch step.op {
+				case opAllow:
+					if got := b.allow(); got != step.allowed {
+						t.Fatalf("step %d: allow() = %v, want %v", i, got, step.allowed)
+					}
+				case opSucceed:
+					b.record(false)
+				case opFail:
+					b.record(true)
+				case opElapse:
+					b.openedAt = b.openedAt.Add(-b.openTime)
+				}
+				if step.wantState != "" && b.state != step.wantState {
+					t.Fatalf("step %d (%v): state = %v, want %v", i, step.op, b.state, step.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "success", err: nil, want: false},
+		{name: "bad request", err: errors.WithStack(&elasticsearch.StatusError{Op: "search", StatusCode: http.StatusBadRequest}), want: false},
+		{name: "server error", err: errors.WithStack(&elasticsearch.StatusError{Op: "search", StatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "timeout", err: errors.Wrap(context.DeadlineExceeded, "search"), want: true},
+		{name: "other error", err: errors.New("connection refused"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isFailure(tt.err); got != tt.want {
+				t.Errorf("isFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeEngine 只实现 Name，查询的行为由传给 queryWithFallback 的 fn 决定
+type fakeEngine struct {
+	SearchEngine
+	name string
+}
+
+func (e *fakeEngine) Name() string {
+	return e.name
+}
+
+// setupEngines 替换查询顺序和熔断器，测试结束后恢复
+func setupEngines(t *testing.T, threshold int, timeout time.Duration, names ...string) {
+	t.Helper()
+	oldOrder, oldBreakers, oldTimeout := queryOrder, breakers, queryTimeout
+	t.Cleanup(func() { queryOrder, breakers, queryTimeout = oldOrder, oldBreakers, oldTimeout })
+
+	queryOrder = make([]SearchEngine, 0, len(names))
+	breakers = make(map[string]*breaker, len(names))
+	for _, name := range names {
+		queryOrder = append(queryOrder, &fakeEngine{name: name})
+		breakers[name] = newBreaker(threshold, time.Minute)
+	}
+	queryTimeout = timeout
+}
+
+func TestQueryWithFallback(t *testing.T) {
+	badRequest := errors.WithStack(&elasticsearch.StatusError{Op: "search", StatusCode: http.StatusBadRequest})
+	serverError := errors.WithStack(&elasticsearch.StatusError{Op: "search", StatusCode: http.StatusInternalServerError})
+
+	tests := []struct {
+		name string
+		// 每个引擎每次查询返回的错误，为 nil 表示成功；"hang" 表示一直阻塞到超时
+		primaryErr error
+		hang       bool
+		// 连续查询的次数
+		times int
+		// 最后一次查询
+		wantErr     bool
+		wantCalls   map[string]int
+		wantPrimary string
+	}{
+		{
+			name:        "primary succeeds",
+			times:       3,
+			wantCalls:   map[string]int{"primary": 3},
+			wantPrimary: StateClosed,
+		},
+		{
+			name:        "fall back and trip on engine failures",
+			primaryErr:  serverError,
+			times:       3,
+			wantCalls:   map[string]int{"primary": 2, "secondary": 3}, // 第 3 次查询时 primary 已熔断
+			wantPrimary: StateOpen,
+		},
+		{
+			name:        "query errors do not trip",
+			primaryErr:  badRequest,
+			times:       3,
+			wantCalls:   map[string]int{"primary": 3, "secondary": 3},
+			wantPrimary: StateClosed,
+		},
+		{
+			name:        "timeout counts as failure",
+			hang:        true,
+			times:       2,
+			wantCalls:   map[string]int{"primary": 2, "secondary": 2},
+			wantPrimary: StateOpen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupEngines(t, 2, 20*time.Millisecond, "primary", "secondary")
+			calls := make(map[string]int)
+			var err error
+			for i := 0; i < tt.times; i++ {
+				var used string
+				err = queryWithFallback(func(ctx context.Context, engine SearchEngine) error {
+					calls[engine.Name()]++
+					if engine.Name() != "primary" {
+						used = engine.Name()
+						return nil
+					}
+					if tt.hang {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+					if tt.primaryErr == nil {
+						used = engine.Name()
+					}
+					return tt.primaryErr
+				})
+				if err == nil && used == "" {
+					t.Fatalf("query %d succeeded without any engine", i)
+				}
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			for name, want := range tt.wantCalls {
+				if calls[name] != want {
+					t.Errorf("calls[%v] = %d, want %d", name, calls[name], want)
+				}
+			}
+			if got := breakers["primary"].state; got != tt.wantPrimary {
+				t.Errorf("primary state = %v, want %v", got, tt.wantPrimary)
+			}
+		})
+	}
+}
+
+func TestQueryWithFallbackAllEnginesFail(t *testing.T) {
+	setupEngines(t, 1, 20*time.Millisecond, "primary", "secondary")
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) error {
+		return errors.New("connection refused")
+	})
+	if err == nil {
+		t.Fatal("queryWithFallback should fail when every engine fails")
+	}
+
+	// 所有引擎都已熔断，不再调用 fn
+	called := false
+	err = queryWithFallback(func(ctx context.Context, engine SearchEngine) error {
+		called = true
+		return nil
+	})
+	if err == nil || called {
+		t.Errorf("err = %v, called = %v, want breaker errors without calling any engine", err, called)
+	}
+}
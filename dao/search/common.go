@@ -0,0 +1,309 @@
+package search
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"bluebell/dao/mysql"
+	"bluebell/logger"
+	"bluebell/models"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	EngineBleve         = "bleve"
+	EngineElasticsearch = "elasticsearch"
+	EngineAll           = "all" // 所有启用的引擎
+)
+
+// SearchEngine 帖子搜索引擎的统一抽象
+type SearchEngine interface {
+	Name() string
+	Index(doc *models.PostDoc) error
+	Delete(postID int64) error
+	Update(doc *models.PostDoc) error
+	Query(ctx context.Context, params *models.ParamPostListByKeyword) (*models.PostSearchResult, error)
+	SuggestTitles(ctx context.Context, prefix string, size int) ([]models.PostTitleSuggestionDTO, error)
+	RelatedPosts(ctx context.Context, doc *models.PostDoc, size int) ([]models.PostSimilarity, error)
+
+	IndexComment(doc *models.CommentDoc) error
+	DeleteComments(commentIDs []int64) error
+	QueryComments(ctx context.Context, params *models.ParamCommentSearch) ([]string, int, error)
+
+	IndexUser(doc *models.UserDoc) error
+	DeleteUser(userID int64) error
+	QueryUsers(ctx context.Context, params *models.ParamSearch) ([]string, int, error)
+	IndexCommunity(doc *models.CommunityDoc) error
+	QueryCommunities(ctx context.Context, params *models.ParamSearch) ([]string, int, error)
+
+	// 用于重建、校验索引
+	IndexPosts(docs []*models.PostDoc) error
+	PostIDsExist(postIDs []string) (map[string]bool, error)
+	ScanPostIDs(batchSize int, fn func(postIDs []string) error) error
+}
+
+var engines []SearchEngine   // 所有启用的搜索引擎，写操作会扇出到每个引擎
+var queryEngine SearchEngine // 负责处理查询的搜索引擎
+
+// 查询的顺序：queryEngine 在前，出错或熔断时依次降级到其他启用的引擎
+var queryOrder []SearchEngine
+var breakers map[string]*breaker
+var queryTimeout time.Duration // 单次查询的超时时间，超时计为引擎失败
+
+var mysqlFallback bool // 所有引擎都不可用时，是否使用 mysql 兜底搜索帖子
+
+// Init 根据配置初始化搜索引擎，需要在 bleve、elasticsearch 初始化之后调用
+func Init() {
+	engines = engines[:0]
+	if viper.GetBool("elasticsearch.enable") {
+		engines = append(engines, new(esEngine))
+	}
+	if viper.GetBool("bleve.enable") {
+		engines = append(engines, new(bleveEngine))
+	}
+	if len(engines) == 0 {
+		return
+	}
+
+	// 查询使用 search.engine 指定的引擎，未启用时退化为第一个启用的引擎
+	name := viper.GetString("search.engine")
+	queryEngine = engines[0]
+	for _, engine := range engines {
+		if engine.Name() == name {
+			queryEngine = engine
+			break
+		}
+	}
+
+	queryOrder = []SearchEngine{queryEngine}
+	breakers = make(map[string]*breaker, len(engines))
+	threshold := viper.GetInt("search.breaker.failure_threshold")
+	openTime := time.Second * time.Duration(viper.GetInt("search.breaker.open_time"))
+	for _, engine := range engines {
+		if engine != queryEngine {
+			queryOrder = append(queryOrder, engine)
+		}
+		breakers[engine.Name()] = newBreaker(threshold, openTime)
+	}
+	queryTimeout = time.Millisecond * time.Duration(viper.GetInt("search.breaker.timeout"))
+	mysqlFallback = viper.GetBool("search.fallback.mysql")
+}
+
+// Engines 根据名称获取启用的引擎，名称为 EngineAll 时返回所有启用的引擎
+func Engines(name string) ([]SearchEngine, error) {
+	if name == EngineAll {
+		if len(engines) == 0 {
+			return nil, errors.New("search:Engines: no search engine enabled")
+		}
+		return engines, nil
+	}
+	for _, engine := range engines {
+		if engine.Name() == name {
+			return []SearchEngine{engine}, nil
+		}
+	}
+	return nil, errors.Errorf("search:Engines: search engine %v is not enabled", name)
+}
+
+// Enabled 是否至少启用了一个搜索引擎
+func Enabled() bool {
+	return queryEngine != nil
+}
+
+// IndexPost 将帖子写入所有启用的搜索引擎，任一引擎失败都不会影响其他引擎
+func IndexPost(doc *models.PostDoc) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.Index(doc)
+	})
+}
+
+// DeletePost 从所有启用的搜索引擎中删除帖子
+func DeletePost(postID int64) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.Delete(postID)
+	})
+}
+
+// UpdatePost 更新所有启用的搜索引擎中的帖子
+func UpdatePost(doc *models.PostDoc) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.Update(doc)
+	})
+}
+
+// QueryPosts 使用查询引擎根据关键字及过滤条件检索帖子，所有引擎都不可用时，按配置使用 mysql 兜底
+func QueryPosts(params *models.ParamPostListByKeyword) (*models.PostSearchResult, error) {
+	if queryEngine == nil {
+		return nil, errors.New("search:QueryPosts: no search engine enabled")
+	}
+	var res *models.PostSearchResult
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) (err error) {
+		res, err = engine.Query(ctx, params)
+		return err
+	})
+	if err != nil && mysqlFallback {
+		logger.Warnf("search:QueryPosts: all search engines are unavailable, fallback to mysql, reason: %v", err.Error())
+		postIDs, total, mysqlErr := mysql.SelectPostIDsByKeyword(params)
+		if mysqlErr == nil {
+			return &models.PostSearchResult{PostIDs: postIDs, Total: int(total)}, nil
+		}
+		err = stderrors.Join(err, mysqlErr)
+	}
+	return res, errors.Wrap(err, "search:QueryPosts")
+}
+
+// SuggestPostTitles 使用查询引擎补全以 prefix 开头的帖子标题
+func SuggestPostTitles(prefix string, size int) ([]models.PostTitleSuggestionDTO, error) {
+	if queryEngine == nil {
+		return nil, errors.New("search:SuggestPostTitles: no search engine enabled")
+	}
+	var titles []models.PostTitleSuggestionDTO
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) (err error) {
+		titles, err = engine.SuggestTitles(ctx, prefix, size)
+		return err
+	})
+	return titles, errors.Wrap(err, "search:SuggestPostTitles")
+}
+
+// RelatedPosts 使用查询引擎查找与 doc 相似的帖子，按相似度降序
+func RelatedPosts(doc *models.PostDoc, size int) ([]models.PostSimilarity, error) {
+	if queryEngine == nil {
+		return nil, errors.New("search:RelatedPosts: no search engine enabled")
+	}
+	var posts []models.PostSimilarity
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) (err error) {
+		posts, err = engine.RelatedPosts(ctx, doc, size)
+		return err
+	})
+	return posts, errors.Wrap(err, "search:RelatedPosts")
+}
+
+// IndexComment 将评论写入所有启用的搜索引擎
+func IndexComment(doc *models.CommentDoc) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.IndexComment(doc)
+	})
+}
+
+// DeleteComments 从所有启用的搜索引擎中删除评论
+func DeleteComments(commentIDs []int64) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	return fanOut(func(engine SearchEngine) error {
+		return engine.DeleteComments(commentIDs)
+	})
+}
+
+// QueryCommentIDs 使用查询引擎根据关键字检索评论 id
+func QueryCommentIDs(params *models.ParamCommentSearch) ([]string, int, error) {
+	if queryEngine == nil {
+		return nil, 0, errors.New("search:QueryCommentIDs: no search engine enabled")
+	}
+	var commentIDs []string
+	var total int
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) (err error) {
+		commentIDs, total, err = engine.QueryComments(ctx, params)
+		return err
+	})
+	return commentIDs, total, errors.Wrap(err, "search:QueryCommentIDs")
+}
+
+// IndexUser 将用户写入所有启用的搜索引擎
+func IndexUser(doc *models.UserDoc) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.IndexUser(doc)
+	})
+}
+
+// DeleteUser 从所有启用的搜索引擎中删除用户
+func DeleteUser(userID int64) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.DeleteUser(userID)
+	})
+}
+
+// QueryUserIDs 使用查询引擎根据关键字检索用户 id
+func QueryUserIDs(params *models.ParamSearch) ([]string, int, error) {
+	if queryEngine == nil {
+		return nil, 0, errors.New("search:QueryUserIDs: no search engine enabled")
+	}
+	var userIDs []string
+	var total int
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) (err error) {
+		userIDs, total, err = engine.QueryUsers(ctx, params)
+		return err
+	})
+	return userIDs, total, errors.Wrap(err, "search:QueryUserIDs")
+}
+
+// IndexCommunity 将社区写入所有启用的搜索引擎
+func IndexCommunity(doc *models.CommunityDoc) error {
+	return fanOut(func(engine SearchEngine) error {
+		return engine.IndexCommunity(doc)
+	})
+}
+
+// QueryCommunityIDs 使用查询引擎根据关键字检索社区 id
+func QueryCommunityIDs(params *models.ParamSearch) ([]string, int, error) {
+	if queryEngine == nil {
+		return nil, 0, errors.New("search:QueryCommunityIDs: no search engine enabled")
+	}
+	var communityIDs []string
+	var total int
+	err := queryWithFallback(func(ctx context.Context, engine SearchEngine) (err error) {
+		communityIDs, total, err = engine.QueryCommunities(ctx, params)
+		return err
+	})
+	return communityIDs, total, errors.Wrap(err, "search:QueryCommunityIDs")
+}
+
+// Status 获取各个引擎的健康状态
+func Status() *models.SearchStatusDTO {
+	status := &models.SearchStatusDTO{
+		Engines:       make([]*models.SearchEngineStatusDTO, 0, len(queryOrder)),
+		MySQLFallback: mysqlFallback,
+	}
+	for _, engine := range queryOrder {
+		status.Engines = append(status.Engines, breakers[engine.Name()].status(engine.Name(), engine == queryEngine))
+	}
+	return status
+}
+
+// queryWithFallback 按 queryOrder 依次执行 fn，跳过熔断中的引擎，直到某个引擎成功
+//
+// 每个引擎的查询都有独立的超时时间，避免引擎卡住时请求一直阻塞
+func queryWithFallback(fn func(ctx context.Context, engine SearchEngine) error) error {
+	var errs []error
+	for _, engine := range queryOrder {
+		b := breakers[engine.Name()]
+		if !b.allow() {
+			errs = append(errs, errors.Errorf("%v: circuit breaker is open", engine.Name()))
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		err := fn(ctx, engine)
+		cancel()
+		b.record(isFailure(err))
+		if err == nil {
+			return nil
+		}
+		logger.Warnf("search:queryWithFallback: %v failed, reason: %v", engine.Name(), err.Error())
+		errs = append(errs, errors.Wrap(err, engine.Name()))
+	}
+	return stderrors.Join(errs...)
+}
+
+// fanOut 对每个引擎执行 fn，并聚合所有错误
+func fanOut(fn func(engine SearchEngine) error) error {
+	var errs []error
+	for _, engine := range engines {
+		if err := fn(engine); err != nil {
+			errs = append(errs, errors.Wrap(err, engine.Name()))
+		}
+	}
+	return stderrors.Join(errs...)
+}
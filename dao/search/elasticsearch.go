@@ -0,0 +1,93 @@
+package search
+
+import (
+	"bluebell/dao/elasticsearch"
+	"bluebell/models"
+	"context"
+	"time"
+)
+
+type esEngine struct{}
+
+func (*esEngine) Name() string {
+	return EngineElasticsearch
+}
+
+func (*esEngine) Index(doc *models.PostDoc) error {
+	return elasticsearch.CreatePost(withCreatedAt(doc, toESTime))
+}
+
+func (*esEngine) Delete(postID int64) error {
+	return elasticsearch.DeletePost(postID)
+}
+
+func (*esEngine) Update(doc *models.PostDoc) error {
+	return elasticsearch.UpdatePost(withCreatedAt(doc, toESTime))
+}
+
+func (*esEngine) Query(ctx context.Context, params *models.ParamPostListByKeyword) (*models.PostSearchResult, error) {
+	return elasticsearch.SearchPosts(ctx, params)
+}
+
+func (*esEngine) SuggestTitles(ctx context.Context, prefix string, size int) ([]models.PostTitleSuggestionDTO, error) {
+	return elasticsearch.SuggestPostTitles(ctx, prefix, size)
+}
+
+func (*esEngine) RelatedPosts(ctx context.Context, doc *models.PostDoc, size int) ([]models.PostSimilarity, error) {
+	return elasticsearch.GetRelatedPosts(ctx, doc, size)
+}
+
+func (*esEngine) IndexComment(doc *models.CommentDoc) error {
+	tmp := *doc
+	tmp.CreatedAt = models.Time(time.Now())
+	return elasticsearch.CreateComment(&tmp)
+}
+
+func (*esEngine) DeleteComments(commentIDs []int64) error {
+	return elasticsearch.DeleteComments(commentIDs)
+}
+
+func (*esEngine) QueryComments(ctx context.Context, params *models.ParamCommentSearch) ([]string, int, error) {
+	return elasticsearch.GetCommentIDsByKeyword(ctx, params)
+}
+
+func (*esEngine) IndexUser(doc *models.UserDoc) error {
+	return elasticsearch.CreateUser(doc)
+}
+
+func (*esEngine) DeleteUser(userID int64) error {
+	return elasticsearch.DeleteUser(userID)
+}
+
+func (*esEngine) QueryUsers(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	return elasticsearch.GetUserIDsByKeyword(ctx, params)
+}
+
+func (*esEngine) IndexCommunity(doc *models.CommunityDoc) error {
+	return elasticsearch.CreateCommunity(doc)
+}
+
+func (*esEngine) QueryCommunities(ctx context.Context, params *models.ParamSearch) ([]string, int, error) {
+	return elasticsearch.GetCommunityIDsByKeyword(ctx, params)
+}
+
+func (*esEngine) IndexPosts(docs []*models.PostDoc) error {
+	tmp := make([]*models.PostDoc, len(docs))
+	for i, doc := range docs {
+		tmp[i] = withCreatedAt(doc, toESTime)
+	}
+	return elasticsearch.CreatePosts(tmp)
+}
+
+func (*esEngine) PostIDsExist(postIDs []string) (map[string]bool, error) {
+	return elasticsearch.GetExistingPostIDs(postIDs)
+}
+
+func (*esEngine) ScanPostIDs(batchSize int, fn func(postIDs []string) error) error {
+	return elasticsearch.ScanPostIDs(batchSize, fn)
+}
+
+// es 中 created_time 的格式为 yyyy-MM-dd HH:mm:ss
+func toESTime(t time.Time) any {
+	return models.Time(t)
+}
@@ -7,14 +7,23 @@ var (
 	ErrUserExist     = errors.New("用户已经存在")
 	ErrUserNotExist  = errors.New("用户不存在")
 	ErrWrongPassword = errors.New("密码错误")
+	ErrAccountLocked = errors.New("账户已被锁定")
+
+	// 2fa
+	ErrTwoFactorEnabled    = errors.New("已开启两步验证")
+	ErrTwoFactorNotEnabled = errors.New("未开启两步验证")
+
+	// oauth
+	ErrOAuthFailed = errors.New("第三方登录失败")
 
 	// common
-	ErrGenToken     = errors.New("生成 Token 失败")
-	ErrInvalidToken = errors.New("无效的 Token")
-	ErrExpiredToken = errors.New("过期的 Token")
-	ErrNotFound     = errors.New("未找到")
-	ErrInternal     = errors.New("内部错误")
-	ErrTimeout      = errors.New("请求超时")
+	ErrGenToken      = errors.New("生成 Token 失败")
+	ErrInvalidToken  = errors.New("无效的 Token")
+	ErrExpiredToken  = errors.New("过期的 Token")
+	ErrNotFound      = errors.New("未找到")
+	ErrInternal      = errors.New("内部错误")
+	ErrTimeout       = errors.New("请求超时")
+	ErrServerClosing = errors.New("服务器正在关闭")
 
 	// community
 	ErrNoSuchCommunity = errors.New("没有该社区")
@@ -30,7 +39,9 @@ var (
 	ErrInvalidParam = errors.New("无效参数")
 
 	// permissions
-	ErrForbidden = errors.New("禁止访问")
+	ErrForbidden      = errors.New("禁止访问")
+	ErrBlocked        = errors.New("对方已将你拉黑")
+	ErrKarmaNotEnough = errors.New("karma 不足")
 
 	// email
 	ErrInvalidVerificationCode = errors.New("无效验证码")
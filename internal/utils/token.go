@@ -2,6 +2,7 @@ package utils
 
 import (
 	bluebell "bluebell/errors"
+	"bluebell/models"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,6 +12,7 @@ import (
 
 type UserClaims struct {
 	UserID int64 `json:"user_id"`
+	models.RoleInfo
 	jwt.RegisteredClaims
 }
 
@@ -33,11 +35,13 @@ func InitToken() {
 	issuer = "Sky_Lee"
 }
 
-func GenToken(UserID int64, Type TokenType) (string, error) {
+// role 只对 access_token 有效
+func GenToken(UserID int64, role models.RoleInfo, Type TokenType) (string, error) {
 	var cliams jwt.Claims
 	if Type == AccessType {
 		cliams = &UserClaims{
 			UserID,
+			role,
 			jwt.RegisteredClaims{
 				ExpiresAt: jwt.NewNumericDate(time.Now().Add(aExpireDuration)),
 				Issuer:    issuer,
@@ -54,23 +58,32 @@ func GenToken(UserID int64, Type TokenType) (string, error) {
 }
 
 func ParseToken(tokenStr string) (UserID int64, err error) {
+	cliams, err := ParseTokenClaims(tokenStr)
+	if err != nil {
+		return 0, err
+	}
+	return cliams.UserID, nil
+}
+
+// 解析 access_token，返回完整的 claims（包含角色信息）
+func ParseTokenClaims(tokenStr string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return jwtKey, nil
 	})
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return 0, bluebell.ErrExpiredToken
+			return nil, bluebell.ErrExpiredToken
 		}
-		return 0, err
+		return nil, err
 	}
 
 	cliams, ok := token.Claims.(*UserClaims)
 	if !ok || !token.Valid {
-		return 0, bluebell.ErrInvalidToken
+		return nil, bluebell.ErrInvalidToken
 	}
 
-	return cliams.UserID, nil
+	return cliams, nil
 }
 
 func GetAccessTokenExpireDuration() time.Duration {
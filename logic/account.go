@@ -0,0 +1,283 @@
+package logic
+
+import (
+	"archive/zip"
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/dao/search"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/objects"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+/*
+	账户注销、数据导出
+*/
+
+const (
+	AccountDeleteModeAnonymize = "anonymize" // 保留帖子、评论，只抹去个人信息
+	AccountDeleteModeRemove    = "remove"    // 同时删除帖子、评论
+)
+
+// 注销后账户邮箱的后缀
+const deletedAccountEmailSuffix = "@deleted.bluebell.invalid"
+
+// 导出用户的个人信息、帖子、评论、点赞/点踩记录
+func ExportUserData(userID int64) (*models.UserExport, error) {
+	profile, err := UserGetInfo(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:ExportUserData: UserGetInfo")
+	}
+
+	data := &models.UserExport{
+		Profile:    profile,
+		ExportedAt: models.Time(time.Now()),
+	}
+	if data.Posts, err = mysql.SelectAllPostsByAuthorID(userID); err != nil {
+		return nil, errors.Wrap(err, "logic:ExportUserData: SelectAllPostsByAuthorID")
+	}
+	if data.Comments, err = mysql.SelectCommentExportsByUserID(userID); err != nil {
+		return nil, errors.Wrap(err, "logic:ExportUserData: SelectCommentExportsByUserID")
+	}
+	if data.Likes, err = mysql.SelectCommentUserLikeMappingsByUserID(userID); err != nil {
+		return nil, errors.Wrap(err, "logic:ExportUserData: SelectCommentUserLikeMappingsByUserID")
+	}
+	if data.Hates, err = mysql.SelectCommentUserHateMappingsByUserID(userID); err != nil {
+		return nil, errors.Wrap(err, "logic:ExportUserData: SelectCommentUserHateMappingsByUserID")
+	}
+
+	return data, nil
+}
+
+// 将导出的数据打包为 zip，每一类数据一个 json 文件
+func ExportUserDataZip(userID int64) ([]byte, error) {
+	data, err := ExportUserData(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"profile.json", data.Profile},
+		{"posts.json", data.Posts},
+		{"comments.json", data.Comments},
+		{"likes.json", data.Likes},
+		{"hates.json", data.Hates},
+	}
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, errors.Wrap(err, "logic:ExportUserDataZip: Create")
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, errors.Wrap(err, "logic:ExportUserDataZip: Encode")
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "logic:ExportUserDataZip: Close")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// 注销账户
+//
+// 1. 重新验证身份：开启了两步验证时校验 TOTP code 或恢复码，否则校验密码；第三方登录的用户也可以使用重新授权得到的 reauth_token，
+// 避免 token 泄露后账户被注销
+//
+// 2. mode 为 remove 时，删除用户的帖子、评论，有帖子删除失败时返回错误，账户保持不变，可以重试
+//
+// 3. 抹去个人信息（用户名、邮箱、密码等），解绑第三方登录、两步验证、角色、关注关系、加入的社区、通知、收藏
+//
+// 4. 吊销 token
+func DeleteAccount(userID int64, role models.RoleInfo, params *models.ParamUserDelete) error {
+	if err := verifyAccountOwner(userID, params); err != nil {
+		return err
+	}
+
+	// 先处理内容，删除帖子时需要关联到用户
+	if params.Mode == AccountDeleteModeRemove {
+		if err := removeUserContents(userID, role); err != nil {
+			return errors.Wrap(err, "logic:DeleteAccount: removeUserContents")
+		}
+	}
+
+	// 随机密码，账户无法再登录
+	randomPassword, err := utils.GenRandomToken(16)
+	if err != nil {
+		return errors.Wrap(err, "logic:DeleteAccount: GenRandomToken")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, "logic:DeleteAccount: GenerateFromPassword")
+	}
+	userIDStr := strconv.FormatInt(userID, 36)
+	userName := "已注销用户_" + userIDStr
+	email := "deleted_" + userIDStr + deletedAccountEmailSuffix
+
+	err = mysql.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := mysql.AnonymizeUser(tx, userID, userName, email, string(hashedPassword)); err != nil {
+			return err
+		}
+		if err := mysql.DeleteUserIdentitiesByUserID(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteUserTOTP(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteUserRecoveryCodes(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteUserRolesByUserID(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteUserFollowsByUserID(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteCommunityMembersByUserID(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteNotificationsByUserID(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteConversationsByUserID(tx, userID); err != nil {
+			return err
+		}
+		if err := mysql.DeleteUserBlocksByUserID(tx, userID); err != nil {
+			return err
+		}
+		return mysql.DeleteUserBookmarksByUserID(tx, userID)
+	})
+	if err != nil {
+		return errors.Wrap(err, "logic:DeleteAccount: Transaction")
+	}
+	if err := redis.DelUserFeed(userID); err != nil {
+		return errors.Wrap(err, "logic:DeleteAccount: DelUserFeed")
+	}
+	if err := redis.DelBlockedIDs([]int64{userID}); err != nil {
+		return errors.Wrap(err, "logic:DeleteAccount: DelBlockedIDs")
+	}
+	if err := redis.DelNotificationUnreadCount(userID); err != nil {
+		return errors.Wrap(err, "logic:DeleteAccount: DelNotificationUnreadCount")
+	}
+	if err := search.DeleteUser(userID); err != nil {
+		logger.Warnf("logic:DeleteAccount: remove user from search engines failed, reason: %v", err.Error())
+	}
+
+	// 吊销 token
+	return errors.Wrap(redis.DelUserTokens(userID), "logic:DeleteAccount: DelUserTokens")
+}
+
+// 注销前重新验证身份，失败与登录失败一样计入账户的失败次数
+//
+// 第三方登录的用户可以重新走一次第三方授权，使用回调返回的 reauth_token 代替密码（和两步验证 code）
+func verifyAccountOwner(userID int64, params *models.ParamUserDelete) error {
+	if err := checkLoginLocked(loginAccountTarget(userID)); err != nil {
+		return err
+	}
+	if params.ReauthToken != "" {
+		return verifyOAuthReauthToken(userID, params.ReauthToken)
+	}
+
+	usr, err := mysql.SelectUserByUserID(userID)
+	if err != nil {
+		return errors.Wrap(err, "logic:verifyAccountOwner: SelectUserByUserID")
+	}
+	totp, err := mysql.SelectUserTOTPByUserID(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.Wrap(err, "logic:verifyAccountOwner: SelectUserTOTPByUserID")
+	}
+
+	if err == nil && totp.Enabled {
+		if err := verifyTwoFactorCode(totp, params.Code); err != nil {
+			if errors.Is(err, bluebell.ErrInvalidVerificationCode) {
+				recordLoginAccountFailure(usr)
+			}
+			return err
+		}
+	} else if bcrypt.CompareHashAndPassword([]byte(usr.Password), []byte(params.Password)) != nil {
+		recordLoginAccountFailure(usr)
+		return bluebell.ErrWrongPassword
+	}
+
+	if err := redis.ClearLoginFailCount(loginAccountTarget(userID)); err != nil {
+		logger.ErrorWithStack(err)
+	}
+	return nil
+}
+
+// 删除用户的帖子、评论
+func removeUserContents(userID int64, role models.RoleInfo) error {
+	posts, err := mysql.SelectAllPostsByAuthorID(userID)
+	if err != nil {
+		return errors.Wrap(err, "logic:removeUserContents: SelectAllPostsByAuthorID")
+	}
+	indices, err := mysql.SelectCommentIndicesByUserID(nil, userID)
+	if err != nil {
+		return errors.Wrap(err, "logic:removeUserContents: SelectCommentIndicesByUserID")
+	}
+
+	// 评论走已有的删除流程（kafka），删除一条评论时会连同子评论一起删除，
+	// 因此，如果根评论（或父评论）也是该用户的，跳过，避免重复删除；
+	// 用户自己帖子下的评论会随帖子一起删除，同样跳过
+	postIDs := make(map[int64]struct{}, len(posts))
+	for _, post := range posts {
+		postIDs[post.PostID] = struct{}{}
+	}
+	ids := make(map[int64]struct{}, len(indices))
+	for _, index := range indices {
+		ids[index.ID] = struct{}{}
+	}
+	for _, index := range indices {
+		if _, ok := ids[index.Root]; ok {
+			continue
+		}
+		if _, ok := ids[index.Parent]; ok {
+			continue
+		}
+		if _, ok := postIDs[index.ObjID]; ok && index.ObjType == objects.ObjPost {
+			continue
+		}
+		err := RemoveComment(&models.ParamCommentRemove{
+			ObjID:     index.ObjID,
+			ObjType:   index.ObjType,
+			CommentID: index.ID,
+		}, userID, role)
+		if err != nil {
+			return errors.Wrap(err, "logic:removeUserContents: RemoveComment")
+		}
+	}
+
+	// 同步删除帖子，某个帖子失败时继续删除其余的，最后返回失败的帖子
+	var failed []int64
+	for _, post := range posts {
+		if err := RemovePost(userID, role, models.ParamPostRemove{PostID: post.PostID}); err != nil {
+			logger.Errorf("logic:removeUserContents: remove post %v failed, reason: %v", post.PostID, err.Error())
+			failed = append(failed, post.PostID)
+			continue
+		}
+		RemoveCommentsByObjID(post.PostID, objects.ObjPost)
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("logic:removeUserContents: remove posts %v failed", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"bluebell/dao/redis"
+	bluebell "bluebell/errors"
+	"bluebell/models"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// newTestRedis 启动一个内存中的 redis，并让 dao/redis 连接到它
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	viper.Set("redis.host", mr.Host())
+	viper.Set("redis.port", mr.Port())
+	viper.Set("redis.max_oper_time", 3)
+	viper.Set("oauth.reauth_expire_time", 300)
+	redis.InitRedis()
+	return mr
+}
+
+// 第三方登录的用户没有可用的密码，通过重新授权得到的 reauth_token 注销账户
+func TestVerifyAccountOwnerWithReauthToken(t *testing.T) {
+	const userID, otherUserID = int64(1), int64(2)
+
+	tests := []struct {
+		name string
+		// 返回注销时提交的 reauth_token
+		prepare func(t *testing.T, mr *miniredis.Miniredis) string
+		wantErr error
+	}{
+		{
+			name: "own token",
+			prepare: func(t *testing.T, mr *miniredis.Miniredis) string {
+				return mustIssueReauthToken(t, userID)
+			},
+		},
+		{
+			name: "token of another user",
+			prepare: func(t *testing.T, mr *miniredis.Miniredis) string {
+				return mustIssueReauthToken(t, otherUserID)
+			},
+			wantErr: bluebell.ErrInvalidToken,
+		},
+		{
+			name: "unknown token",
+			prepare: func(t *testing.T, mr *miniredis.Miniredis) string {
+				return "not-issued"
+			},
+			wantErr: bluebell.ErrInvalidToken,
+		},
+		{
+			name: "token used twice",
+			prepare: func(t *testing.T, mr *miniredis.Miniredis) string {
+				token := mustIssueReauthToken(t, userID)
+				if err := verifyAccountOwner(userID, &models.ParamUserDelete{ReauthToken: token}); err != nil {
+					t.Fatalf("first use failed: %v", err)
+				}
+				return token
+			},
+			wantErr: bluebell.ErrInvalidToken,
+		},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, mr *miniredis.Miniredis) string {
+				token := mustIssueReauthToken(t, userID)
+				mr.FastForward(301 * time.Second)
+				return token
+			},
+			wantErr: bluebell.ErrInvalidToken,
+		},
+		{
+			name: "locked account",
+			prepare: func(t *testing.T, mr *miniredis.Miniredis) string {
+				token := mustIssueReauthToken(t, userID)
+				if _, err := redis.LockLogin(loginAccountTarget(userID), time.Minute, time.Hour, time.Hour); err != nil {
+					t.Fatalf("LockLogin failed: %v", err)
+				}
+				return token
+			},
+			wantErr: bluebell.ErrAccountLocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := newTestRedis(t)
+			token := tt.prepare(t, mr)
+			// 提供 reauth_token 时不需要密码，错误的密码也不影响结果
+			err := verifyAccountOwner(userID, &models.ParamUserDelete{Password: "wrong-password", ReauthToken: token})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("verifyAccountOwner failed: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustIssueReauthToken(t *testing.T, userID int64) string {
+	t.Helper()
+	token, err := issueOAuthReauthToken(userID)
+	if err != nil {
+		t.Fatalf("issueOAuthReauthToken failed: %v", err)
+	}
+	return token
+}
@@ -0,0 +1,208 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	bluebell "bluebell/errors"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/objects"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+/*
+	拉黑
+
+	1. 拉黑者看不到被拉黑者的帖子、评论
+	2. 被拉黑者不能回复、@ 拉黑者
+*/
+
+// 评论中的 @用户名
+var mentionRegexp = regexp.MustCompile(`@([\p{Han}\w-]{3,64})`)
+
+const maxMentionCheck = 10 // 每条评论最多检查的 @ 数量
+
+func BlockUser(userID, blockedID int64) error {
+	if userID == blockedID {
+		return bluebell.ErrInvalidParam
+	}
+	if _, err := mysql.SelectUserByUserID(blockedID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bluebell.ErrUserNotExist
+		}
+		return errors.Wrap(err, "logic:BlockUser: SelectUserByUserID")
+	}
+
+	if err := mysql.CreateUserBlock(userID, blockedID); err != nil {
+		return errors.Wrap(err, "logic:BlockUser: CreateUserBlock")
+	}
+	return errors.Wrap(redis.DelBlockedIDs([]int64{userID}), "logic:BlockUser: DelBlockedIDs")
+}
+
+func UnblockUser(userID, blockedID int64) error {
+	if err := mysql.DeleteUserBlock(userID, blockedID); err != nil {
+		return errors.Wrap(err, "logic:UnblockUser: DeleteUserBlock")
+	}
+	return errors.Wrap(redis.DelBlockedIDs([]int64{userID}), "logic:UnblockUser: DelBlockedIDs")
+}
+
+// 拉黑列表
+func GetBlockList(userID int64) ([]string, error) {
+	ids, err := mysql.SelectBlockedIDs(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetBlockList: SelectBlockedIDs")
+	}
+	res := make([]string, len(ids))
+	for i, id := range ids {
+		res[i] = strconv.FormatInt(id, 10)
+	}
+	return res, nil
+}
+
+// 过滤被拉黑的用户的帖子，返回过滤后的帖子和扣除被过滤数量后的总数
+//
+// 帖子列表由 redis 分页，过滤发生在分页之后，所以当页可能不足 page_size 条；
+// total 只扣除当页被过滤的帖子，其他页的被拉黑帖子仍计入总数
+func FilterBlockedPosts(userID int64, posts []*models.PostDTO, total int) ([]*models.PostDTO, int, error) {
+	blocked, err := getBlockedSet(userID)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "logic:FilterBlockedPosts: getBlockedSet")
+	}
+	if len(blocked) == 0 {
+		return posts, total, nil
+	}
+
+	res := make([]*models.PostDTO, 0, len(posts))
+	for _, post := range posts {
+		if _, ok := blocked[post.UserID]; !ok {
+			res = append(res, post)
+		}
+	}
+	return res, total - (len(posts) - len(res)), nil
+}
+
+// 过滤被拉黑的用户的评论（包括楼中楼）
+//
+// 和帖子一样在分页之后过滤，当页可能不足 page_size 条；Total 是根评论总数，只扣除当页被过滤的根评论
+func FilterBlockedComments(userID int64, list *models.CommentListDTO) (*models.CommentListDTO, error) {
+	blocked, err := getBlockedSet(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:FilterBlockedComments: getBlockedSet")
+	}
+	if len(blocked) == 0 {
+		return list, nil
+	}
+
+	comments := make([]models.CommentDTO, 0, len(list.Comments))
+	for _, comment := range list.Comments {
+		if _, ok := blocked[comment.UserID]; ok {
+			continue
+		}
+		replies := make([]models.CommentDTO, 0, len(comment.Replies))
+		for _, reply := range comment.Replies {
+			if _, ok := blocked[reply.UserID]; !ok {
+				replies = append(replies, reply)
+			}
+		}
+		comment.Replies = replies
+		comments = append(comments, comment)
+	}
+	return &models.CommentListDTO{
+		Total:    list.Total - (len(list.Comments) - len(comments)),
+		Comments: comments,
+	}, nil
+}
+
+// 检查评论的回复对象、@ 的用户是否拉黑了评论者
+func checkCommentBlocked(param *models.ParamCommentCreate, userID int64) error {
+	// 回复对象
+	var targetID int64
+	var err error
+	if param.Parent != 0 {
+		targetID, err = mysql.SelectUserIDByCommentID(nil, param.Parent)
+		if err != nil {
+			return errors.Wrap(err, "logic:checkCommentBlocked: SelectUserIDByCommentID")
+		}
+	} else if param.ObjType == objects.ObjPost {
+		post, err := GetPostDetailByID(param.ObjID, false)
+		if err != nil {
+			return err
+		}
+		targetID = post.UserID
+	}
+	if targetID != 0 && targetID != userID {
+		blocked, err := isBlockedBy(targetID, userID)
+		if err != nil {
+			return errors.Wrap(err, "logic:checkCommentBlocked: isBlockedBy")
+		}
+		if blocked {
+			return bluebell.ErrBlocked
+		}
+	}
+
+	// @ 的用户
+	for _, match := range mentionRegexp.FindAllStringSubmatch(param.Message, maxMentionCheck) {
+		user, err := mysql.SelectUserByName(match[1])
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return errors.Wrap(err, "logic:checkCommentBlocked: SelectUserByName")
+		}
+		blocked, err := isBlockedBy(user.UserID, userID)
+		if err != nil {
+			return errors.Wrap(err, "logic:checkCommentBlocked: isBlockedBy")
+		}
+		if blocked {
+			return bluebell.ErrBlocked
+		}
+	}
+	return nil
+}
+
+// userID 是否被 blockerID 拉黑
+func isBlockedBy(blockerID, userID int64) (bool, error) {
+	blocked, err := getBlockedSet(blockerID)
+	if err != nil {
+		return false, err
+	}
+	_, ok := blocked[userID]
+	return ok, nil
+}
+
+// 获取拉黑列表，优先读 redis 缓存，未命中时查询 mysql 并重建缓存
+func getBlockedSet(userID int64) (map[int64]struct{}, error) {
+	ids, err := redis.GetBlockedIDs(userID)
+	if err == nil {
+		blocked := make(map[int64]struct{}, len(ids))
+		for _, id := range ids {
+			blockedID, _ := strconv.ParseInt(id, 10, 64)
+			blocked[blockedID] = struct{}{}
+		}
+		return blocked, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, errors.Wrap(err, "logic:getBlockedSet: GetBlockedIDs")
+	}
+
+	blockedIDs, err := mysql.SelectBlockedIDs(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:getBlockedSet: SelectBlockedIDs")
+	}
+	expireDuration := time.Second * time.Duration(viper.GetInt("service.block.cache_expire_time"))
+	if err := redis.SetBlockedIDs(userID, blockedIDs, expireDuration); err != nil {
+		logger.Warnf("logic:getBlockedSet: SetBlockedIDs, reason: %v", err.Error())
+	}
+
+	blocked := make(map[int64]struct{}, len(blockedIDs))
+	for _, id := range blockedIDs {
+		blocked[id] = struct{}{}
+	}
+	return blocked, nil
+}
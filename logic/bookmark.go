@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	bluebell "bluebell/errors"
+	"bluebell/models"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// 收藏帖子，已经收藏过则移动到 folder
+func BookmarkPost(userID int64, params *models.ParamBookmark) error {
+	post, err := GetPostDetailByID(params.PostID, false)
+	if err != nil {
+		return errors.Wrap(err, "logic:BookmarkPost: GetPostDetailByID")
+	}
+	// 帖子不存在时返回的是空的 DTO
+	if post.PostID == 0 {
+		return bluebell.ErrNoSuchPost
+	}
+	err = mysql.CreateUserBookmark(userID, params.PostID, params.Folder)
+	return errors.Wrap(err, "logic:BookmarkPost: CreateUserBookmark")
+}
+
+func UnbookmarkPost(userID, postID int64) error {
+	return errors.Wrap(mysql.DeleteUserBookmark(userID, postID), "logic:UnbookmarkPost: DeleteUserBookmark")
+}
+
+// 收藏列表，基于游标分页
+func GetBookmarkList(userID int64, params *models.ParamBookmarkList) (*models.BookmarkListDTO, error) {
+	var cursor int64
+	if params.Cursor != "" {
+		var err error
+		if cursor, err = strconv.ParseInt(params.Cursor, 10, 64); err != nil {
+			return nil, bluebell.ErrInvalidParam
+		}
+	}
+
+	bookmarks, err := mysql.SelectUserBookmarks(userID, cursor, params.Folder, int(params.PageSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetBookmarkList: SelectUserBookmarks")
+	}
+
+	postIDs := make([]string, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		postIDs[i] = strconv.FormatInt(bookmark.PostID, 10)
+	}
+	posts, err := GetPostListByIDs(postIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetBookmarkList: GetPostListByIDs")
+	}
+	for i := range posts {
+		post := *posts[i] // 拷贝一份，缓存中的 PostDTO 是共享的
+		post.IsBookmarked = true
+		posts[i] = &post
+	}
+
+	res := &models.BookmarkListDTO{Posts: posts}
+	if len(bookmarks) == int(params.PageSize) {
+		res.NextCursor = strconv.FormatInt(bookmarks[len(bookmarks)-1].ID, 10)
+	}
+	return res, nil
+}
+
+// 为帖子列表设置 is_bookmarked
+//
+// 缓存中的 PostDTO 是所有用户共享的，因此返回的是拷贝
+func FillBookmarkFlag(userID int64, posts []*models.PostDTO) ([]*models.PostDTO, error) {
+	if len(posts) == 0 {
+		return posts, nil
+	}
+	postIDs := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.PostID
+	}
+	bookmarkedIDs, err := mysql.SelectBookmarkedPostIDs(userID, postIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:FillBookmarkFlag: SelectBookmarkedPostIDs")
+	}
+	bookmarked := make(map[int64]struct{}, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = struct{}{}
+	}
+
+	res := make([]*models.PostDTO, len(posts))
+	for i := range posts {
+		post := *posts[i]
+		_, post.IsBookmarked = bookmarked[post.PostID]
+		res[i] = &post
+	}
+	return res, nil
+}
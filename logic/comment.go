@@ -6,6 +6,7 @@ import (
 	"bluebell/dao/mysql"
 	"bluebell/dao/rebuild"
 	"bluebell/dao/redis"
+	"bluebell/dao/search"
 	bluebell "bluebell/errors"
 	"bluebell/internal/utils"
 	"bluebell/logger"
@@ -21,13 +22,20 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
 )
 
 var CommentIndexGrp singleflight.Group
 var CommentContentGrp singleflight.Group
 var CommentMetaDataGrp singleflight.Group
+var CommentSearchGrp singleflight.Group
 
 func CreateComment(param *models.ParamCommentCreate, userID int64) (*models.CommentDTO, error) {
+	// 被回复者、被 @ 者拉黑了评论者，不允许评论
+	if err := checkCommentBlocked(param, userID); err != nil {
+		return nil, err
+	}
+
 	commentID := utils.GenSnowflakeID()
 	// 异步投递消息到 kafka
 	go func() {
@@ -118,14 +126,32 @@ func GetCommentList(param *models.ParamCommentList) (*models.CommentListDTO, err
 	return list, nil
 }
 
-func RemoveComment(params *models.ParamCommentRemove, userID int64) error {
-	// 鉴权处理
-	_userID, err := mysql.SelectUserIDByCommentID(nil, params.CommentID)
+func RemoveComment(params *models.ParamCommentRemove, userID int64, role models.RoleInfo) error {
+	// 以数据库中评论实际所属的主题为准，不信任客户端传入的 obj_id、obj_type
+	index, err := mysql.SelectCommentIndexByCommentID(nil, params.CommentID)
 	if err != nil {
-		return errors.Wrap(err, "logic:RemoveComment: SelectUserIDByCommentID")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bluebell.ErrNoSuchComment
+		}
+		return errors.Wrap(err, "logic:RemoveComment: SelectCommentIndexByCommentID")
 	}
-	if userID != _userID { // 非法操作
-		return bluebell.ErrForbidden
+	params.ObjID, params.ObjType = index.ObjID, index.ObjType
+
+	// 鉴权处理：评论作者本人，或者评论所在社区的版主、管理员
+	if userID != index.UserID && !role.IsAdmin() {
+		if index.ObjType != objects.ObjPost || len(role.ModCommunities) == 0 {
+			return bluebell.ErrForbidden
+		}
+		post, err := mysql.SelectPostByID(index.ObjID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return bluebell.ErrForbidden
+			}
+			return errors.Wrap(err, "logic:RemoveComment: SelectPostByID")
+		}
+		if !role.CanModerate(post.CommunityID) { // 非法操作
+			return bluebell.ErrForbidden
+		}
 	}
 
 	// 判断是不是根评论
@@ -432,3 +458,101 @@ func getCommentContent(commentIDs []int64) ([]string, error) {
 	// rebuild 成功（或者不需要 rebuild），读缓存
 	return redis.GetCommentContents(commentIDs)
 }
+
+type commentSearchResult struct {
+	Total      int
+	CommentIDs []string
+}
+
+// SearchComments 根据关键字搜索评论，obj_id 为 0 时全局搜索，登录用户会过滤掉被拉黑的用户的评论
+func SearchComments(userID int64, params *models.ParamCommentSearch) (*models.CommentSearchListDTO, error) {
+	sfkey := fmt.Sprintf("%v_%v_%v_%v", params.Keyword, params.ObjID, params.PageNum, params.PageSize)
+	timeout := time.Second * time.Duration(viper.GetInt("service.timeout"))
+	rps := viper.GetInt("service.rps")
+	interval := time.Second / time.Duration(rps)
+
+	ret, err := utils.SfDoWithTimeout(&CommentSearchGrp, sfkey, timeout, interval, func() (any, error) {
+		commentIDs, total, err := search.QueryCommentIDs(params)
+		return commentSearchResult{
+			CommentIDs: commentIDs,
+			Total:      total,
+		}, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchComments: QueryCommentIDs")
+	}
+	total := ret.(commentSearchResult).Total
+	commentIDs, err := utils.ConvertStringSliceToInt64Slice(ret.(commentSearchResult).CommentIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchComments: ConvertStringSliceToInt64Slice")
+	}
+	list := &models.CommentSearchListDTO{Total: total, Comments: make([]models.CommentSearchDTO, 0, len(commentIDs))}
+	if len(commentIDs) == 0 {
+		return list, nil
+	}
+
+	// 查元数据（索引可能滞后于数据库，已删除的评论会被跳过）
+	metas, err := mysql.SelectCommentMetaDataByCommentIDs(nil, "id", commentIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchComments: SelectCommentMetaDataByCommentIDs")
+	}
+	if len(metas) == 0 {
+		return list, nil
+	}
+	foundIDs := make([]int64, len(metas))
+	for i := 0; i < len(metas); i++ {
+		foundIDs[i] = metas[i].CommentID
+	}
+	likes, err := redis.GetCommentLikeOrHateCountByCommentIDs(foundIDs, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchComments: GetCommentLikeOrHateCountByCommentIDs")
+	}
+	contents, err := getCommentContent(foundIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchComments: getCommentContent")
+	}
+	if len(contents) != len(metas) || len(likes) != len(metas) {
+		return nil, errors.Wrap(bluebell.ErrInternal, "logic:SearchComments: contents, likes and metas length is not equal")
+	}
+	mapping := make(map[int64]models.CommentDTO, len(metas))
+	for i := 0; i < len(metas); i++ {
+		metas[i].Content.Message = contents[i]
+		metas[i].Like += likes[i]
+		mapping[metas[i].CommentID] = metas[i]
+	}
+
+	var blocked map[int64]struct{}
+	if userID != 0 {
+		if blocked, err = getBlockedSet(userID); err != nil {
+			return nil, errors.Wrap(err, "logic:SearchComments: getBlockedSet")
+		}
+	}
+
+	// 按搜索结果的顺序组装数据，并附带帖子标题
+	titles := make(map[int64]string)
+	for _, commentID := range commentIDs {
+		comment, ok := mapping[commentID]
+		if !ok {
+			continue
+		}
+		if _, ok := blocked[comment.UserID]; ok {
+			continue
+		}
+		title, ok := titles[comment.ObjID]
+		if !ok {
+			post, err := GetPostDetailByID(comment.ObjID, false)
+			if err != nil && !errors.Is(err, bluebell.ErrNoSuchPost) {
+				return nil, errors.Wrap(err, "logic:SearchComments: GetPostDetailByID")
+			}
+			if post != nil {
+				title = post.Title
+			}
+			titles[comment.ObjID] = title
+		}
+		list.Comments = append(list.Comments, models.CommentSearchDTO{
+			CommentDTO: comment,
+			PostTitle:  title,
+		})
+	}
+	return list, nil
+}
@@ -2,6 +2,7 @@ package logic
 
 import (
 	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
 	bluebell "bluebell/errors"
 	"bluebell/models"
 
@@ -30,5 +31,42 @@ func GetCommunityDetailByID(id int64) (*models.CommunityDTO, error) {
 }
 
 func CreateCommunity(params *models.ParamCommunityCreate) error {
-	return errors.Wrap(mysql.CreateCommunity(params.CommunityID, params.CommunityName, params.Introduction), "logic:CreateCommunity: CreateCommunity")
-}
\ No newline at end of file
+	if err := mysql.CreateCommunity(params.CommunityID, params.CommunityName, params.Introduction); err != nil {
+		return errors.Wrap(err, "logic:CreateCommunity: CreateCommunity")
+	}
+	indexCommunity(params.CommunityID, params.CommunityName, params.Introduction)
+	return nil
+}
+
+// 加入社区
+func JoinCommunity(userID, communityID int64) error {
+	if _, err := GetCommunityDetailByID(communityID); err != nil {
+		return err
+	}
+	created, err := mysql.CreateCommunityMember(userID, communityID)
+	if err != nil {
+		return errors.Wrap(err, "logic:JoinCommunity: CreateCommunityMember")
+	}
+	if !created {
+		return nil
+	}
+	return errors.Wrap(redis.DelJoinedPostCache(userID), "logic:JoinCommunity: DelJoinedPostCache")
+}
+
+// 退出社区
+func LeaveCommunity(userID, communityID int64) error {
+	deleted, err := mysql.DeleteCommunityMember(userID, communityID)
+	if err != nil {
+		return errors.Wrap(err, "logic:LeaveCommunity: DeleteCommunityMember")
+	}
+	if !deleted {
+		return nil
+	}
+	return errors.Wrap(redis.DelJoinedPostCache(userID), "logic:LeaveCommunity: DelJoinedPostCache")
+}
+
+// 返回用户加入的社区列表（不包含 intro）
+func GetJoinedCommunityList(userID int64) ([]models.CommunityDTO, error) {
+	list, err := mysql.SelectJoinedCommunityList(userID)
+	return list, errors.Wrap(err, "logic:GetJoinedCommunityList: SelectJoinedCommunityList")
+}
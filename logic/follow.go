@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	bluebell "bluebell/errors"
+	"bluebell/models"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+/*
+	关注、关注流
+
+	普通作者写扩散（inbox），粉丝数达到 service.feed.fanout_threshold 的作者读扩散（outbox）
+*/
+
+func FollowUser(userID, followedID int64) error {
+	if userID == followedID {
+		return bluebell.ErrInvalidParam
+	}
+	if _, err := mysql.SelectUserByUserID(followedID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bluebell.ErrUserNotExist
+		}
+		return errors.Wrap(err, "logic:FollowUser: SelectUserByUserID")
+	}
+
+	created, err := mysql.CreateUserFollow(userID, followedID)
+	if err != nil {
+		return errors.Wrap(err, "logic:FollowUser: CreateUserFollow")
+	}
+	if !created { // 已经关注过
+		return nil
+	}
+
+	big, err := refreshBigAuthor(followedID)
+	if err != nil {
+		return errors.Wrap(err, "logic:FollowUser: refreshBigAuthor")
+	}
+	// 普通作者，将其近期的帖子合并到 inbox；大 V 的帖子在读取时合并
+	if !big {
+		err = redis.MergeOutboxToInbox(userID, followedID, viper.GetInt64("service.feed.inbox_size"))
+	}
+	return errors.Wrap(err, "logic:FollowUser: MergeOutboxToInbox")
+}
+
+func UnfollowUser(userID, followedID int64) error {
+	deleted, err := mysql.DeleteUserFollow(userID, followedID)
+	if err != nil {
+		return errors.Wrap(err, "logic:UnfollowUser: DeleteUserFollow")
+	}
+	if !deleted {
+		return nil
+	}
+
+	if err := redis.RemOutboxFromInbox(userID, followedID); err != nil {
+		return errors.Wrap(err, "logic:UnfollowUser: RemOutboxFromInbox")
+	}
+	_, err = refreshBigAuthor(followedID)
+	return errors.Wrap(err, "logic:UnfollowUser: refreshBigAuthor")
+}
+
+// 获取关注流
+func GetFeedPostList(userID int64, params *models.ParamPostFeed) ([]*models.PostDTO, int, error) {
+	followingIDs, err := mysql.SelectFollowingIDs(userID)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "logic:GetFeedPostList: SelectFollowingIDs")
+	}
+	bigAuthorIDs, err := redis.FilterBigAuthors(followingIDs)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "logic:GetFeedPostList: FilterBigAuthors")
+	}
+
+	postIDs, total, err := redis.GetFeedPostIDs(userID, bigAuthorIDs, params.PageNum, params.PageSize)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "logic:GetFeedPostList: GetFeedPostIDs")
+	}
+
+	list, err := GetPostListByIDs(postIDs)
+	return list, total, err
+}
+
+// 根据粉丝数，更新作者是否为大 V
+//
+// 注意：大 V 降为普通作者后，之前的帖子不会补发到粉丝的 inbox，只有新帖子会写扩散
+func refreshBigAuthor(authorID int64) (bool, error) {
+	count, err := mysql.SelectFollowerCount(authorID)
+	if err != nil {
+		return false, errors.Wrap(err, "logic:refreshBigAuthor: SelectFollowerCount")
+	}
+	big := count >= viper.GetInt("service.feed.fanout_threshold")
+	return big, errors.Wrap(redis.SetBigAuthor(authorID, big), "logic:refreshBigAuthor: SetBigAuthor")
+}
+
+// 发帖后，将帖子推送到关注流
+func pushPostToFeeds(authorID, postID int64) error {
+	timestamp := float64(time.Now().Unix())
+	size := viper.GetInt64("service.feed.inbox_size")
+
+	if err := redis.AddPostToOutbox(authorID, postID, timestamp, size); err != nil {
+		return errors.Wrap(err, "logic:pushPostToFeeds: AddPostToOutbox")
+	}
+	big, err := redis.IsBigAuthor(authorID)
+	if err != nil {
+		return errors.Wrap(err, "logic:pushPostToFeeds: IsBigAuthor")
+	}
+	if big { // 读扩散
+		return nil
+	}
+
+	followerIDs, err := mysql.SelectFollowerIDs(authorID)
+	if err != nil {
+		return errors.Wrap(err, "logic:pushPostToFeeds: SelectFollowerIDs")
+	}
+	err = redis.AddPostToInboxes(followerIDs, postID, timestamp, size)
+	return errors.Wrap(err, "logic:pushPostToFeeds: AddPostToInboxes")
+}
+
+// 删帖后，将帖子从关注流中移除
+//
+// 作者可能在发帖后成为大 V，因此无论是否为大 V，都从粉丝的 inbox 中移除
+func removePostFromFeeds(authorID, postID int64) error {
+	followerIDs, err := mysql.SelectFollowerIDs(authorID)
+	if err != nil {
+		return errors.Wrap(err, "logic:removePostFromFeeds: SelectFollowerIDs")
+	}
+	err = redis.RemPostFromFeeds(authorID, followerIDs, postID)
+	return errors.Wrap(err, "logic:removePostFromFeeds: RemPostFromFeeds")
+}
+
+func getFollowCount(userID int64) (int, int, error) {
+	followerCount, err := mysql.SelectFollowerCount(userID)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "logic:getFollowCount: SelectFollowerCount")
+	}
+	followingCount, err := mysql.SelectFollowingCount(userID)
+	return followerCount, followingCount, errors.Wrap(err, "logic:getFollowCount: SelectFollowingCount")
+}
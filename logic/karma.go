@@ -0,0 +1,144 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	bluebell "bluebell/errors"
+	"bluebell/models"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+/*
+	karma
+
+	karma = 帖子赞成票 * post_up + 帖子反对票 * post_down + 评论点赞 * comment_like + 评论点踩 * comment_hate
+	由后台任务 workers.RefreshUserKarma 定期全量计算，用于限制创建社区、上传图片等操作
+*/
+
+// 需要 karma 的操作，对应配置 service.karma.privilege.<privilege>
+const (
+	KarmaPrivilegeCreateCommunity = "create_community"
+	KarmaPrivilegeUploadImage     = "upload_image"
+)
+
+type karmaCounter struct {
+	postUp, postDown, commentLike, commentHate int64
+}
+
+// 全量计算所有用户的 karma，返回更新的用户数
+func RefreshUserKarma() (int, error) {
+	startTime := time.Now()
+	counters := make(map[int64]*karmaCounter)
+	getCounter := func(userID int64) *karmaCounter {
+		counter, ok := counters[userID]
+		if !ok {
+			counter = &karmaCounter{}
+			counters[userID] = counter
+		}
+		return counter
+	}
+
+	// 未过期帖子的投票，在 redis 中
+	postIDs, err := redis.GetVotedPostIDs()
+	if err != nil {
+		return 0, errors.Wrap(err, "logic:RefreshUserKarma: GetVotedPostIDs")
+	}
+	if len(postIDs) != 0 {
+		upVoteNums, err := redis.GetPostUpVoteNums(postIDs)
+		if err != nil {
+			return 0, errors.Wrap(err, "logic:RefreshUserKarma: GetPostUpVoteNums")
+		}
+		downVoteNums, err := redis.GetPostDownVoteNums(postIDs)
+		if err != nil {
+			return 0, errors.Wrap(err, "logic:RefreshUserKarma: GetPostDownVoteNums")
+		}
+		authors, err := mysql.SelectAuthorIDsByPostIDs(postIDs)
+		if err != nil {
+			return 0, errors.Wrap(err, "logic:RefreshUserKarma: SelectAuthorIDsByPostIDs")
+		}
+		for i, postIDStr := range postIDs {
+			postID, _ := strconv.ParseInt(postIDStr, 10, 64)
+			authorID, ok := authors[postID]
+			if !ok { // 帖子已被删除
+				continue
+			}
+			counter := getCounter(authorID)
+			counter.postUp += upVoteNums[i]
+			counter.postDown += downVoteNums[i]
+		}
+	}
+
+	// 过期帖子的投票，在 expired_post_scores 中
+	expiredVotes, err := mysql.SelectExpiredPostVoteSumGroupByAuthor()
+	if err != nil {
+		return 0, errors.Wrap(err, "logic:RefreshUserKarma: SelectExpiredPostVoteSumGroupByAuthor")
+	}
+	for _, sum := range expiredVotes {
+		counter := getCounter(sum.UserID)
+		counter.postUp += sum.Up
+		counter.postDown += sum.Down
+	}
+
+	// 评论的点赞、点踩
+	commentLikes, err := mysql.SelectCommentLikeSumGroupByUserID()
+	if err != nil {
+		return 0, errors.Wrap(err, "logic:RefreshUserKarma: SelectCommentLikeSumGroupByUserID")
+	}
+	for _, sum := range commentLikes {
+		counter := getCounter(sum.UserID)
+		counter.commentLike += sum.Up
+		counter.commentHate += sum.Down
+	}
+
+	karmas := make([]models.UserKarma, 0, len(counters))
+	for userID, counter := range counters {
+		karmas = append(karmas, models.UserKarma{
+			UserID: userID,
+			Karma:  computeKarma(counter),
+		})
+	}
+	if err := mysql.UpsertUserKarmas(karmas); err != nil {
+		return 0, errors.Wrap(err, "logic:RefreshUserKarma: UpsertUserKarmas")
+	}
+	if err := mysql.ResetStaleUserKarmas(startTime); err != nil {
+		return 0, errors.Wrap(err, "logic:RefreshUserKarma: ResetStaleUserKarmas")
+	}
+	return len(karmas), nil
+}
+
+func computeKarma(counter *karmaCounter) int64 {
+	karma := float64(counter.postUp)*viper.GetFloat64("service.karma.weight.post_up") +
+		float64(counter.postDown)*viper.GetFloat64("service.karma.weight.post_down") +
+		float64(counter.commentLike)*viper.GetFloat64("service.karma.weight.comment_like") +
+		float64(counter.commentHate)*viper.GetFloat64("service.karma.weight.comment_hate")
+	return int64(math.Round(karma))
+}
+
+func GetUserKarma(userID int64) (int64, error) {
+	karma, err := mysql.SelectUserKarma(userID)
+	return karma, errors.Wrap(err, "logic:GetUserKarma: SelectUserKarma")
+}
+
+// 检查用户的 karma 是否满足操作的要求，管理员不受限制
+func CheckKarmaPrivilege(userID int64, role models.RoleInfo, privilege string) error {
+	if role.IsAdmin() {
+		return nil
+	}
+	required := viper.GetInt64("service.karma.privilege." + privilege)
+	if required <= 0 {
+		return nil
+	}
+	karma, err := GetUserKarma(userID)
+	if err != nil {
+		return errors.Wrap(err, "logic:CheckKarmaPrivilege: GetUserKarma")
+	}
+	if karma < required {
+		return bluebell.ErrKarmaNotEnough
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"bluebell/dao/kafka"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"strconv"
+	"time"
+
+	bluebell "bluebell/errors"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+/*
+	登录防爆破：账户、IP 两个维度的失败计数，超过阈值后按指数增长的时间锁定
+*/
+
+func loginAccountTarget(userID int64) string {
+	return "account_" + strconv.FormatInt(userID, 10)
+}
+
+func loginIPTarget(ip string) string {
+	return "ip_" + ip
+}
+
+// 检查 target 是否被锁定
+func checkLoginLocked(target string) error {
+	ttl, err := redis.GetLoginLockTTL(target)
+	if err != nil {
+		return errors.Wrap(err, "logic:checkLoginLocked: GetLoginLockTTL")
+	}
+	if ttl > 0 {
+		return bluebell.ErrAccountLocked
+	}
+	return nil
+}
+
+// 记录一次失败，超过阈值则锁定，返回锁定时间（未锁定为 0）
+func recordLoginFailure(target string, maxAttempts int64) (time.Duration, error) {
+	window := time.Second * time.Duration(viper.GetInt64("service.login.fail_window"))
+	count, err := redis.IncrLoginFailCount(target, window)
+	if err != nil {
+		return 0, errors.Wrap(err, "logic:recordLoginFailure: IncrLoginFailCount")
+	}
+	if count < maxAttempts {
+		return 0, nil
+	}
+
+	base := time.Second * time.Duration(viper.GetInt64("service.login.lock_base_time"))
+	max := time.Second * time.Duration(viper.GetInt64("service.login.lock_max_time"))
+	levelExpire := time.Second * time.Duration(viper.GetInt64("service.login.lock_level_expire"))
+	duration, err := redis.LockLogin(target, base, max, levelExpire)
+	return duration, errors.Wrap(err, "logic:recordLoginFailure: LockLogin")
+}
+
+// 记录 IP 维度的失败
+func recordLoginIPFailure(ip string) {
+	if _, err := recordLoginFailure(loginIPTarget(ip), viper.GetInt64("service.login.ip_max_attempts")); err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// 记录账户维度的失败，账户被锁定时，发送邮件通知用户
+func recordLoginAccountFailure(usr *models.User) {
+	duration, err := recordLoginFailure(loginAccountTarget(usr.UserID), viper.GetInt64("service.login.max_attempts"))
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	if duration == 0 {
+		return
+	}
+
+	logger.Warnf("logic:recordLoginAccountFailure: account %v locked for %v", usr.UserID, duration)
+	go func() {
+		if err := kafka.SendAccountLockedEmail(usr.Email, usr.UserName, duration); err != nil {
+			logger.Errorf("logic:recordLoginAccountFailure: send message to kafka failed, reason: %v", err.Error())
+		}
+	}()
+}
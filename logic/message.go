@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"bluebell/dao/kafka"
+	"bluebell/dao/mysql"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/models"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+/*
+	私信
+
+	与评论一样，私信先投递到 topic-direct-message，由消费者写入 mysql 并更新双方的会话，
+	同一个会话的私信在同一个 partition 中串行消费
+*/
+
+func SendDirectMessage(senderID int64, params *models.ParamDirectMessageSend) (*models.DirectMessageDTO, error) {
+	if senderID == params.ToUserID {
+		return nil, bluebell.ErrInvalidParam
+	}
+	if _, err := mysql.SelectUserByUserID(params.ToUserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, bluebell.ErrUserNotExist
+		}
+		return nil, errors.Wrap(err, "logic:SendDirectMessage: SelectUserByUserID")
+	}
+	// 被对方拉黑，不允许发送
+	blocked, err := isBlockedBy(params.ToUserID, senderID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SendDirectMessage: isBlockedBy")
+	}
+	if blocked {
+		return nil, bluebell.ErrBlocked
+	}
+
+	messageID := utils.GenSnowflakeID()
+	msg := kafka.DirectMessageCreate{
+		MessageID:       messageID,
+		ConversationKey: conversationKey(senderID, params.ToUserID),
+		SenderID:        senderID,
+		ReceiverID:      params.ToUserID,
+		Content:         params.Content,
+	}
+	// 异步投递消息到 kafka
+	go func() {
+		if err := kafka.SendDirectMessage(msg); err != nil {
+			logger.Errorf("logic:SendDirectMessage: send message to kafka failed, reason: %v", err.Error())
+		}
+	}()
+
+	return &models.DirectMessageDTO{
+		MessageID:  messageID,
+		SenderID:   senderID,
+		ReceiverID: params.ToUserID,
+		Content:    params.Content,
+		CreatedAt:  models.Time(time.Now()),
+	}, nil
+}
+
+func GetConversationList(userID int64, params *models.ParamConversationList) (*models.ConversationListDTO, error) {
+	start := int((params.PageNum - 1) * params.PageSize)
+	list, err := mysql.SelectConversationsByUserID(userID, start, int(params.PageSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetConversationList: SelectConversationsByUserID")
+	}
+	total, err := mysql.SelectConversationCountByUserID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetConversationList: SelectConversationCountByUserID")
+	}
+	unread, err := mysql.SelectConversationUnreadSum(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetConversationList: SelectConversationUnreadSum")
+	}
+
+	return &models.ConversationListDTO{
+		Total:         total,
+		Unread:        unread,
+		Conversations: list,
+	}, nil
+}
+
+// 私信记录，按发送时间倒序；查询第一页时，将会话标记为已读
+func GetDirectMessageHistory(userID int64, params *models.ParamDirectMessageHistory) (*models.DirectMessageListDTO, error) {
+	var cursor int64
+	if params.Cursor != "" {
+		var err error
+		if cursor, err = strconv.ParseInt(params.Cursor, 10, 64); err != nil {
+			return nil, bluebell.ErrInvalidParam
+		}
+	}
+
+	messages, err := mysql.SelectDirectMessages(conversationKey(userID, params.PeerID), cursor, int(params.PageSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetDirectMessageHistory: SelectDirectMessages")
+	}
+	if cursor == 0 {
+		if err := mysql.UpdateConversationRead(userID, params.PeerID); err != nil {
+			return nil, errors.Wrap(err, "logic:GetDirectMessageHistory: UpdateConversationRead")
+		}
+	}
+
+	res := &models.DirectMessageListDTO{Messages: messages}
+	if len(messages) == int(params.PageSize) {
+		res.NextCursor = strconv.FormatInt(messages[len(messages)-1].MessageID, 10)
+	}
+	return res, nil
+}
+
+// 同一对用户的会话 key 相同，与发送方向无关
+func conversationKey(userID, peerID int64) string {
+	if userID > peerID {
+		userID, peerID = peerID, userID
+	}
+	return fmt.Sprintf("%d:%d", userID, peerID)
+}
@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"bluebell/dao/kafka"
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/models"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+/*
+	站内通知
+
+	通知由 kafka 的 comment、like 消费者，以及 VoteForPost 产生，
+	统一投递到 topic-notification，由消费者聚合后写入 mysql
+*/
+
+func GetNotificationList(userID int64, params *models.ParamNotificationList) (*models.NotificationListDTO, error) {
+	start := int((params.PageNum - 1) * params.PageSize)
+	list, err := mysql.SelectNotificationsByUserID(userID, start, int(params.PageSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetNotificationList: SelectNotificationsByUserID")
+	}
+	total, err := mysql.SelectNotificationCountByUserID(userID, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetNotificationList: SelectNotificationCountByUserID")
+	}
+	unread, err := GetNotificationUnreadCount(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetNotificationList: GetNotificationUnreadCount")
+	}
+
+	return &models.NotificationListDTO{
+		Total:         total,
+		Unread:        unread,
+		Notifications: list,
+	}, nil
+}
+
+// 未读通知数，优先读 redis 缓存，未命中时查询 mysql 并重建缓存
+func GetNotificationUnreadCount(userID int64) (int, error) {
+	count, err := redis.GetNotificationUnreadCount(userID)
+	if err == nil {
+		return count, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return 0, errors.Wrap(err, "logic:GetNotificationUnreadCount: GetNotificationUnreadCount")
+	}
+
+	count, err = mysql.SelectNotificationCountByUserID(userID, true)
+	if err != nil {
+		return 0, errors.Wrap(err, "logic:GetNotificationUnreadCount: SelectNotificationCountByUserID")
+	}
+	expireDuration := time.Second * time.Duration(viper.GetInt("service.notification.unread_expire_time"))
+	if err := redis.SetNotificationUnreadCount(userID, count, expireDuration); err != nil {
+		logger.Warnf("logic:GetNotificationUnreadCount: SetNotificationUnreadCount, reason: %v", err.Error())
+	}
+	return count, nil
+}
+
+// 标记通知为已读，all 为 true 时标记所有通知
+func ReadNotifications(userID int64, params *models.ParamNotificationRead) error {
+	var ids []int64
+	if !params.All {
+		if len(params.IDs) == 0 {
+			return bluebell.ErrInvalidParam
+		}
+		var err error
+		if ids, err = utils.ConvertStringSliceToInt64Slice(params.IDs); err != nil {
+			return bluebell.ErrInvalidParam
+		}
+	}
+
+	if err := mysql.UpdateNotificationsRead(userID, ids); err != nil {
+		return errors.Wrap(err, "logic:ReadNotifications: UpdateNotificationsRead")
+	}
+	return errors.Wrap(redis.DelNotificationUnreadCount(userID), "logic:ReadNotifications: DelNotificationUnreadCount")
+}
+
+// 帖子收到赞成票后，通知作者（只通知赞成票）
+func notifyPostVoted(userID, postID int64) {
+	post, err := GetPostDetailByID(postID, false)
+	if err != nil {
+		logger.Warnf("logic:notifyPostVoted: GetPostDetailByID, reason: %v", err.Error())
+		return
+	}
+	if post.UserID == userID {
+		return
+	}
+
+	err = kafka.SendNotification(kafka.NotificationCreate{
+		UserID:  post.UserID,
+		ActorID: userID,
+		Type:    models.NotificationVotePost,
+		ObjID:   postID,
+		PostID:  postID,
+	})
+	if err != nil {
+		logger.Warnf("logic:notifyPostVoted: SendNotification, reason: %v", err.Error())
+	}
+}
@@ -0,0 +1,249 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/oauth"
+	"bluebell/dao/redis"
+	"bluebell/internal/utils"
+	"bluebell/models"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	bluebell "bluebell/errors"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+/*
+	第三方登录
+*/
+
+const oauthEmailDomain = "@oauth.bluebell.invalid" // 没有可用邮箱时，使用的占位邮箱域名
+
+// 重新验证身份时 state 的前缀，回调时据此区分登录和重新验证身份
+const oauthReauthStatePrefix = "reauth_"
+
+// 生成跳转到第三方平台的授权地址
+//
+// userID 不为 0 时，用于已登录用户重新验证身份（如注销账户），回调时不会登录，而是返回 reauth_token
+func OAuthGetAuthURL(providerName string, userID int64) (string, error) {
+	provider, ok := oauth.GetProvider(providerName)
+	if !ok {
+		return "", bluebell.ErrNotFound
+	}
+
+	state, err := utils.GenRandomToken(16)
+	if err != nil {
+		return "", errors.Wrap(err, "logic:OAuthGetAuthURL: GenRandomToken(state)")
+	}
+	if userID != 0 {
+		state = oauthReauthStatePrefix + state
+	}
+	nonce, err := utils.GenRandomToken(16)
+	if err != nil {
+		return "", errors.Wrap(err, "logic:OAuthGetAuthURL: GenRandomToken(nonce)")
+	}
+
+	expireDuration := time.Second * time.Duration(viper.GetInt64("oauth.state_expire_time"))
+	if err := redis.SetOAuthState(state, providerName, nonce, userID, expireDuration); err != nil {
+		return "", errors.Wrap(err, "logic:OAuthGetAuthURL: SetOAuthState")
+	}
+
+	return provider.AuthCodeURL(state, nonce), nil
+}
+
+func IsOAuthReauthState(state string) bool {
+	return strings.HasPrefix(state, oauthReauthStatePrefix)
+}
+
+// 第三方平台回调：校验 state，换取身份，找到（或新建）对应的用户后登录
+//
+// 与 UserLogin 一样，开启了两步验证的用户返回 challenge_token
+func OAuthLogin(providerName, code, state string) (*models.User, string, string, string, error) {
+	identity, userID, err := exchangeOAuthIdentity(providerName, code, state)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	if userID != 0 { // 重新验证身份的 state 不能用于登录
+		return nil, "", "", "", bluebell.ErrInvalidToken
+	}
+
+	usr, err := getOrCreateUserByIdentity(identity)
+	if err != nil {
+		return nil, "", "", "", errors.Wrap(err, "logic:OAuthLogin: getOrCreateUserByIdentity")
+	}
+
+	access_token, refresh_token, challenge_token, err := loginHelper(usr.UserID)
+	return usr, access_token, refresh_token, challenge_token, errors.Wrap(err, "logic:OAuthLogin: loginHelper")
+}
+
+// 第三方平台回调（重新验证身份）：第三方身份需要已经绑定到发起授权的用户，通过后返回一次性的 reauth_token
+//
+// 第三方登录的用户不知道自己的（随机）密码，注销账户等敏感操作可以使用 reauth_token 代替密码
+func OAuthReauth(providerName, code, state string) (string, error) {
+	identity, userID, err := exchangeOAuthIdentity(providerName, code, state)
+	if err != nil {
+		return "", err
+	}
+	if userID == 0 {
+		return "", bluebell.ErrInvalidToken
+	}
+
+	_identity, err := mysql.SelectUserIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", bluebell.ErrForbidden
+		}
+		return "", errors.Wrap(err, "logic:OAuthReauth: SelectUserIdentity")
+	}
+	if _identity.UserID != userID { // 绑定的是其他账户
+		return "", bluebell.ErrForbidden
+	}
+
+	reauthToken, err := issueOAuthReauthToken(userID)
+	return reauthToken, errors.Wrap(err, "logic:OAuthReauth: issueOAuthReauthToken")
+}
+
+// 校验 state，使用 code 换取第三方身份，返回发起授权的 user_id（登录时为 0）
+func exchangeOAuthIdentity(providerName, code, state string) (*oauth.Identity, int64, error) {
+	provider, ok := oauth.GetProvider(providerName)
+	if !ok {
+		return nil, 0, bluebell.ErrNotFound
+	}
+
+	_providerName, nonce, userID, err := redis.GetAndDelOAuthState(state)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "logic:exchangeOAuthIdentity: GetAndDelOAuthState")
+	}
+	if _providerName != providerName { // state 过期，或者不是该 provider 发出的
+		return nil, 0, bluebell.ErrInvalidToken
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oauth.GetRequestTimeout())
+	defer cancel()
+	identity, err := provider.Exchange(ctx, code, nonce)
+	if err != nil {
+		return nil, 0, errors.Wrap(bluebell.ErrOAuthFailed, err.Error())
+	}
+	return identity, userID, nil
+}
+
+func issueOAuthReauthToken(userID int64) (string, error) {
+	reauthToken, err := utils.GenRandomToken(32)
+	if err != nil {
+		return "", errors.Wrap(err, "logic:issueOAuthReauthToken: GenRandomToken")
+	}
+	expireDuration := time.Second * time.Duration(viper.GetInt64("oauth.reauth_expire_time"))
+	if err := redis.SetOAuthReauthToken(reauthToken, userID, expireDuration); err != nil {
+		return "", errors.Wrap(err, "logic:issueOAuthReauthToken: SetOAuthReauthToken")
+	}
+	return reauthToken, nil
+}
+
+// 消费 reauth_token，token 不存在、已过期或属于其他用户时返回 ErrInvalidToken
+func verifyOAuthReauthToken(userID int64, reauthToken string) error {
+	_userID, err := redis.GetAndDelOAuthReauthToken(reauthToken)
+	if err != nil {
+		return errors.Wrap(err, "logic:verifyOAuthReauthToken: GetAndDelOAuthReauthToken")
+	}
+	if _userID == 0 || _userID != userID {
+		return bluebell.ErrInvalidToken
+	}
+	return nil
+}
+
+func getOrCreateUserByIdentity(identity *oauth.Identity) (*models.User, error) {
+	_identity, err := mysql.SelectUserIdentity(identity.Provider, identity.Subject)
+	if err == nil { // 已经绑定过
+		usr, err := mysql.SelectUserByUserID(_identity.UserID)
+		return usr, errors.Wrap(err, "logic:getOrCreateUserByIdentity: SelectUserByUserID")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrap(err, "logic:getOrCreateUserByIdentity: SelectUserIdentity")
+	}
+
+	// 首次登录，新建用户
+	usr := &models.User{
+		UserID: utils.GenSnowflakeID(),
+		Avatar: utils.Substr(identity.Avatar, 0, 256),
+	}
+
+	usr.UserName, err = genUniqueUserName(identity.Name, identity.Provider)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:getOrCreateUserByIdentity: genUniqueUserName")
+	}
+
+	// 只使用第三方平台验证过、且未被注册的邮箱，不会自动绑定到已有账户上
+	usr.Email = "u" + strconv.FormatInt(usr.UserID, 10) + oauthEmailDomain
+	if identity.Email != "" && identity.EmailVerified && len(identity.Email) <= 64 {
+		exist, _, err := checkUserIfExist(identity.Email, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "logic:getOrCreateUserByIdentity: checkUserIfExist")
+		}
+		if !exist {
+			usr.Email = identity.Email
+		}
+	}
+
+	// 第三方登录的用户没有密码，设置一个随机密码，只能通过第三方登录
+	randomPassword, err := utils.GenRandomToken(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:getOrCreateUserByIdentity: GenRandomToken")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:getOrCreateUserByIdentity: GenerateFromPassword")
+	}
+	usr.Password = string(hashedPassword)
+
+	err = mysql.CreateUserWithIdentity(usr, &models.UserIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    utils.Substr(identity.Email, 0, 64),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:getOrCreateUserByIdentity: CreateUserWithIdentity")
+	}
+	indexUser(usr.UserID, usr.UserName, usr.Intro)
+	return usr, nil
+}
+
+// 基于第三方平台的用户名，生成一个本站唯一的用户名
+func genUniqueUserName(name, provider string) (string, error) {
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+	base = utils.Substr(base, 0, 48)
+	if len([]rune(base)) < 3 {
+		base = provider + "_user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		exist, _, err := checkUserIfExist(candidate, true)
+		if err != nil {
+			return "", errors.Wrap(err, "logic:genUniqueUserName: checkUserIfExist")
+		}
+		if !exist {
+			return candidate, nil
+		}
+
+		suffix, err := genVerificationCode(4)
+		if err != nil {
+			return "", errors.Wrap(err, "logic:genUniqueUserName: genVerificationCode")
+		}
+		candidate = base + "_" + suffix
+	}
+
+	// 多次冲突，使用 snowflake id 保证唯一
+	return base + "_" + strconv.FormatInt(utils.GenSnowflakeID(), 36), nil
+}
@@ -2,12 +2,11 @@ package logic
 
 import (
 	"bluebell/algorithm"
-	"bluebell/dao/bleve"
-	"bluebell/dao/elasticsearch"
 	"bluebell/dao/localcache"
 	"bluebell/dao/mysql"
 	"bluebell/dao/rebuild"
 	"bluebell/dao/redis"
+	"bluebell/dao/search"
 	bluebell "bluebell/errors"
 	"bluebell/internal/utils"
 	"bluebell/logger"
@@ -43,21 +42,21 @@ func CreatePost(post *models.Post) error {
 		return err
 	}
 
-	doc := models.PostDoc{
-		PostID:  post.PostID,
-		Title:   utils.Substr(post.Title, 0, 64),    // 只索引前 64 个字符
-		Content: utils.Substr(post.Content, 0, 256), // 只索引前 256 个字符
+	// 递增作者的帖子数
+	if err := mysql.IncrUserStatField(nil, post.AuthorID, models.UserStatPostCount, 1); err != nil {
+		logger.Warnf("logic:CreatePost: IncrUserStatField, reason: %v", err.Error())
 	}
-	var err error
-	if viper.GetBool("elasticsearch.enable") {
-		doc.CreatedAt = models.Time(time.Now())
-		err = elasticsearch.CreatePost(&doc)
-	}
-	if viper.GetBool("bleve.enable") {
-		doc.CreatedAt = time.Now()
-		err = bleve.CreatePost(&doc)
-	}
-	return errors.Wrap(err, "logic:CreatePost: index post doc")
+
+	// 推送到粉丝的关注流
+	go func() {
+		if err := pushPostToFeeds(post.AuthorID, post.PostID); err != nil {
+			logger.Errorf("logic:CreatePost: push post to feeds failed, reason: %v", err.Error())
+		}
+	}()
+
+	doc := newPostDoc(post.PostID, post.CommunityID, post.AuthorID, post.Title, post.Content, 0, time.Now())
+	// 写入所有启用的搜索引擎，错误会被聚合返回
+	return errors.Wrap(indexPostDoc(doc, true), "logic:CreatePost: indexPostDoc")
 }
 
 func GetPostDetailByID(id int64, needIncrView bool) (detail *models.PostDTO, err error) {
@@ -133,9 +132,18 @@ func VoteForPost(user_id, post_id int64, direction int8) error {
 	}
 
 	// 保存用户操作
-	if err := redis.SetUserPostDirection(post_id, user_id, direction); err != nil {
+	oldDirection, err := redis.SetUserPostDirection(post_id, user_id, direction)
+	if err != nil {
 		return errors.Wrap(err, "logic:VoteForPost: SetUserPostDirection")
 	}
+	// 赞成票发生变化，更新作者收到的赞成票总数
+	if voteOffset := upVoteOf(direction) - upVoteOf(oldDirection); voteOffset != 0 {
+		go incrPostAuthorVoteCount(post_id, voteOffset)
+		schedulePostVoteSync(post_id) // 同步到搜索引擎，用于按赞成票数过滤
+	}
+	if direction == 1 {
+		go notifyPostVoted(user_id, post_id)
+	}
 
 	postIDStr := fmt.Sprintf("%d", post_id)
 	upVoteNum, err := redis.GetPostUpVoteNums([]string{postIDStr})
@@ -176,6 +184,13 @@ func VoteForPost(user_id, post_id int64, direction int8) error {
 
 }
 
+func upVoteOf(direction int8) int64 {
+	if direction == 1 {
+		return 1
+	}
+	return 0
+}
+
 func GetAllPostList(params *models.ParamPostList) ([]*models.PostDTO, int, error) {
 	// 在 redis 中查询 posts 的 id
 	var postIDs []string
@@ -199,60 +214,58 @@ func GetAllPostList(params *models.ParamPostList) ([]*models.PostDTO, int, error
 	return list, total, err
 }
 
-func GetPostListByKeyword2(params *models.ParamPostListByKeyword) ([]*models.PostDTO, int, error) {
-	sfkey := fmt.Sprintf("%v_%v_%v_%v", params.Keyword, params.OrderBy, params.PageNum, params.PageSize)
-	timeout := time.Second * time.Duration(viper.GetInt("service.timeout"))
-	rps := viper.GetInt("service.rps")
-	interval := time.Second / time.Duration(rps)
-
-	ret, err := utils.SfDoWithTimeout(&postIDsGrp, sfkey, timeout, interval, func() (any, error) {
-		var postIDs []string
-		var err error
-		total := 0
-		if params.OrderBy == "time" {
-			postIDs, total, err = elasticsearch.GetPostIDsByKeywordOrderByTime(params)
-		} else if params.OrderBy == "correlation" {
-			postIDs, total, err = elasticsearch.GetPostIDsByKeywordOrderByCorrelation(params)
-		}
-		return ReturnValueFromSearch{
-			PostIDs: postIDs,
-			Total:   total,
-		}, err
-	})
+// 查询用户加入的社区的帖子
+func GetJoinedPostList(userID int64, params *models.ParamPostList) ([]*models.PostDTO, int, error) {
+	communityIDs, err := mysql.SelectJoinedCommunityIDs(userID)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "logic:GetJoinedPostList: SelectJoinedCommunityIDs")
+	}
+	if len(communityIDs) == 0 {
+		return []*models.PostDTO{}, 0, nil
+	}
 
+	postIDs, total, err := redis.GetPostIDsByCommunities(params.PageNum, params.PageSize, params.OrderBy, userID, communityIDs)
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "logic:GetPostListByKeyword2:elasticsearch")
+		return nil, 0, errors.Wrap(err, "logic:GetJoinedPostList: GetPostIDsByCommunities")
 	}
-	postIDs := ret.(ReturnValueFromSearch).PostIDs
-	total := ret.(ReturnValueFromSearch).Total
 
 	list, err := GetPostListByIDs(postIDs)
 	return list, total, err
 }
 
-// 使用 bleve 实现的搜索
-func GetPostListByKeyword(params *models.ParamPostListByKeyword) ([]*models.PostDTO, int, error) {
-	sfkey := fmt.Sprintf("%v_%v_%v_%v", params.Keyword, params.OrderBy, params.PageNum, params.PageSize)
+// GetPostListByKeyword 根据关键字及过滤条件搜索帖子，具体使用的搜索引擎由配置决定
+func GetPostListByKeyword(params *models.ParamPostListByKeyword) (*models.PostSearchListDTO, error) {
+	sfkey := fmt.Sprintf("%v_%v_%v_%v_%v_%v_%v_%v_%v_%v", params.Keyword, params.OrderBy, params.PageNum, params.PageSize,
+		params.CommunityID, params.AuthorID, params.StartDate, params.EndDate, params.MinVotes, params.WithFacets)
 	timeout := time.Second * time.Duration(viper.GetInt("service.timeout"))
 	rps := viper.GetInt("service.rps")
 	interval := time.Second / time.Duration(rps)
 
 	ret, err := utils.SfDoWithTimeout(&postIDsGrp, sfkey, timeout, interval, func() (any, error) {
-		postIDs, total, err := bleve.GetPostIDsByKeyword(params)
-		return ReturnValueFromSearch{
-			PostIDs: postIDs,
-			Total:   total,
-		}, err
+		return search.QueryPosts(params)
 	})
-
 	if err != nil {
-		return nil, 0, errors.Wrap(err, "logic:GetPostListByKeyword2:bleve")
+		return nil, errors.Wrap(err, "logic:GetPostListByKeyword: QueryPosts")
+	}
+	res := ret.(*models.PostSearchResult)
+	if params.PageNum == 1 && res.Total > 0 { // 只统计有结果的搜索，翻页不重复统计
+		recordSearchTerm(params.Keyword)
 	}
-	postIDs := ret.(ReturnValueFromSearch).PostIDs
-	total := ret.(ReturnValueFromSearch).Total
 
-	list, err := GetPostListByIDs(postIDs)
-	return list, total, err
+	list, err := GetPostListByIDs(res.PostIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetPostListByKeyword: GetPostListByIDs")
+	}
+	facets, err := withCommunityFacetNames(res.Facets)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetPostListByKeyword: withCommunityFacetNames")
+	}
+	return &models.PostSearchListDTO{
+		Total:      res.Total,
+		Posts:      list,
+		Highlights: res.Highlights,
+		Facets:     facets,
+	}, nil
 }
 
 /*
@@ -354,7 +367,8 @@ func GetPostListByIDs(postIDs []string) ([]*models.PostDTO, error) {
 		}
 	}
 
-	return list, nil
+	// 为转发填充原帖
+	return FillRepostOriginals(list)
 }
 
 func GetPostListByAuthorID(params models.ParamUserPostList) (int, []*models.PostDTO, error) {
@@ -365,7 +379,11 @@ func GetPostListByAuthorID(params models.ParamUserPostList) (int, []*models.Post
 		return 0, nil, errors.Wrap(err, "logic:GetPostListByAuthorID: SelectPostsByAuthorID")
 	}
 	total, err := mysql.SelectPostCountByAuthorID(params.UserID)
-	return total, postList, errors.Wrap(err, "logic:GetPostListByAuthorID: SelectPostCountByAuthorID")
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "logic:GetPostListByAuthorID: SelectPostCountByAuthorID")
+	}
+	postList, err = FillRepostOriginals(postList)
+	return total, postList, errors.Wrap(err, "logic:GetPostListByAuthorID: FillRepostOriginals")
 }
 
 func GetHotPostList() ([]*models.PostDTO, error) {
@@ -388,16 +406,18 @@ func GetHotPostList() ([]*models.PostDTO, error) {
 	return posts.([]*models.PostDTO), nil
 }
 
-func RemovePost(userID int64, params models.ParamPostRemove) error {
+func RemovePost(userID int64, role models.RoleInfo, params models.ParamPostRemove) error {
 	// 鉴权
-	// 1. 获取 Post 的元数据（author_id、status）
+	// 1. 获取 Post 的元数据（author_id、community_id、status）
 	post, err := mysql.SelectPostDetailByID(params.PostID)
 	if err != nil {
 		return errors.Wrap(err, "logic:RemovePost: SelectPostDetailByID")
 	}
-	// 2. 判断 user_id 与 author_id 是否相等
-	// 后续可以引入管理员
-	if userID != post.UserID {
+	if post.PostID == 0 {
+		return bluebell.ErrNoSuchPost
+	}
+	// 2. 作者本人，或者该社区的版主、管理员才能删除
+	if !canManagePost(userID, role, post.UserID, post.CommunityID) {
 		return bluebell.ErrForbidden
 	}
 
@@ -417,7 +437,32 @@ func RemovePost(userID int64, params models.ParamPostRemove) error {
 			return errors.Wrap(err, "logic:RemovePost: DeletePostExpiredScoresByPostID")
 		}
 	}
+	// 删除收藏
+	if err := mysql.DeleteUserBookmarksByPostID(tx, post.PostID); err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "logic:RemovePost: DeleteUserBookmarksByPostID")
+	}
+	// 递减作者的帖子数
+	if err := mysql.IncrUserStatField(tx, post.UserID, models.UserStatPostCount, -1); err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "logic:RemovePost: IncrUserStatField")
+	}
+	// 删除的是转发，递减原帖的转发数（原帖已被删除时不影响）
+	if post.OriginalPostID != 0 {
+		if err := mysql.IncrPostRepostCount(tx, post.OriginalPostID, -1); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "logic:RemovePost: IncrPostRepostCount")
+		}
+	}
 	tx.Commit()
+	if post.OriginalPostID != 0 {
+		removePostLocalCache(post.OriginalPostID)
+	}
+
+	// 从关注流中移除
+	if err := removePostFromFeeds(post.UserID, post.PostID); err != nil {
+		logger.Errorf("logic:RemovePost: remove post from feeds failed, reason: %v", err.Error())
+	}
 
 	// 判断 status，如果为 0，还要删除 redis 中的相关记录
 	// 删除失败，简单处理如下：重试 5 次，每次间隔时间从 1s 开始指数增加
@@ -470,28 +515,17 @@ func RemovePost(userID int64, params models.ParamPostRemove) error {
 		}
 	}
 
-	if viper.GetBool("bleve.enable") {
-		// 删 bleve 搜索引擎中的索引
-		err = bleve.DeletePost(params.PostID)
-		if err != nil {
-			logger.Errorf("remove post from bleve failed, reason: %v", err.Error())
-		}
+	// 删除所有搜索引擎中的索引
+	if err = search.DeletePost(params.PostID); err != nil {
+		logger.Errorf("logic:RemovePost: remove post from search engines failed, reason: %v", err.Error())
 	}
-	if viper.GetBool("elasticsearch.enable") {
-		// 删 elasticsearch 搜索引擎中的索引
-		err = elasticsearch.DeletePost(params.PostID)
-		if err != nil {
-			logger.Errorf("remove post from elasticsearch failed, reason: %v", err.Error())
-		}
+	if err = redis.DelPostSearchHash(params.PostID); err != nil {
+		logger.Warnf("logic:RemovePost: DelPostSearchHash failed, reason: %v", err.Error())
 	}
 
 	// 删除本地缓存
 	cacheKey := fmt.Sprintf("%v_%v", objects.ObjPost, post.PostID)
 	localcache.GetLocalCache().Remove(cacheKey)
+	localcache.GetLocalCache().Remove(relatedPostsCacheKey(post.PostID))
 	return nil
 }
-
-type ReturnValueFromSearch struct {
-	Total   int
-	PostIDs []string
-}
@@ -0,0 +1,255 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/dao/search"
+	"bluebell/logger"
+	"bluebell/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 索引校验的结果
+type IndexVerifyReport struct {
+	Engine   string
+	Checked  int64    // 校验的帖子数
+	Missing  []int64  // 数据库中存在，但没有被索引的帖子
+	Orphaned []string // 被索引，但数据库中已不存在的帖子
+}
+
+// ReindexPosts 按主键顺序分批将 mysql 中的帖子写入搜索引擎
+// resume 为 true 时从上次中断的位置继续，每批写入成功后记录进度，progress 用于报告进度
+func ReindexPosts(engine string, batchSize int, resume bool, progress func(done, total int64)) error {
+	engines, err := search.Engines(engine)
+	if err != nil {
+		return errors.Wrap(err, "logic:ReindexPosts: Engines")
+	}
+
+	var cursor int64
+	if resume {
+		if cursor, err = redis.GetReindexCursor(engine); err != nil {
+			return errors.Wrap(err, "logic:ReindexPosts: GetReindexCursor")
+		}
+	}
+	total, err := mysql.SelectPostCountAfterID(cursor)
+	if err != nil {
+		return errors.Wrap(err, "logic:ReindexPosts: SelectPostCountAfterID")
+	}
+
+	var done int64
+	for {
+		posts, err := mysql.SelectPostsAfterID(cursor, batchSize)
+		if err != nil {
+			return errors.Wrap(err, "logic:ReindexPosts: SelectPostsAfterID")
+		}
+		if len(posts) == 0 {
+			break
+		}
+		docs, err := buildPostDocs(posts)
+		if err != nil {
+			return errors.Wrap(err, "logic:ReindexPosts: buildPostDocs")
+		}
+
+		for _, e := range engines {
+			if err := e.IndexPosts(docs); err != nil {
+				return errors.Wrapf(err, "logic:ReindexPosts: IndexPosts(%v)", e.Name())
+			}
+		}
+		// 写入了所有引擎，才能记录 hash（hash 表示所有引擎中的文档都是最新的）
+		if engine == search.EngineAll {
+			for _, doc := range docs {
+				if err := redis.SetPostSearchHash(doc.PostID, postDocHash(doc)); err != nil {
+					logger.Warnf("logic:ReindexPosts: SetPostSearchHash failed, reason: %v", err.Error())
+				}
+			}
+		}
+
+		cursor = posts[len(posts)-1].ID
+		if err := redis.SetReindexCursor(engine, cursor); err != nil {
+			logger.Warnf("logic:ReindexPosts: SetReindexCursor failed, reason: %v", err.Error())
+		}
+		done += int64(len(posts))
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	// 全部完成，清除进度
+	return errors.Wrap(redis.DelReindexCursor(engine), "logic:ReindexPosts: DelReindexCursor")
+}
+
+// ReindexUsers 按主键顺序分批将 mysql 中的用户写入搜索引擎，已注销的用户不会被写入
+func ReindexUsers(engine string, batchSize int, progress func(done, total int64)) error {
+	engines, err := search.Engines(engine)
+	if err != nil {
+		return errors.Wrap(err, "logic:ReindexUsers: Engines")
+	}
+
+	total, err := mysql.SelectUserCount()
+	if err != nil {
+		return errors.Wrap(err, "logic:ReindexUsers: SelectUserCount")
+	}
+
+	var cursor, done int64
+	for {
+		users, err := mysql.SelectUsersAfterID(cursor, batchSize)
+		if err != nil {
+			return errors.Wrap(err, "logic:ReindexUsers: SelectUsersAfterID")
+		}
+		if len(users) == 0 {
+			break
+		}
+		for _, user := range users {
+			if strings.HasSuffix(user.Email, deletedAccountEmailSuffix) {
+				continue
+			}
+			doc := &models.UserDoc{UserID: user.UserID, UserName: user.UserName, Intro: user.Intro}
+			for _, e := range engines {
+				if err := e.IndexUser(doc); err != nil {
+					return errors.Wrapf(err, "logic:ReindexUsers: IndexUser(%v)", e.Name())
+				}
+			}
+		}
+
+		cursor = users[len(users)-1].ID
+		done += int64(len(users))
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+	return nil
+}
+
+// ReindexCommunities 将 mysql 中的所有社区写入搜索引擎，社区数量较少，不分批
+func ReindexCommunities(engine string, progress func(done, total int64)) error {
+	engines, err := search.Engines(engine)
+	if err != nil {
+		return errors.Wrap(err, "logic:ReindexCommunities: Engines")
+	}
+	communities, err := mysql.SelectAllCommunities()
+	if err != nil {
+		return errors.Wrap(err, "logic:ReindexCommunities: SelectAllCommunities")
+	}
+
+	total := int64(len(communities))
+	for i, community := range communities {
+		doc := &models.CommunityDoc{CommunityID: community.CommunityID, CommunityName: community.CommunityName, Introduction: community.Introduction}
+		for _, e := range engines {
+			if err := e.IndexCommunity(doc); err != nil {
+				return errors.Wrapf(err, "logic:ReindexCommunities: IndexCommunity(%v)", e.Name())
+			}
+		}
+		if progress != nil {
+			progress(int64(i+1), total)
+		}
+	}
+	return nil
+}
+
+// VerifyPostIndex 校验搜索引擎中的索引与 mysql 是否一致，报告缺失和多余的帖子
+func VerifyPostIndex(engine string, batchSize int) ([]*IndexVerifyReport, error) {
+	engines, err := search.Engines(engine)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:VerifyPostIndex: Engines")
+	}
+	reports := make([]*IndexVerifyReport, len(engines))
+	for i, e := range engines {
+		reports[i] = &IndexVerifyReport{Engine: e.Name(), Missing: make([]int64, 0), Orphaned: make([]string, 0)}
+	}
+
+	// 遍历 mysql，找出没有被索引的帖子
+	var cursor int64
+	for {
+		posts, err := mysql.SelectPostIDsAfterID(cursor, batchSize)
+		if err != nil {
+			return nil, errors.Wrap(err, "logic:VerifyPostIndex: SelectPostIDsAfterID")
+		}
+		if len(posts) == 0 {
+			break
+		}
+		postIDs := make([]string, len(posts))
+		for i, post := range posts {
+			postIDs[i] = strconv.FormatInt(post.PostID, 10)
+		}
+
+		for i, e := range engines {
+			existing, err := e.PostIDsExist(postIDs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "logic:VerifyPostIndex: PostIDsExist(%v)", e.Name())
+			}
+			for _, post := range posts {
+				if !existing[strconv.FormatInt(post.PostID, 10)] {
+					reports[i].Missing = append(reports[i].Missing, post.PostID)
+				}
+			}
+			reports[i].Checked += int64(len(posts))
+		}
+		cursor = posts[len(posts)-1].ID
+	}
+
+	// 遍历索引，找出数据库中已不存在的帖子
+	for i, e := range engines {
+		report := reports[i]
+		err := e.ScanPostIDs(batchSize, func(postIDs []string) error {
+			ids := make([]int64, 0, len(postIDs))
+			for _, postID := range postIDs {
+				id, err := strconv.ParseInt(postID, 10, 64)
+				if err != nil { // 不合法的文档 id，一定是多余的
+					report.Orphaned = append(report.Orphaned, postID)
+					continue
+				}
+				ids = append(ids, id)
+			}
+			existing, err := mysql.SelectExistingPostIDs(ids)
+			if err != nil {
+				return errors.Wrap(err, "SelectExistingPostIDs")
+			}
+			set := make(map[int64]struct{}, len(existing))
+			for _, id := range existing {
+				set[id] = struct{}{}
+			}
+			for _, id := range ids {
+				if _, ok := set[id]; !ok {
+					report.Orphaned = append(report.Orphaned, strconv.FormatInt(id, 10))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "logic:VerifyPostIndex: ScanPostIDs(%v)", e.Name())
+		}
+	}
+	return reports, nil
+}
+
+// 构造一批帖子的文档，赞成票数：活跃帖子从 redis 获取，过期帖子从 mysql 获取
+func buildPostDocs(posts []*models.Post) ([]*models.PostDoc, error) {
+	postIDs := make([]string, len(posts))
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = strconv.FormatInt(post.PostID, 10)
+		ids[i] = post.PostID
+	}
+	upVoteNums, err := redis.GetPostUpVoteNums(postIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:buildPostDocs: GetPostUpVoteNums")
+	}
+	expiredUpVoteNums, err := mysql.SelectExpiredPostUpVoteNums(ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:buildPostDocs: SelectExpiredPostUpVoteNums")
+	}
+
+	docs := make([]*models.PostDoc, len(posts))
+	for i, post := range posts {
+		voteNum := upVoteNums[i]
+		if expired, ok := expiredUpVoteNums[post.PostID]; ok {
+			voteNum = expired
+		}
+		docs[i] = newPostDoc(post.PostID, post.CommunityID, post.AuthorID, post.Title, post.Content, voteNum, time.Time(post.CreatedAt))
+	}
+	return docs, nil
+}
@@ -0,0 +1,118 @@
+package logic
+
+import (
+	"bluebell/dao/localcache"
+	"bluebell/dao/redis"
+	"bluebell/dao/search"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/models"
+	"bluebell/objects"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/singleflight"
+)
+
+var relatedPostsGrp singleflight.Group
+
+// 相关帖子的本地缓存 key，与帖子详情的 key（ObjPost_postID）放在一起
+func relatedPostsCacheKey(postID int64) string {
+	return fmt.Sprintf("%v_%v_related", objects.ObjPost, postID)
+}
+
+// GetRelatedPosts 获取与帖子内容相似的同社区帖子
+//
+// 先由搜索引擎按内容相似度召回候选，再结合帖子的分数（bluebell:post:score）重新排序，结果缓存在本地缓存中
+func GetRelatedPosts(postID int64) ([]*models.PostDTO, error) {
+	cacheKey := relatedPostsCacheKey(postID)
+	if postIDs, err := localcache.GetLocalCache().Get(cacheKey); err == nil { // 本地缓存命中
+		return GetPostListByIDs(postIDs.([]string))
+	}
+
+	timeout := time.Second * time.Duration(viper.GetInt("service.timeout"))
+	interval := time.Second / time.Duration(viper.GetInt("service.rps"))
+	ret, err := utils.SfDoWithTimeout(&relatedPostsGrp, strconv.FormatInt(postID, 10), timeout, interval, func() (any, error) {
+		return getRelatedPostIDs(postID)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetRelatedPosts: getRelatedPostIDs")
+	}
+	postIDs := ret.([]string)
+	expire := time.Second * time.Duration(viper.GetInt("search.related.cache_time"))
+	localcache.GetLocalCache().SetWithExpire(cacheKey, postIDs, expire)
+
+	list, err := GetPostListByIDs(postIDs)
+	return list, errors.Wrap(err, "logic:GetRelatedPosts: GetPostListByIDs")
+}
+
+func getRelatedPostIDs(postID int64) ([]string, error) {
+	post, err := GetPostDetailByID(postID, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetPostDetailByID")
+	}
+	if post.PostID == 0 {
+		return nil, bluebell.ErrNoSuchPost
+	}
+	doc := newPostDoc(post.PostID, post.CommunityID, post.UserID, post.Title, post.Content, post.VoteNum, time.Time(post.CreatedAt))
+	candidates, err := search.RelatedPosts(doc, viper.GetInt("search.related.candidate_num"))
+	if err != nil {
+		return nil, errors.Wrap(err, "RelatedPosts")
+	}
+	if len(candidates) == 0 {
+		return make([]string, 0), nil
+	}
+
+	postIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		postIDs[i] = candidate.PostID
+	}
+	scores, err := redis.MGetPostScores(postIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "MGetPostScores")
+	}
+	rerankBySimilarityAndScore(candidates, scores, viper.GetFloat64("search.related.score_boost"))
+
+	size := viper.GetInt("search.related.size")
+	if len(candidates) < size {
+		size = len(candidates)
+	}
+	for i := 0; i < size; i++ {
+		postIDs[i] = candidates[i].PostID
+	}
+	return postIDs[:size], nil
+}
+
+// 最终得分 = 相似度 * (1 + boost * 归一化的帖子分数)
+//
+// 帖子分数包含发布时间，只在候选集内做 min-max 归一化，没有分数的帖子（已过期）按 0 计算
+func rerankBySimilarityAndScore(candidates []models.PostSimilarity, scores []float64, boost float64) {
+	min, max := 0.0, 0.0
+	first := true
+	for _, score := range scores {
+		if score == 0 {
+			continue
+		}
+		if first || score < min {
+			min = score
+		}
+		if first || score > max {
+			max = score
+		}
+		first = false
+	}
+	for i := range candidates {
+		normalized := 0.0
+		if scores[i] != 0 && max > min {
+			normalized = (scores[i] - min) / (max - min)
+		}
+		candidates[i].Score *= 1 + boost*normalized
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+}
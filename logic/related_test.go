@@ -0,0 +1,101 @@
+package logic
+
+import (
+	"bluebell/models"
+	"math"
+	"testing"
+)
+
+func TestRerankBySimilarityAndScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []models.PostSimilarity
+		scores     []float64
+		boost      float64
+		want       []models.PostSimilarity
+	}{
+		{
+			name: "min-max normalization",
+			candidates: []models.PostSimilarity{
+				{PostID: "1", Score: 1}, {PostID: "2", Score: 1}, {PostID: "3", Score: 1},
+			},
+			scores: []float64{100, 300, 200},
+			boost:  1,
+			// 最低分不加权，最高分加权 boost，中间按比例
+			want: []models.PostSimilarity{
+				{PostID: "2", Score: 2}, {PostID: "3", Score: 1.5}, {PostID: "1", Score: 1},
+			},
+		},
+		{
+			name: "score can overtake similarity",
+			candidates: []models.PostSimilarity{
+				{PostID: "1", Score: 2}, {PostID: "2", Score: 1.5},
+			},
+			scores: []float64{10, 20},
+			boost:  0.5,
+			want: []models.PostSimilarity{
+				{PostID: "2", Score: 2.25}, {PostID: "1", Score: 2},
+			},
+		},
+		{
+			name: "expired posts are excluded from the range",
+			candidates: []models.PostSimilarity{
+				{PostID: "1", Score: 1}, {PostID: "2", Score: 1}, {PostID: "3", Score: 1},
+			},
+			scores: []float64{0, 50, 150},
+			boost:  1,
+			want: []models.PostSimilarity{
+				{PostID: "3", Score: 2}, {PostID: "1", Score: 1}, {PostID: "2", Score: 1},
+			},
+		},
+		{
+			name: "all zero scores keep similarity order",
+			candidates: []models.PostSimilarity{
+				{PostID: "1", Score: 3}, {PostID: "2", Score: 2}, {PostID: "3", Score: 2},
+			},
+			scores: []float64{0, 0, 0},
+			boost:  1,
+			want: []models.PostSimilarity{
+				{PostID: "1", Score: 3}, {PostID: "2", Score: 2}, {PostID: "3", Score: 2},
+			},
+		},
+		{
+			name: "equal scores are not boosted",
+			candidates: []models.PostSimilarity{
+				{PostID: "1", Score: 1}, {PostID: "2", Score: 2},
+			},
+			scores: []float64{40, 40},
+			boost:  1,
+			want: []models.PostSimilarity{
+				{PostID: "2", Score: 2}, {PostID: "1", Score: 1},
+			},
+		},
+		{
+			name:       "single candidate",
+			candidates: []models.PostSimilarity{{PostID: "1", Score: 0.8}},
+			scores:     []float64{120},
+			boost:      1,
+			want:       []models.PostSimilarity{{PostID: "1", Score: 0.8}},
+		},
+		{
+			name:       "no candidates",
+			candidates: []models.PostSimilarity{},
+			scores:     []float64{},
+			boost:      1,
+			want:       []models.PostSimilarity{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rerankBySimilarityAndScore(tt.candidates, tt.scores, tt.boost)
+			if len(tt.candidates) != len(tt.want) {
+				t.Fatalf("len = %d, want %d", len(tt.candidates), len(tt.want))
+			}
+			for i, got := range tt.candidates {
+				if got.PostID != tt.want[i].PostID || math.Abs(got.Score-tt.want[i].Score) > 1e-9 {
+					t.Errorf("candidates[%d] = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
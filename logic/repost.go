@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"bluebell/dao/localcache"
+	"bluebell/dao/mysql"
+	bluebell "bluebell/errors"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/models"
+	"bluebell/objects"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+/*
+	转发
+
+	转发也是一篇帖子，original_post_id 指向原帖；转发一篇转发时，指向最初的原帖
+	原帖被删除后，转发保留，返回时标记 original_removed
+*/
+
+const (
+	repostTitlePrefix     = "转发："
+	defaultRepostContent  = "转发"
+	maxPostTitleRuneCount = 128
+)
+
+// 返回转发生成的帖子 id
+func RepostPost(userID int64, params *models.ParamPostRepost) (int64, error) {
+	original, err := mysql.SelectPostByID(params.PostID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, bluebell.ErrNoSuchPost
+		}
+		return 0, errors.Wrap(err, "logic:RepostPost: SelectPostByID")
+	}
+	// 转发的是一篇转发，指向最初的原帖
+	if original.OriginalPostID != 0 {
+		if original, err = mysql.SelectPostByID(original.OriginalPostID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, bluebell.ErrNoSuchPost
+			}
+			return 0, errors.Wrap(err, "logic:RepostPost: SelectPostByID(original)")
+		}
+	}
+
+	title := params.Title
+	if title == "" {
+		title = utils.Substr(repostTitlePrefix+original.Title, 0, maxPostTitleRuneCount)
+	}
+	content := params.Content
+	if content == "" {
+		content = defaultRepostContent
+	}
+	post := &models.Post{
+		PostID:         utils.GenSnowflakeID(),
+		CommunityID:    params.CommunityID,
+		AuthorID:       userID,
+		Title:          title,
+		Content:        content,
+		OriginalPostID: original.PostID,
+	}
+	if err := CreatePost(post); err != nil {
+		return 0, errors.Wrap(err, "logic:RepostPost: CreatePost")
+	}
+
+	if err := mysql.IncrPostRepostCount(nil, original.PostID, 1); err != nil {
+		logger.Warnf("logic:RepostPost: IncrPostRepostCount, reason: %v", err.Error())
+	}
+	removePostLocalCache(original.PostID)
+	return post.PostID, nil
+}
+
+// 为转发填充原帖
+//
+// 缓存中的 PostDTO 是所有用户共享的，因此返回的是拷贝
+func FillRepostOriginals(posts []*models.PostDTO) ([]*models.PostDTO, error) {
+	res := make([]*models.PostDTO, len(posts))
+	for i, post := range posts {
+		res[i] = post
+		if post.OriginalPostID == 0 {
+			continue
+		}
+
+		repost := *post
+		original, err := GetPostDetailByID(post.OriginalPostID, false)
+		if err != nil && !errors.Is(err, bluebell.ErrNoSuchPost) {
+			return nil, errors.Wrap(err, "logic:FillRepostOriginals: GetPostDetailByID")
+		}
+		if err != nil || original.PostID == 0 { // 原帖已被删除
+			repost.OriginalRemoved = true
+		} else {
+			repost.Original = original
+		}
+		res[i] = &repost
+	}
+	return res, nil
+}
+
+func removePostLocalCache(postID int64) {
+	cacheKey := fmt.Sprintf("%v_%v", objects.ObjPost, postID)
+	localcache.GetLocalCache().Remove(cacheKey)
+}
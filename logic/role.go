@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/models"
+
+	bluebell "bluebell/errors"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// 将配置文件中 service.rbac.admins 指定的用户设置为管理员
+func InitAdmins() {
+	for _, userID := range viper.GetIntSlice("service.rbac.admins") {
+		if err := mysql.CreateUserRole(int64(userID), models.RoleAdmin, 0); err != nil {
+			panic(err.Error())
+		}
+	}
+}
+
+func GetUserRoleInfo(userID int64) (models.RoleInfo, error) {
+	roles, err := mysql.SelectUserRolesByUserID(userID)
+	if err != nil {
+		return models.RoleInfo{}, errors.Wrap(err, "logic:GetUserRoleInfo: SelectUserRolesByUserID")
+	}
+	return models.NewRoleInfo(roles), nil
+}
+
+// 授予角色，版主需要指定社区
+func GrantRole(params *models.ParamRoleUpdate) error {
+	if err := checkRoleParams(params); err != nil {
+		return err
+	}
+	if err := mysql.CreateUserRole(params.UserID, params.Role, params.CommunityID); err != nil {
+		return errors.Wrap(err, "logic:GrantRole: CreateUserRole")
+	}
+	return errors.Wrap(expireUserAccessToken(params.UserID), "logic:GrantRole: expireUserAccessToken")
+}
+
+func RevokeRole(params *models.ParamRoleUpdate) error {
+	if err := checkRoleParams(params); err != nil {
+		return err
+	}
+	if err := mysql.DeleteUserRole(params.UserID, params.Role, params.CommunityID); err != nil {
+		return errors.Wrap(err, "logic:RevokeRole: DeleteUserRole")
+	}
+	return errors.Wrap(expireUserAccessToken(params.UserID), "logic:RevokeRole: expireUserAccessToken")
+}
+
+func checkRoleParams(params *models.ParamRoleUpdate) error {
+	if _, err := mysql.SelectUserByUserID(params.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bluebell.ErrUserNotExist
+		}
+		return errors.Wrap(err, "logic:checkRoleParams: SelectUserByUserID")
+	}
+
+	if params.Role == models.RoleAdmin {
+		params.CommunityID = 0
+		return nil
+	}
+	if _, err := GetCommunityDetailByID(params.CommunityID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 角色记录在 access_token 中，角色变更后，使旧的 access_token 失效，用户重新登录后生效
+func expireUserAccessToken(userID int64) error {
+	return redis.DelUserAccessToken(userID)
+}
+
+// 判断用户能否管理某个帖子（作者本人，或者帖子所在社区的版主、管理员）
+func canManagePost(userID int64, role models.RoleInfo, authorID, communityID int64) bool {
+	return userID == authorID || role.CanModerate(communityID)
+}
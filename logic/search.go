@@ -0,0 +1,282 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/dao/search"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/models"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/singleflight"
+)
+
+// 搜索词的最大长度，过长的搜索词不参与热门搜索词统计
+const maxSearchTermLen = 50
+
+var pendingVoteSync sync.Map // 等待同步赞成票数到搜索引擎的帖子
+var postSuggestGrp singleflight.Group
+var searchGrp singleflight.Group
+
+// 构造帖子在搜索引擎中的文档，索引完整的标题和内容
+func newPostDoc(postID, communityID, authorID int64, title, content string, voteNum int64, createdAt time.Time) *models.PostDoc {
+	return &models.PostDoc{
+		PostID:      postID,
+		Title:       title,
+		Content:     content,
+		CommunityID: strconv.FormatInt(communityID, 10),
+		AuthorID:    strconv.FormatInt(authorID, 10),
+		VoteNum:     voteNum,
+		CreatedAt:   createdAt,
+	}
+}
+
+// 赞成票数变化后，延迟一段时间再同步到搜索引擎，合并短时间内的多次投票
+func schedulePostVoteSync(postID int64) {
+	if _, loaded := pendingVoteSync.LoadOrStore(postID, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		time.Sleep(time.Second * time.Duration(viper.GetInt("search.vote_sync_delay")))
+		pendingVoteSync.Delete(postID) // 先删除标记，同步期间的新投票会再次触发同步
+		if err := syncPostVoteNum(postID); err != nil {
+			logger.Warnf("logic:schedulePostVoteSync: syncPostVoteNum failed, reason: %v", err.Error())
+		}
+	}()
+}
+
+func syncPostVoteNum(postID int64) error {
+	post, err := mysql.SelectPostDetailByID(postID)
+	if err != nil {
+		return errors.Wrap(err, "logic:syncPostVoteNum: SelectPostDetailByID")
+	}
+	if post.PostID == 0 { // 帖子已被删除
+		return nil
+	}
+	voteNums, err := redis.GetPostUpVoteNums([]string{strconv.FormatInt(postID, 10)})
+	if err != nil {
+		return errors.Wrap(err, "logic:syncPostVoteNum: GetPostUpVoteNums")
+	}
+
+	doc := newPostDoc(post.PostID, post.CommunityID, post.UserID, post.Title, post.Content, voteNums[0], time.Time(post.CreatedAt))
+	return errors.Wrap(indexPostDoc(doc, false), "logic:syncPostVoteNum: indexPostDoc")
+}
+
+// indexPostDoc 将帖子写入所有启用的搜索引擎，force 为 false 时，文档与上次写入的内容相同则跳过
+// 只有所有引擎都写入成功才会记录 hash，失败的引擎会在下次写入时重试
+func indexPostDoc(doc *models.PostDoc, force bool) error {
+	hash := postDocHash(doc)
+	if !force {
+		oldHash, err := redis.GetPostSearchHash(doc.PostID)
+		if err != nil {
+			logger.Warnf("logic:indexPostDoc: GetPostSearchHash failed, reason: %v", err.Error())
+		} else if oldHash == hash {
+			return nil
+		}
+	}
+
+	if err := search.IndexPost(doc); err != nil {
+		return errors.Wrap(err, "logic:indexPostDoc: IndexPost")
+	}
+	if err := redis.SetPostSearchHash(doc.PostID, hash); err != nil {
+		logger.Warnf("logic:indexPostDoc: SetPostSearchHash failed, reason: %v", err.Error())
+	}
+	return nil
+}
+
+// 计算文档中被索引的内容的 hash（不包含创建时间）
+func postDocHash(doc *models.PostDoc) string {
+	tmp := *doc
+	tmp.CreatedAt = nil
+	data, _ := json.Marshal(&tmp)
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// 为社区分面补充社区名称，搜索结果可能被 singleflight 共享，因此返回副本
+func withCommunityFacetNames(facets *models.PostFacetsDTO) (*models.PostFacetsDTO, error) {
+	if facets == nil || len(facets.Communities) == 0 {
+		return facets, nil
+	}
+	communities, err := GetCommunityList()
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:withCommunityFacetNames: GetCommunityList")
+	}
+	names := make(map[string]string, len(communities))
+	for _, community := range communities {
+		names[strconv.FormatInt(community.CommunityID, 10)] = community.CommunityName
+	}
+
+	tmp := *facets
+	tmp.Communities = make([]models.FacetBucketDTO, len(facets.Communities))
+	for i, bucket := range facets.Communities {
+		bucket.Name = names[bucket.Key]
+		tmp.Communities[i] = bucket
+	}
+	return &tmp, nil
+}
+
+// GetPostSuggestions 根据已输入的前缀补全帖子标题，并返回近期以该前缀开头的热门搜索词
+func GetPostSuggestions(params *models.ParamPostSuggest) (*models.PostSuggestDTO, error) {
+	prefix := normalizeSearchTerm(params.Prefix)
+	if prefix == "" {
+		return &models.PostSuggestDTO{Titles: make([]models.PostTitleSuggestionDTO, 0), Queries: make([]string, 0)}, nil
+	}
+
+	sfkey := fmt.Sprintf("%v_%v", prefix, params.Size)
+	timeout := time.Second * time.Duration(viper.GetInt("service.timeout"))
+	interval := time.Second / time.Duration(viper.GetInt("service.rps"))
+	ret, err := utils.SfDoWithTimeout(&postSuggestGrp, sfkey, timeout, interval, func() (any, error) {
+		titles, err := search.SuggestPostTitles(prefix, params.Size)
+		if err != nil {
+			return nil, errors.Wrap(err, "SuggestPostTitles")
+		}
+		queries, err := redis.GetPopularSearchTerms(recentSearchTermDays(time.Now()), prefix, params.Size)
+		if err != nil {
+			return nil, errors.Wrap(err, "GetPopularSearchTerms")
+		}
+		return &models.PostSuggestDTO{Titles: titles, Queries: queries}, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:GetPostSuggestions: SfDoWithTimeout")
+	}
+	return ret.(*models.PostSuggestDTO), nil
+}
+
+// 记录一次搜索，用于统计热门搜索词
+func recordSearchTerm(keyword string) {
+	term := normalizeSearchTerm(keyword)
+	if term == "" || utf8.RuneCountInString(term) > maxSearchTermLen {
+		return
+	}
+	days := viper.GetInt("search.suggest.term_days")
+	err := redis.IncrSearchTerm(term, time.Now(), time.Duration(days)*24*time.Hour, viper.GetInt64("search.suggest.max_terms"))
+	if err != nil {
+		logger.Warnf("logic:recordSearchTerm: IncrSearchTerm failed, reason: %v", err.Error())
+	}
+}
+
+// 去除首尾空白、合并连续空白并转小写，避免同一个搜索词被分开统计
+func normalizeSearchTerm(keyword string) string {
+	return strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+}
+
+// 统计热门搜索词的日期，包含今天
+func recentSearchTermDays(now time.Time) []time.Time {
+	days := make([]time.Time, viper.GetInt("search.suggest.term_days"))
+	for i := range days {
+		days[i] = now.AddDate(0, 0, -i)
+	}
+	return days
+}
+
+// 将用户写入搜索引擎，失败只记录日志，不影响主流程
+func indexUser(userID int64, userName, intro string) {
+	doc := &models.UserDoc{UserID: userID, UserName: userName, Intro: intro}
+	if err := search.IndexUser(doc); err != nil {
+		logger.Warnf("logic:indexUser: IndexUser failed, reason: %v", err.Error())
+	}
+}
+
+// 将社区写入搜索引擎，失败只记录日志，不影响主流程
+func indexCommunity(communityID int64, communityName, introduction string) {
+	doc := &models.CommunityDoc{CommunityID: communityID, CommunityName: communityName, Introduction: introduction}
+	if err := search.IndexCommunity(doc); err != nil {
+		logger.Warnf("logic:indexCommunity: IndexCommunity failed, reason: %v", err.Error())
+	}
+}
+
+// SearchUsers 根据关键字搜索用户（用户名、简介），按相关性排序
+func SearchUsers(params *models.ParamSearch) (*models.UserSearchListDTO, error) {
+	userIDs, total, err := queryIDsWithSf(params, search.QueryUserIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchUsers: QueryUserIDs")
+	}
+	users, err := mysql.SelectUsersByUserIDs(userIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchUsers: SelectUsersByUserIDs")
+	}
+
+	// 按搜索结果的顺序返回，已不存在的用户直接跳过
+	userMap := make(map[int64]*models.User, len(users))
+	for _, user := range users {
+		userMap[user.UserID] = user
+	}
+	list := make([]*models.UserSearchDTO, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if user, ok := userMap[userID]; ok {
+			list = append(list, &models.UserSearchDTO{
+				UserID:   user.UserID,
+				UserName: user.UserName,
+				Avatar:   user.Avatar,
+				Intro:    user.Intro,
+			})
+		}
+	}
+	return &models.UserSearchListDTO{Total: total, Users: list}, nil
+}
+
+// SearchCommunities 根据关键字搜索社区（名称、简介），按相关性排序
+func SearchCommunities(params *models.ParamSearch) (*models.CommunitySearchListDTO, error) {
+	communityIDs, total, err := queryIDsWithSf(params, search.QueryCommunityIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchCommunities: QueryCommunityIDs")
+	}
+	communities, err := mysql.SelectCommunityListByIDs(communityIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:SearchCommunities: SelectCommunityListByIDs")
+	}
+
+	communityMap := make(map[int64]models.CommunityDTO, len(communities))
+	for _, community := range communities {
+		communityMap[community.CommunityID] = community
+	}
+	list := make([]models.CommunityDTO, 0, len(communityIDs))
+	for _, communityID := range communityIDs {
+		if community, ok := communityMap[communityID]; ok {
+			list = append(list, community)
+		}
+	}
+	return &models.CommunitySearchListDTO{Total: total, Communities: list}, nil
+}
+
+type idSearchResult struct {
+	IDs   []int64
+	Total int
+}
+
+// 使用 singleflight 合并相同的搜索请求，返回的 id 按相关性排序
+func queryIDsWithSf(params *models.ParamSearch, query func(params *models.ParamSearch) ([]string, int, error)) ([]int64, int, error) {
+	sfkey := fmt.Sprintf("%v_%v_%v_%v", params.Type, params.Keyword, params.PageNum, params.PageSize)
+	timeout := time.Second * time.Duration(viper.GetInt("service.timeout"))
+	interval := time.Second / time.Duration(viper.GetInt("service.rps"))
+	ret, err := utils.SfDoWithTimeout(&searchGrp, sfkey, timeout, interval, func() (any, error) {
+		idStrs, total, err := query(params)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, 0, len(idStrs))
+		for _, idStr := range idStrs {
+			if id, err := strconv.ParseInt(idStr, 10, 64); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		return &idSearchResult{IDs: ids, Total: total}, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	res := ret.(*idSearchResult)
+	return res.IDs, res.Total, nil
+}
@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"bluebell/dao/kafka"
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/logger"
+	"bluebell/models"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+/*
+	用户统计
+
+	user_stats 由 CreatePost、RemovePost、VoteForPost，以及 kafka 的 comment、like 消费者增量维护，
+	只更新已经存在的记录；不存在的记录在第一次查询时统计全量数据并创建
+
+	初始化时先在事务中插入空记录占住这一行，统计完成后写入并提交。统计期间的递增会等待事务提交后
+	再执行，不会丢失；但统计时已经能读到、递增却在等待的变更（如 CreatePost 先写帖子后递增）会被多算一次
+*/
+
+func getUserStat(user *models.User) (*models.UserStatDTO, error) {
+	stat, err := mysql.SelectUserStat(user.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:getUserStat: SelectUserStat")
+	}
+	if stat == nil {
+		if stat, err = initUserStat(user.UserID); err != nil {
+			return nil, errors.Wrap(err, "logic:getUserStat: initUserStat")
+		}
+	}
+
+	return &models.UserStatDTO{
+		PostCount:        stat.PostCount,
+		VoteCount:        stat.VoteCount,
+		CommentCount:     stat.CommentCount,
+		CommentLikeCount: stat.CommentLikeCount,
+		JoinedAt:         user.CreatedAt,
+	}, nil
+}
+
+// 创建 user_stats 记录并写入全量统计，只在记录不存在时执行一次
+func initUserStat(userID int64) (*models.UserStat, error) {
+	tx := mysql.GetDB().Begin()
+	created, err := mysql.CreateUserStat(tx, &models.UserStat{UserID: userID})
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Wrap(err, "logic:initUserStat: CreateUserStat")
+	}
+	// 其他请求已经初始化完成
+	if !created {
+		tx.Rollback()
+		stat, err := mysql.SelectUserStat(userID)
+		if err == nil && stat == nil {
+			err = errors.New("user stat not found")
+		}
+		return stat, errors.Wrap(err, "logic:initUserStat: SelectUserStat")
+	}
+
+	stat, err := countUserStat(userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Wrap(err, "logic:initUserStat: countUserStat")
+	}
+	if err := mysql.UpdateUserStat(tx, stat); err != nil {
+		tx.Rollback()
+		return nil, errors.Wrap(err, "logic:initUserStat: UpdateUserStat")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, errors.Wrap(err, "logic:initUserStat: Commit")
+	}
+	return stat, nil
+}
+
+// 统计全量数据
+func countUserStat(userID int64) (*models.UserStat, error) {
+	stat := &models.UserStat{UserID: userID}
+
+	posts, err := mysql.SelectAllPostsByAuthorID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:countUserStat: SelectAllPostsByAuthorID")
+	}
+	stat.PostCount = int64(len(posts))
+
+	// 赞成票：未过期的帖子在 redis 中，过期的帖子在 expired_post_scores 中
+	activeIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		if post.Status == 0 {
+			activeIDs = append(activeIDs, strconv.FormatInt(post.PostID, 10))
+		}
+	}
+	if len(activeIDs) != 0 {
+		nums, err := redis.GetPostUpVoteNums(activeIDs)
+		if err != nil {
+			return nil, errors.Wrap(err, "logic:countUserStat: GetPostUpVoteNums")
+		}
+		for _, num := range nums {
+			stat.VoteCount += num
+		}
+	}
+	expiredVoteCount, err := mysql.SelectExpiredPostUpVoteSumByAuthorID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:countUserStat: SelectExpiredPostUpVoteSumByAuthorID")
+	}
+	stat.VoteCount += expiredVoteCount
+
+	stat.CommentCount, stat.CommentLikeCount, err = mysql.SelectCommentStatByUserID(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:countUserStat: SelectCommentStatByUserID")
+	}
+	return stat, nil
+}
+
+// 帖子收到的赞成票发生变化，递增作者的 vote_count
+func incrPostAuthorVoteCount(postID int64, offset int64) {
+	post, err := GetPostDetailByID(postID, false)
+	if err != nil {
+		logger.Warnf("logic:incrPostAuthorVoteCount: GetPostDetailByID, reason: %v", err.Error())
+		return
+	}
+	if err := kafka.IncrUserStatField(post.UserID, models.UserStatVoteCount, offset); err != nil {
+		logger.Warnf("logic:incrPostAuthorVoteCount: IncrUserStatField, reason: %v", err.Error())
+	}
+}
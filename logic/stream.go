@@ -0,0 +1,127 @@
+package logic
+
+import (
+	"bluebell/dao/redis"
+	bluebell "bluebell/errors"
+	"bluebell/logger"
+	"bluebell/models"
+	"encoding/json"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+/*
+	实时推送
+
+	kafka 消费者产生事件后，通过 redis pub/sub 广播到所有实例，
+	每个实例的 streamHub 再分发给本实例上建立了连接的客户端
+*/
+
+type streamClient struct {
+	userID  int64
+	postIDs map[int64]struct{} // 订阅的帖子
+	ch      chan *models.StreamEvent
+}
+
+type streamHub struct {
+	mu          sync.RWMutex
+	clients     map[*streamClient]struct{}
+	closed      bool
+	pubsubClose func() error
+}
+
+var hub = &streamHub{clients: make(map[*streamClient]struct{})}
+
+// 订阅 redis，开始分发事件
+func InitStream() {
+	pubsub := redis.SubscribeStreamEvents()
+	go func() {
+		for msg := range pubsub.Channel() {
+			event := new(models.StreamEvent)
+			if err := json.Unmarshal([]byte(msg.Payload), event); err != nil {
+				logger.Warnf("logic:InitStream: Unmarshal, reason: %v", err.Error())
+				continue
+			}
+			hub.dispatch(event)
+		}
+	}()
+	hub.pubsubClose = pubsub.Close
+}
+
+// 服务器关闭时调用，断开所有连接，否则 http.Server.Shutdown 会一直等待
+func StopStream() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.closed {
+		return
+	}
+	hub.closed = true
+	if hub.pubsubClose != nil {
+		if err := hub.pubsubClose(); err != nil {
+			logger.Warnf("logic:StopStream: close pubsub, reason: %v", err.Error())
+		}
+	}
+	for client := range hub.clients {
+		close(client.ch)
+	}
+	hub.clients = make(map[*streamClient]struct{})
+}
+
+// 建立连接，返回事件 channel，以及断开连接时调用的函数
+func SubscribeStream(userID int64, postIDs []int64) (<-chan *models.StreamEvent, func(), error) {
+	client := &streamClient{
+		userID:  userID,
+		postIDs: make(map[int64]struct{}, len(postIDs)),
+		ch:      make(chan *models.StreamEvent, viper.GetInt("service.stream.buffer_size")),
+	}
+	for _, postID := range postIDs {
+		client.postIDs[postID] = struct{}{}
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if hub.closed {
+		return nil, nil, bluebell.ErrServerClosing
+	}
+	hub.clients[client] = struct{}{}
+
+	unsubscribe := func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		if _, ok := hub.clients[client]; ok {
+			delete(hub.clients, client)
+			close(client.ch)
+		}
+	}
+	return client.ch, unsubscribe, nil
+}
+
+func (h *streamHub) dispatch(event *models.StreamEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if !client.match(event) {
+			continue
+		}
+		// 客户端消费过慢，丢弃事件，避免阻塞其它客户端
+		select {
+		case client.ch <- event:
+		default:
+			logger.Warnf("logic:dispatch: stream buffer of user %v is full, drop event", client.userID)
+		}
+	}
+}
+
+func (c *streamClient) match(event *models.StreamEvent) bool {
+	switch event.Type {
+	case models.StreamEventNotification, models.StreamEventDirectMessage:
+		return event.UserID == c.userID
+	case models.StreamEventComment:
+		_, ok := c.postIDs[event.PostID]
+		return ok
+	}
+	return false
+}
@@ -39,7 +39,12 @@ func RefreshToken(refresh_token, access_token string) (string, error) {
 			return "", bluebell.ErrExpiredToken // refresh_token 不存在或者过期
 		}
 
-		return utils.GenToken(usrClaims.UserID, utils.AccessType)
+		// 重新查询角色，角色的变更在刷新 access_token 后生效
+		role, err := GetUserRoleInfo(usrClaims.UserID)
+		if err != nil {
+			return "", errors.Wrap(err, "logic:RefreshToken: GetUserRoleInfo")
+		}
+		return utils.GenToken(usrClaims.UserID, role, utils.AccessType)
 	}
 
 	return "", nil // 不需要更新
@@ -0,0 +1,244 @@
+package logic
+
+import (
+	"bluebell/dao/mysql"
+	"bluebell/dao/redis"
+	"bluebell/internal/utils"
+	"bluebell/logger"
+	"bluebell/models"
+	"strings"
+	"time"
+
+	bluebell "bluebell/errors"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+/*
+	TOTP 两步验证
+*/
+
+// 生成（覆盖）未确认的密钥，返回密钥与 otpauth URI
+func TwoFactorEnroll(userID int64) (string, string, error) {
+	enabled, err := mysql.SelectUserTOTPEnabled(userID)
+	if err != nil {
+		return "", "", errors.Wrap(err, "logic:TwoFactorEnroll: SelectUserTOTPEnabled")
+	}
+	if enabled {
+		return "", "", bluebell.ErrTwoFactorEnabled
+	}
+
+	usr, err := mysql.SelectUserByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", bluebell.ErrUserNotExist
+		}
+		return "", "", errors.Wrap(err, "logic:TwoFactorEnroll: SelectUserByUserID")
+	}
+
+	secret, err := utils.GenTOTPSecret()
+	if err != nil {
+		return "", "", errors.Wrap(err, "logic:TwoFactorEnroll: GenTOTPSecret")
+	}
+	if err := mysql.SaveUserTOTPSecret(userID, secret); err != nil {
+		return "", "", errors.Wrap(err, "logic:TwoFactorEnroll: SaveUserTOTPSecret")
+	}
+
+	uri := utils.GenTOTPURI(viper.GetString("service.two_factor.issuer"), usr.UserName, secret)
+	return secret, uri, nil
+}
+
+// 使用第一个 code 确认绑定，返回恢复码（明文只返回这一次）
+func TwoFactorConfirm(userID int64, code string) ([]string, error) {
+	totp, err := mysql.SelectUserTOTPByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, bluebell.ErrTwoFactorNotEnabled
+		}
+		return nil, errors.Wrap(err, "logic:TwoFactorConfirm: SelectUserTOTPByUserID")
+	}
+	if totp.Enabled {
+		return nil, bluebell.ErrTwoFactorEnabled
+	}
+	ok, err := checkTOTPCode(userID, totp.Secret, code)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:TwoFactorConfirm: checkTOTPCode")
+	}
+	if !ok {
+		return nil, bluebell.ErrInvalidVerificationCode
+	}
+
+	codes, hashes, err := genRecoveryCodesHelper()
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:TwoFactorConfirm: genRecoveryCodesHelper")
+	}
+
+	err = mysql.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := mysql.EnableUserTOTP(tx, userID); err != nil {
+			return err
+		}
+		return mysql.CreateUserRecoveryCodes(tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:TwoFactorConfirm: Transaction")
+	}
+
+	return codes, nil
+}
+
+// 关闭两步验证，需要提供 TOTP code 或恢复码
+func TwoFactorDisable(userID int64, code string) error {
+	totp, err := mysql.SelectUserTOTPByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bluebell.ErrTwoFactorNotEnabled
+		}
+		return errors.Wrap(err, "logic:TwoFactorDisable: SelectUserTOTPByUserID")
+	}
+	if !totp.Enabled {
+		return bluebell.ErrTwoFactorNotEnabled
+	}
+	if err := verifyTwoFactorCode(totp, code); err != nil {
+		return err
+	}
+
+	err = mysql.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := mysql.DeleteUserTOTP(tx, userID); err != nil {
+			return err
+		}
+		return mysql.DeleteUserRecoveryCodes(tx, userID)
+	})
+	return errors.Wrap(err, "logic:TwoFactorDisable: Transaction")
+}
+
+// 登录的第二步：校验 challenge_token 与 code（TOTP code 或恢复码），通过后发放 token
+func UserLoginTwoFactor(params *models.ParamUserLoginTwoFactor, ip string) (*models.User, string, string, error) {
+	if err := checkLoginLocked(loginIPTarget(ip)); err != nil {
+		return nil, "", "", err
+	}
+
+	userID, err := redis.GetTwoFactorChallenge(params.ChallengeToken)
+	if err != nil {
+		return nil, "", "", errors.Wrap(err, "logic:UserLoginTwoFactor: GetTwoFactorChallenge")
+	}
+	if userID == 0 {
+		return nil, "", "", bluebell.ErrInvalidToken
+	}
+	if err := checkLoginLocked(loginAccountTarget(userID)); err != nil {
+		return nil, "", "", err
+	}
+
+	usr, err := mysql.SelectUserByUserID(userID)
+	if err != nil {
+		return nil, "", "", errors.Wrap(err, "logic:UserLoginTwoFactor: SelectUserByUserID")
+	}
+	totp, err := mysql.SelectUserTOTPByUserID(userID)
+	if err != nil {
+		return nil, "", "", errors.Wrap(err, "logic:UserLoginTwoFactor: SelectUserTOTPByUserID")
+	}
+
+	if err := verifyTwoFactorCode(totp, params.Code); err != nil {
+		if errors.Is(err, bluebell.ErrInvalidVerificationCode) { // 与密码错误一样计入失败次数
+			recordLoginIPFailure(ip)
+			recordLoginAccountFailure(usr)
+		}
+		return nil, "", "", err
+	}
+
+	// challenge 只能使用一次
+	if err := redis.DelTwoFactorChallenge(params.ChallengeToken); err != nil {
+		logger.ErrorWithStack(err)
+	}
+	if err := redis.ClearLoginFailCount(loginAccountTarget(userID)); err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	access_token, refresh_token, err := genTokenHelper(userID)
+	return usr, access_token, refresh_token, errors.Wrap(err, "logic:UserLoginTwoFactor: genTokenHelper")
+}
+
+// 密码校验通过后调用：开启了两步验证，返回 challenge_token；否则直接发放 token
+func loginHelper(userID int64) (access_token, refresh_token, challenge_token string, err error) {
+	enabled, err := mysql.SelectUserTOTPEnabled(userID)
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "logic:loginHelper: SelectUserTOTPEnabled")
+	}
+
+	if !enabled {
+		access_token, refresh_token, err = genTokenHelper(userID)
+		return access_token, refresh_token, "", errors.Wrap(err, "logic:loginHelper: genTokenHelper")
+	}
+
+	challenge_token, err = utils.GenRandomToken(32)
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "logic:loginHelper: GenRandomToken")
+	}
+	expireDuration := time.Second * time.Duration(viper.GetInt64("service.two_factor.challenge_expire_time"))
+	if err := redis.SetTwoFactorChallenge(challenge_token, userID, expireDuration); err != nil {
+		return "", "", "", errors.Wrap(err, "logic:loginHelper: SetTwoFactorChallenge")
+	}
+	return "", "", challenge_token, nil
+}
+
+// 校验 TOTP code 或恢复码
+func verifyTwoFactorCode(totp *models.UserTOTP, code string) error {
+	code = strings.TrimSpace(code)
+	ok, err := checkTOTPCode(totp.UserID, totp.Secret, code)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+
+	// 尝试作为恢复码校验
+	codes, err := mysql.SelectUnusedRecoveryCodesByUserID(totp.UserID)
+	if err != nil {
+		return errors.Wrap(err, "logic:verifyTwoFactorCode: SelectUnusedRecoveryCodesByUserID")
+	}
+	code = strings.ToLower(code)
+	for _, c := range codes {
+		if bcrypt.CompareHashAndPassword([]byte(c.CodeHash), []byte(code)) != nil {
+			continue
+		}
+		used, err := mysql.UpdateRecoveryCodeUsed(c.ID)
+		if err != nil {
+			return errors.Wrap(err, "logic:verifyTwoFactorCode: UpdateRecoveryCodeUsed")
+		}
+		if used {
+			return nil
+		}
+		break // 被并发使用了
+	}
+	return bluebell.ErrInvalidVerificationCode
+}
+
+// 校验 TOTP code，同一个 code 只能使用一次
+func checkTOTPCode(userID int64, secret, code string) (bool, error) {
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	ok, err := redis.MarkTOTPStepUsed(userID, step, 3*time.Minute) // 覆盖允许偏移的时间窗口即可
+	return ok, errors.Wrap(err, "logic:checkTOTPCode: MarkTOTPStepUsed")
+}
+
+// 生成恢复码，返回明文与哈希
+func genRecoveryCodesHelper() ([]string, []string, error) {
+	codes, err := utils.GenRecoveryCodes(viper.GetInt("service.two_factor.recovery_code_num"))
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "logic:genRecoveryCodesHelper: GenerateFromPassword")
+		}
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
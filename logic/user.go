@@ -4,7 +4,9 @@ import (
 	"bluebell/dao/mysql"
 	"bluebell/dao/redis"
 	"bluebell/internal/utils"
+	"bluebell/logger"
 	"bluebell/models"
+	"strings"
 
 	bluebell "bluebell/errors"
 
@@ -46,33 +48,56 @@ func UserRegist(usr *models.User) (string, string, error) {
 	if err := mysql.InsertUser(usr); err != nil {
 		return "", "", errors.Wrap(err, "logic:UserLogin: InsertUser")
 	}
+	indexUser(usr.UserID, usr.UserName, usr.Intro)
 
 	return genTokenHelper(usr.UserID)
 }
 
-func UserLogin(params *models.ParamUserLogin) (*models.User, string, string, error) {
+// 支持使用用户名或邮箱登录，ip 用于登录失败计数
+//
+// 开启了两步验证的用户，不会返回 access_token、refresh_token，而是返回 challenge_token
+func UserLogin(params *models.ParamUserLogin, ip string) (*models.User, string, string, string, error) {
+	// 该 IP 是否被锁定
+	if err := checkLoginLocked(loginIPTarget(ip)); err != nil {
+		return nil, "", "", "", err
+	}
+
 	// 判断用户是否存在
-	exist, _, err := checkUserIfExist(params.Username, true)
+	isUserName := !strings.Contains(params.Username, "@")
+	exist, userID, err := checkUserIfExist(params.Username, isUserName)
 	if err != nil {
-		return nil, "", "", errors.Wrap(err, "logic:UserLogin: checkUserIfExist")
+		return nil, "", "", "", errors.Wrap(err, "logic:UserLogin: checkUserIfExist")
 	}
 	if !exist {
-		return nil, "", "", bluebell.ErrUserNotExist
+		recordLoginIPFailure(ip)
+		return nil, "", "", "", bluebell.ErrUserNotExist
+	}
+
+	// 该账户是否被锁定
+	if err := checkLoginLocked(loginAccountTarget(userID)); err != nil {
+		return nil, "", "", "", err
 	}
 
 	// 查询、解析密码
-	_usr, err := mysql.SelectUserByName(params.Username)
+	_usr, err := mysql.SelectUserByUserID(userID)
 	if err != nil {
-		return nil, "", "", err
+		return nil, "", "", "", err
 	}
 
 	// 验证密码一致性
 	if err := bcrypt.CompareHashAndPassword([]byte(_usr.Password), []byte(params.Password)); err != nil {
-		return nil, "", "", bluebell.ErrWrongPassword
+		recordLoginIPFailure(ip)
+		recordLoginAccountFailure(_usr)
+		return nil, "", "", "", bluebell.ErrWrongPassword
 	}
 
-	access_token, refresh_token, err := genTokenHelper(_usr.UserID)
-	return _usr, access_token, refresh_token, errors.Wrap(err, "logic:UserLogin: genTokenHelper")
+	// 登录成功，清空账户的失败计数
+	if err := redis.ClearLoginFailCount(loginAccountTarget(userID)); err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	access_token, refresh_token, challenge_token, err := loginHelper(_usr.UserID)
+	return _usr, access_token, refresh_token, challenge_token, errors.Wrap(err, "logic:UserLogin: loginHelper")
 }
 
 func UserUpdate(userID int64, params models.ParamUserUpdate) error {
@@ -84,8 +109,11 @@ func UserUpdate(userID int64, params models.ParamUserUpdate) error {
 		return bluebell.ErrUserExist
 	}
 
-	err = mysql.UpdateUserInfo(userID, params)
-	return errors.Wrap(err, "logic:UserUpdate: UpdateUserInfo")
+	if err = mysql.UpdateUserInfo(userID, params); err != nil {
+		return errors.Wrap(err, "logic:UserUpdate: UpdateUserInfo")
+	}
+	indexUser(userID, params.Username, params.Intro)
+	return nil
 }
 
 func UserGetInfo(userID int64) (*models.UserDTO, error) {
@@ -96,22 +124,44 @@ func UserGetInfo(userID int64) (*models.UserDTO, error) {
 		}
 		return nil, errors.Wrap(err, "logic:UserGetInfo: SelectUserByUserID")
 	}
+	followerCount, followingCount, err := getFollowCount(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:UserGetInfo: getFollowCount")
+	}
+	stat, err := getUserStat(user)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:UserGetInfo: getUserStat")
+	}
+	karma, err := GetUserKarma(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "logic:UserGetInfo: GetUserKarma")
+	}
 
 	return &models.UserDTO{
-		UserID:   userID,
-		UserName: user.UserName,
-		Email:    user.Email,
-		Gender:   user.Gender,
-		Avatar:   user.Avatar,
-		Intro:    user.Intro,
+		UserID:         userID,
+		UserName:       user.UserName,
+		Email:          user.Email,
+		Gender:         user.Gender,
+		Avatar:         user.Avatar,
+		Intro:          user.Intro,
+		FollowerCount:  followerCount,
+		FollowingCount: followingCount,
+		Stat:           stat,
+		Karma:          karma,
 	}, nil
 }
 
 // 刷新 access_token、refresh_token 并返回
 func genTokenHelper(UserID int64) (string, string, error) {
+	// 查询角色，携带在 access_token 中
+	role, err := GetUserRoleInfo(UserID)
+	if err != nil {
+		return "", "", errors.Wrap(err, "logic:genTokenHelper: GetUserRoleInfo")
+	}
+
 	// 生成 access_token
-	access_token, err0 := utils.GenToken(UserID, utils.AccessType)
-	refresh_token, err1 := utils.GenToken(0, utils.RefreshType)
+	access_token, err0 := utils.GenToken(UserID, role, utils.AccessType)
+	refresh_token, err1 := utils.GenToken(0, models.RoleInfo{}, utils.RefreshType)
 	if err0 != nil || err1 != nil {
 		return "", "", bluebell.ErrGenToken
 	}
@@ -7,10 +7,13 @@ import (
 	"bluebell/dao/kafka"
 	"bluebell/dao/localcache"
 	"bluebell/dao/mysql"
+	"bluebell/dao/oauth"
 	"bluebell/dao/qiniu"
 	"bluebell/dao/redis"
+	"bluebell/dao/search"
 	"bluebell/internal/utils"
 	"bluebell/logger"
+	"bluebell/logic"
 	"bluebell/router"
 	"bluebell/settings"
 	"bluebell/workers"
@@ -42,15 +45,15 @@ func init() {
 	redis.InitRedis()
 	logger.Infof("Initializing Redis successfully")
 
-	if viper.GetBool("elasticsearch.enable") {
-		elasticsearch.Init()
-		logger.Infof("Initializing Elasticsearch successfully")
-	}
-	if viper.GetBool("bleve.enable") {
-		bleve.InitBleve()
-		logger.Infof("Initializing Bleve successfully")
+	initSearchEngines()
+
+	if flag.NArg() > 0 { // 运行子命令，不需要启动服务
+		return
 	}
 
+	logic.InitAdmins()
+	logic.InitStream()
+
 	kafka.InitKafka()
 	logger.Infof("Initializing Kafka successfully")
 
@@ -63,12 +66,29 @@ func init() {
 	email.InitEmail()
 	logger.Infof("Initializing Email Service successfully")
 
+	if viper.GetBool("oauth.enable") {
+		oauth.InitOAuth()
+		logger.Infof("Initializing OAuth providers successfully")
+	}
+
 	router.Init()
 	logger.Infof("Initializing router successfully")
 
 	workers.InitWorkers() // 后台任务
 }
 
+func initSearchEngines() {
+	if viper.GetBool("elasticsearch.enable") {
+		elasticsearch.Init()
+		logger.Infof("Initializing Elasticsearch successfully")
+	}
+	if viper.GetBool("bleve.enable") {
+		bleve.InitBleve()
+		logger.Infof("Initializing Bleve successfully")
+	}
+	search.Init()
+}
+
 //	@title			Blue-Bell 接口文档
 //	@version		1.0
 //	@description	包含了 Blue-Bell 项目提供的接口
@@ -84,7 +104,12 @@ func init() {
 // @host		127.0.0.1:1145
 // @BasePath	/api/v1
 func main() {
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	srv := router.GetServer()
+	srv.RegisterOnShutdown(logic.StopStream) // 断开实时推送的长连接
 
 	idleConnsClosed := make(chan interface{})
 	go func() {
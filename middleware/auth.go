@@ -36,7 +36,7 @@ func Auth() gin.HandlerFunc {
 		}
 
 		// 检验 token
-		UserID, err := utils.ParseToken(parts[1])
+		claims, err := utils.ParseTokenClaims(parts[1])
 		if err != nil {
 			if errors.Is(err, bluebell.ErrInvalidToken) {
 				controller.ResponseError(ctx, controller.CodeInvalidToken)
@@ -49,8 +49,40 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		ctx.Set("user_id", UserID)
+		ctx.Set("user_id", claims.UserID)
+		ctx.Set("role", claims.RoleInfo)
 		ctx.Set("access_token", parts[1]) // 用于后续限制一个用户登录
 		ctx.Next()
 	}
 }
+
+// 可选的认证中间件，用于游客也可以访问的接口
+//
+// 携带了合法的 access_token 时，与 Auth 一样设置 user_id、role，否则作为游客继续处理
+func TryAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parts := strings.Split(ctx.Request.Header.Get("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := utils.ParseTokenClaims(parts[1]); err == nil {
+				ctx.Set("user_id", claims.UserID)
+				ctx.Set("role", claims.RoleInfo)
+				ctx.Set("access_token", parts[1])
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// 浏览器的 EventSource 无法设置请求头，允许通过 query 参数 token 传递 access_token
+//
+// 需要放在 Auth 之前
+func QueryToken() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Header.Get("Authorization") == "" {
+			if token := ctx.Query("token"); token != "" {
+				ctx.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		ctx.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	controller "bluebell/controller/Common"
 	"time"
 
+	"github.com/bluele/gcache"
 	"github.com/gin-gonic/gin"
 	"github.com/juju/ratelimit"
 	ratelimit2 "go.uber.org/ratelimit"
@@ -28,6 +29,28 @@ func RateLimit(rate float64, capacity int64) gin.HandlerFunc {
 	}
 }
 
+// 按客户端 IP 限流，每个 IP 一个令牌桶
+//
+// rate：每秒生成的令牌数
+//
+// capacity：令牌桶大小
+//
+// size：最多记录的 IP 数，超过后淘汰最久未访问的 IP
+func RateLimitByIP(rate float64, capacity int64, size int) gin.HandlerFunc {
+	buckets := gcache.New(size).LRU().LoaderFunc(func(key interface{}) (interface{}, error) {
+		return ratelimit.NewBucketWithRate(rate, capacity), nil
+	}).Build()
+	return func(ctx *gin.Context) {
+		b, err := buckets.Get(ctx.ClientIP())
+		if err == nil && b.(*ratelimit.Bucket).TakeAvailable(1) != 1 {
+			controller.ResponseError(ctx, controller.CodeServerBusy)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
 func RateLimit2(rate int) gin.HandlerFunc {
 	bucket := ratelimit2.New(rate) // 每秒产生 rate 个水滴，也就是最多允许 rate 个请求
 	return func(ctx *gin.Context) {
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	controller "bluebell/controller/Common"
+	"bluebell/models"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 角色校验中间件，需要放在 Auth 之后
+//
+// 当前用户的角色不在 roles 中时，拒绝请求
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, _ := ctx.Get("role")
+		role, _ := value.(models.RoleInfo)
+		if !slices.Contains(roles, role.Role) {
+			controller.ResponseError(ctx, controller.CodeForbidden)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// 拉黑关系，UserID 拉黑了 BlockedID，(user_id, blocked_id) 唯一
+type UserBlock struct {
+	ID        int64 `gorm:"type:bigint;auto_increment"`
+	UserID    int64 `gorm:"type:bigint;not null"`
+	BlockedID int64 `gorm:"type:bigint;not null;index"`
+	CreatedAt Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// 收藏的帖子，(user_id, post_id) 唯一
+type UserBookmark struct {
+	ID        int64  `gorm:"type:bigint;auto_increment"`
+	UserID    int64  `gorm:"type:bigint;not null"`
+	PostID    int64  `gorm:"type:bigint;not null;index"`
+	Folder    string `gorm:"type:varchar(64);not null;default:''"` // 收藏夹，为空表示默认收藏夹
+	CreatedAt Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+type BookmarkListDTO struct {
+	Posts      []*PostDTO `json:"posts"`
+	NextCursor string     `json:"next_cursor"` // 为空表示没有更多数据
+}
@@ -110,3 +110,21 @@ type CommentListDTO struct {
 type CommentContentDTO struct {
 	Message string `json:"message"`
 }
+
+// 评论在搜索引擎中的文档
+type CommentDoc struct {
+	CommentID int64  `json:"comment_id"`
+	ObjID     string `json:"obj_id"` // 以字符串形式索引，避免雪花 id 转换为浮点数后丢失精度
+	Message   string `json:"message"`
+	CreatedAt any    `json:"created_time"`
+}
+
+type CommentSearchDTO struct {
+	CommentDTO
+	PostTitle string `json:"post_title"` // 评论所在帖子的标题
+}
+
+type CommentSearchListDTO struct {
+	Total    int                `json:"total"`
+	Comments []CommentSearchDTO `json:"comments"`
+}
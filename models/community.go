@@ -5,6 +5,7 @@ type Community struct {
 	CommunityID   int64  `gorm:"type:bigint;not null;unique" json:"community_id"`
 	CommunityName string `gorm:"type:varchar(64);not null;unique" json:"community_name" binding:"required"`
 	Introduction  string `gorm:"type:varchar(256);not null" json:"introduction"`
+	MemberCount   int    `gorm:"type:int;not null;default:0" json:"member_count"`
 	CreatedAt     Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt     Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP" json:"update_at"`
 }
@@ -13,4 +14,13 @@ type CommunityDTO struct {
 	CommunityID   int64  `json:"community_id"`
 	CommunityName string `json:"community_name" binding:"required"`
 	Introduction  string `json:"introduction,omitempty"` // 字段为空则不参与 json 序列化
+	MemberCount   int    `json:"member_count"`
+}
+
+// 社区成员，(user_id, community_id) 唯一
+type CommunityMember struct {
+	ID          int64 `gorm:"type:bigint;auto_increment"`
+	UserID      int64 `gorm:"type:bigint;not null"`
+	CommunityID int64 `gorm:"type:bigint;not null;index"`
+	CreatedAt   Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
 }
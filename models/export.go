@@ -0,0 +1,23 @@
+package models
+
+// 用户数据导出
+type UserExport struct {
+	Profile    *UserDTO                 `json:"profile"`
+	Posts      []*Post                  `json:"posts"`
+	Comments   []CommentExport          `json:"comments"`
+	Likes      []CommentUserLikeMapping `json:"likes"`
+	Hates      []CommentUserHateMapping `json:"hates"`
+	ExportedAt Time                     `json:"exported_at"`
+}
+
+type CommentExport struct {
+	CommentID int64  `json:"comment_id,string"`
+	ObjID     int64  `json:"obj_id,string"`
+	ObjType   int8   `json:"obj_type"`
+	Root      int64  `json:"root,string"`
+	Parent    int64  `json:"parent,string"`
+	Message   string `json:"message"`
+	Like      int    `json:"like"`
+	Hate      int    `json:"hate"`
+	CreatedAt Time   `json:"created_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// 关注关系，UserID 关注了 FollowedID，(user_id, followed_id) 唯一
+type UserFollow struct {
+	ID         int64 `gorm:"type:bigint;auto_increment"`
+	UserID     int64 `gorm:"type:bigint;not null"`
+	FollowedID int64 `gorm:"type:bigint;not null;index"`
+	CreatedAt  Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
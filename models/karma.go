@@ -0,0 +1,17 @@
+package models
+
+// 用户的 karma，由后台任务定期全量计算
+type UserKarma struct {
+	ID        int64 `gorm:"type:bigint;auto_increment"`
+	UserID    int64 `gorm:"type:bigint;not null;unique"`
+	Karma     int64 `gorm:"type:bigint;not null;default:0"`
+	CreatedAt Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+	UpdatedAt Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+// 按用户聚合的正、负反馈数：帖子的赞成票、反对票，或评论的点赞、点踩数
+type KarmaVoteSum struct {
+	UserID int64
+	Up     int64
+	Down   int64
+}
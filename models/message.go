@@ -0,0 +1,55 @@
+package models
+
+// 私信
+//
+// 同一对用户之间的私信属于同一个会话，ConversationKey 为 "较小的 user_id:较大的 user_id"
+type DirectMessage struct {
+	ID              int64  `gorm:"type:bigint;auto_increment"`
+	MessageID       int64  `gorm:"type:bigint;not null;uniqueIndex"`
+	ConversationKey string `gorm:"type:varchar(64);not null"`
+	SenderID        int64  `gorm:"type:bigint;not null"`
+	ReceiverID      int64  `gorm:"type:bigint;not null"`
+	Content         string `gorm:"type:varchar(1024);not null"`
+	CreatedAt       Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+// 会话，每个参与者各有一条记录，(user_id, peer_id) 唯一
+type Conversation struct {
+	ID            int64  `gorm:"type:bigint;auto_increment"`
+	UserID        int64  `gorm:"type:bigint;not null"`
+	PeerID        int64  `gorm:"type:bigint;not null"`
+	LastMessageID int64  `gorm:"type:bigint;not null"`
+	LastMessage   string `gorm:"type:varchar(256)"`
+	UnreadCount   int    `gorm:"type:int;not null;default:0"`
+	CreatedAt     Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+	UpdatedAt     Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+type DirectMessageDTO struct {
+	MessageID  int64  `json:"message_id,string"`
+	SenderID   int64  `json:"sender_id,string"`
+	ReceiverID int64  `json:"receiver_id,string"`
+	Content    string `json:"content"`
+	CreatedAt  Time   `json:"created_at"`
+}
+
+type DirectMessageListDTO struct {
+	Messages   []*DirectMessageDTO `json:"messages"`
+	NextCursor string              `json:"next_cursor"` // 为空表示没有更多
+}
+
+type ConversationDTO struct {
+	PeerID        int64  `json:"peer_id,string"`
+	PeerName      string `json:"peer_name"`
+	PeerAvatar    string `json:"peer_avatar"`
+	LastMessageID int64  `json:"last_message_id,string"`
+	LastMessage   string `json:"last_message"`
+	UnreadCount   int    `json:"unread_count"`
+	UpdatedAt     Time   `json:"updated_at"`
+}
+
+type ConversationListDTO struct {
+	Total         int                `json:"total"`
+	Unread        int                `json:"unread"` // 所有会话的未读数之和
+	Conversations []*ConversationDTO `json:"conversations"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// 通知类型
+const (
+	NotificationReplyPost    = iota + 1 // 评论了你的帖子
+	NotificationReplyComment            // 回复了你的评论
+	NotificationLikeComment             // 点赞了你的评论
+	NotificationVotePost                // 给你的帖子投了赞成票
+)
+
+// 站内通知
+//
+// 同一个用户、同一类型、同一个对象的未读通知会聚合为一条，如 "5 个人点赞了你的评论"
+type Notification struct {
+	ID          int64  `gorm:"type:bigint;auto_increment"`
+	UserID      int64  `gorm:"type:bigint;not null"` // 接收者
+	Type        int8   `gorm:"type:tinyint;not null"`
+	ObjID       int64  `gorm:"type:bigint;not null"`        // 聚合的对象：帖子 id 或评论 id
+	PostID      int64  `gorm:"type:bigint;not null"`        // 对象所属的帖子，用于跳转
+	LastActorID int64  `gorm:"type:bigint;not null"`        // 最近一个触发通知的用户
+	ActorCount  int    `gorm:"type:int;not null;default:0"` // 触发通知的用户数（去重）
+	Content     string `gorm:"type:varchar(256)"`           // 最近一条回复的内容
+	IsRead      bool   `gorm:"not null;default:false"`
+	CreatedAt   Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+	UpdatedAt   Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+// 聚合通知的参与者，用于去重，(notification_id, actor_id) 唯一
+type NotificationActor struct {
+	ID             int64 `gorm:"type:bigint;auto_increment"`
+	NotificationID int64 `gorm:"type:bigint;not null"`
+	ActorID        int64 `gorm:"type:bigint;not null"`
+}
+
+type NotificationDTO struct {
+	ID            int64  `json:"id,string"`
+	Type          int8   `json:"type"`
+	ObjID         int64  `json:"obj_id,string"`
+	PostID        int64  `json:"post_id,string"`
+	LastActorID   int64  `json:"last_actor_id,string"`
+	LastActorName string `json:"last_actor_name"`
+	ActorCount    int    `json:"actor_count"`
+	Content       string `json:"content"`
+	IsRead        bool   `json:"is_read"`
+	UpdatedAt     Time   `json:"updated_at"`
+}
+
+type NotificationListDTO struct {
+	Total         int                `json:"total"`
+	Unread        int                `json:"unread"`
+	Notifications []*NotificationDTO `json:"notifications"`
+}
@@ -15,10 +15,88 @@ type ParamUserRegist struct {
 }
 
 type ParamUserLogin struct {
-	Username string `json:"username" binding:"required,min=3,max=64"`
+	Username string `json:"username" binding:"required,min=3,max=256"` // 用户名或邮箱
 	Password string `json:"password" binding:"required,min=6,max=64"`
 }
 
+type ParamUserLoginTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,min=6,max=32"` // TOTP code 或恢复码
+}
+
+type ParamTwoFactorCode struct {
+	Code string `json:"code" binding:"required,min=6,max=32"`
+}
+
+type ParamUserExport struct {
+	Format string `form:"format" binding:"omitempty,oneof=json zip"` // 默认 json
+}
+
+type ParamUserFollow struct {
+	UserID int64 `json:"user_id,string" binding:"required"` // 被关注者的 user_id
+}
+
+type ParamUserBlock struct {
+	UserID int64 `json:"user_id,string" binding:"required"` // 被拉黑者的 user_id
+}
+
+type ParamPostFeed struct {
+	PageNum  int64 `form:"page" binding:"gt=0" example:"1"`  // 页码
+	PageSize int64 `form:"size" binding:"gt=0" example:"10"` // 每页展示的 post 的数量
+}
+
+type ParamDirectMessageSend struct {
+	ToUserID int64  `json:"to_user_id,string" binding:"required"`
+	Content  string `json:"content" binding:"required,min=1,max=1024"`
+}
+
+type ParamConversationList struct {
+	PageNum  int64 `form:"page" binding:"gt=0" example:"1"`
+	PageSize int64 `form:"size" binding:"gt=0,lte=100" example:"10"`
+}
+
+type ParamDirectMessageHistory struct {
+	PeerID   int64  `form:"peer_id" binding:"required"` // 会话的对方
+	Cursor   string `form:"cursor"`                     // 上一页返回的 next_cursor，为空表示第一页
+	PageSize int64  `form:"size" binding:"gt=0,lte=100" example:"20"`
+}
+
+type ParamNotificationList struct {
+	PageNum  int64 `form:"page" binding:"gt=0" example:"1"`
+	PageSize int64 `form:"size" binding:"gt=0" example:"10"`
+}
+
+type ParamNotificationRead struct {
+	IDs []string `json:"ids"` // 通知 id
+	All bool     `json:"all"` // 为 true 时标记所有通知为已读，忽略 ids
+}
+
+type ParamStream struct {
+	PostIDs []string `form:"post_id" binding:"max=50"` // 订阅评论的帖子，可以传多个
+}
+
+type ParamBookmark struct {
+	PostID int64  `json:"post_id,string" binding:"required"`
+	Folder string `json:"folder" binding:"max=64"` // 收藏夹，为空表示默认收藏夹
+}
+
+type ParamUnbookmark struct {
+	PostID int64 `json:"post_id,string" binding:"required"`
+}
+
+type ParamBookmarkList struct {
+	Cursor   string  `form:"cursor"` // 上一页返回的 next_cursor，为空表示第一页
+	PageSize int64   `form:"size" binding:"gt=0,lte=100" example:"10"`
+	Folder   *string `form:"folder" binding:"omitempty,max=64"` // 不传表示所有收藏夹
+}
+
+type ParamUserDelete struct {
+	Mode        string `form:"mode" binding:"omitempty,oneof=anonymize remove"` // 默认 anonymize
+	Password    string `json:"password" binding:"max=64"`                       // 未开启两步验证时需要
+	Code        string `json:"code" binding:"max=32"`                           // 开启了两步验证时需要，TOTP code 或恢复码
+	ReauthToken string `json:"reauth_token" binding:"max=64"`                   // 第三方重新授权后得到，提供时不再需要 password、code
+}
+
 type ParamUserUpdate struct {
 	Username string `json:"username" binding:"required,min=3,max=64"`
 	Gender   int8   `json:"gender" binding:"required,min=1,max=3"`
@@ -45,17 +123,31 @@ type ParamVote struct {
 }
 
 type ParamPostList struct {
-	PageNum     int64  `form:"page" binding:"gt=0" example:"1"`    // 页码
-	PageSize    int64  `form:"size" binding:"gt=0" example:"10"`   // 每页展示的 post 的数量
-	OrderBy     string `form:"orderby" binding:"oneof=time score"` // 排序方式
-	CommunityID int64  `form:"community_id" example:"1"`           // 社区 id
+	PageNum     int64  `form:"page" binding:"gt=0" example:"1"`            // 页码
+	PageSize    int64  `form:"size" binding:"gt=0" example:"10"`           // 每页展示的 post 的数量
+	OrderBy     string `form:"orderby" binding:"oneof=time score"`         // 排序方式
+	CommunityID int64  `form:"community_id" example:"1"`                   // 社区 id
+	Scope       string `form:"scope" binding:"omitempty,oneof=all joined"` // joined：只查询加入的社区（需要登录），此时忽略 community_id
 }
 
 type ParamPostListByKeyword struct {
-	PageNum  int64  `form:"page" binding:"gt=0" example:"1"`          // 页码
-	PageSize int64  `form:"size" binding:"gt=0" example:"10"`         // 每页展示的 post 的数量
-	OrderBy  string `form:"orderby" binding:"oneof=time correlation"` // 排序方式
-	Keyword  string `form:"keyword" binding:"required"`               // 关键字
+	PageNum     int64  `form:"page" binding:"gt=0" example:"1"`                                         // 页码
+	PageSize    int64  `form:"size" binding:"gt=0" example:"10"`                                        // 每页展示的 post 的数量
+	OrderBy     string `form:"orderby" binding:"oneof=time correlation"`                                // 排序方式
+	Keyword     string `form:"keyword" binding:"required"`                                              // 关键字
+	CommunityID int64  `form:"community_id"`                                                            // 只搜索该社区的帖子
+	AuthorID    int64  `form:"author_id"`                                                               // 只搜索该作者的帖子
+	StartDate   string `form:"start_date" binding:"omitempty,datetime=2006-01-02" example:"2024-01-01"` // 发布日期下限（包含）
+	EndDate     string `form:"end_date" binding:"omitempty,datetime=2006-01-02" example:"2024-12-31"`   // 发布日期上限（包含）
+	MinVotes    int64  `form:"min_votes" binding:"gte=0"`                                               // 最少赞成票数
+	WithFacets  bool   `form:"facets"`                                                                  // 是否返回分面统计
+}
+
+type ParamPostRepost struct {
+	PostID      int64  `json:"post_id,string" binding:"required"`    // 被转发的帖子
+	CommunityID int64  `json:"community_id" binding:"required"`      // 转发到的社区
+	Title       string `json:"title" binding:"omitempty,max=128"`    // 为空时使用 "转发：原帖标题"
+	Content     string `json:"content" binding:"omitempty,max=8192"` // 转发时附带的评论
 }
 
 type ParamPostRemove struct {
@@ -79,6 +171,29 @@ type ParamCommentList struct {
 	PageSize int64  `form:"size" binding:"gt=0" example:"10"`   // 每页展示的 post 的数量
 }
 
+type ParamSearch struct {
+	Type     string `form:"type" binding:"oneof=post user community" example:"post"` // 搜索类型
+	Keyword  string `form:"keyword" binding:"required"`                              // 关键字
+	PageNum  int64  `form:"page" binding:"gt=0" example:"1"`                         // 页码
+	PageSize int64  `form:"size" binding:"gt=0" example:"10"`                        // 每页数量
+}
+
+type ParamPostRelated struct {
+	PostID int64 `form:"post_id" binding:"required"` // 帖子 id
+}
+
+type ParamPostSuggest struct {
+	Prefix string `form:"prefix" binding:"required,max=50"`       // 已输入的前缀
+	Size   int    `form:"size" binding:"gt=0,lte=20" example:"5"` // 标题、热门搜索词各自返回的最大数量
+}
+
+type ParamCommentSearch struct {
+	Keyword  string `form:"keyword" binding:"required"`       // 关键字
+	ObjID    int64  `form:"obj_id"`                           // 帖子 id，为空时全局搜索
+	PageNum  int64  `form:"page" binding:"gt=0" example:"1"`  // 页码
+	PageSize int64  `form:"size" binding:"gt=0" example:"10"` // 每页展示的评论数量
+}
+
 type ParamCommentRemove struct {
 	ObjID     int64 `form:"obj_id" binding:"required"`
 	ObjType   int8  `form:"obj_type" binding:"required"`
@@ -104,12 +219,23 @@ type ParamCommentUserLikeOrHateList struct {
 }
 
 /* Community */
+type ParamCommunityMember struct {
+	CommunityID int64 `json:"community_id" binding:"required"`
+}
+
 type ParamCommunityCreate struct {
 	CommunityID   int64  `json:"community_id" binding:"required"`
 	CommunityName string `json:"community_name" binding:"required"`
 	Introduction  string `json:"introduction" binding:"required"`
 }
 
+/* Role */
+type ParamRoleUpdate struct {
+	UserID      int64  `json:"user_id,string" binding:"required"`
+	Role        string `json:"role" binding:"required,oneof=admin moderator"`
+	CommunityID int64  `json:"community_id"` // 版主需要指定社区
+}
+
 /* Email */
 type ParamSendEmailVerificationCode struct {
 	Email string `form:"email" binding:"required"`
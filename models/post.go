@@ -1,28 +1,105 @@
 package models
 
+import "time"
+
 type Post struct {
-	ID          int64  `gorm:"type:bigint;auto_increment" json:"id"`
-	PostID      int64  `gorm:"type:bigint;not null;unique" json:"post_id"`
-	CommunityID int64  `gorm:"type:bigint;not null;" json:"community_id" binding:"required"`
-	AuthorID    int64  `gorm:"type:bigint;not null;index:idx_author_id" json:"author_id"`
-	Status      int8   `gorm:"type:tinyint;not null;default 1;" json:"status"`
-	Title       string `gorm:"type:varchar(128);not null;" json:"title" binding:"required"`
-	Content     string `gorm:"type:longtext;not null;" json:"content" binding:"required"`
-	CreatedAt   Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt   Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP" json:"update_at"`
+	ID             int64  `gorm:"type:bigint;auto_increment" json:"id"`
+	PostID         int64  `gorm:"type:bigint;not null;unique" json:"post_id"`
+	CommunityID    int64  `gorm:"type:bigint;not null;" json:"community_id" binding:"required"`
+	AuthorID       int64  `gorm:"type:bigint;not null;index:idx_author_id" json:"author_id"`
+	Status         int8   `gorm:"type:tinyint;not null;default 1;" json:"status"`
+	Title          string `gorm:"type:varchar(128);not null;" json:"title" binding:"required"`
+	Content        string `gorm:"type:longtext;not null;" json:"content" binding:"required"`
+	OriginalPostID int64  `gorm:"type:bigint;not null;default:0;index" json:"-"` // 转发的原帖，0 表示不是转发
+	RepostCount    int64  `gorm:"type:bigint;not null;default:0" json:"-"`       // 被转发的次数
+	CreatedAt      Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP" json:"update_at"`
 }
 
+// UpVoteNum、DownVoteNum 加入之前过期的帖子，这两列为 0，且无法恢复：
+// 当时 redis 中的投票记录在过期时已被删除，而写入的 post_vote_num 恒为 0（反对票数误读成了赞成票数），
+// 因此这些帖子收到的票数不计入 karma 和用户的统计数据
 type ExpiredPostScore struct {
 	PostID      int64 `gorm:"primaryKey" json:"post_id"`
 	PostScore   int64 `json:"post_score"`
 	PostVoteNum int64 `json:"post_vote_num"`
+	UpVoteNum   int64 `gorm:"not null;default:0" json:"up_vote_num"`   // 赞成票数，用于计算 karma
+	DownVoteNum int64 `gorm:"not null;default:0" json:"down_vote_num"` // 反对票数，用于计算 karma
 }
 
 type PostDoc struct {
-	PostID    int64  `json:"post_id"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	CreatedAt any    `json:"created_time"`
+	PostID      int64  `json:"post_id"`
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	CommunityID string `json:"community_id"` // 以字符串形式索引，只做精确匹配
+	AuthorID    string `json:"author_id"`
+	VoteNum     int64  `json:"vote_num"`
+	CreatedAt   any    `json:"created_time"`
+}
+
+// 搜索结果中标题、内容的高亮片段，关键字使用 <mark></mark> 包裹，其余内容已做 HTML 转义
+type PostHighlightDTO struct {
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type FacetBucketDTO struct {
+	Key   string `json:"key"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// 搜索结果的分面统计
+type PostFacetsDTO struct {
+	Communities []FacetBucketDTO `json:"communities"` // 按社区统计，key 为社区 id
+	Months      []FacetBucketDTO `json:"months"`      // 按月份统计（最近 12 个月），key 形如 2006-01
+}
+
+// 搜索引擎返回的结果
+type PostSearchResult struct {
+	PostIDs    []string
+	Total      int
+	Highlights map[string]PostHighlightDTO // key 为 post_id
+	Facets     *PostFacetsDTO
+}
+
+// 相关帖子的候选，Score 为与原帖的相似度
+type PostSimilarity struct {
+	PostID string
+	Score  float64
+}
+
+// 按月份统计时，统计的月份数
+const FacetMonthNum = 12
+
+// DateRange 解析发布日期的范围，返回 [start, end)，未指定的一端为零值
+func (p *ParamPostListByKeyword) DateRange() (start, end time.Time) {
+	if p.StartDate != "" {
+		start, _ = time.ParseInLocation("2006-01-02", p.StartDate, time.Local) // 格式已在参数校验时检查
+	}
+	if p.EndDate != "" {
+		end, _ = time.ParseInLocation("2006-01-02", p.EndDate, time.Local)
+		end = end.AddDate(0, 0, 1)
+	}
+	return
+}
+
+type PostSearchListDTO struct {
+	Total      int                         `json:"total"`
+	Posts      []*PostDTO                  `json:"posts"`
+	Highlights map[string]PostHighlightDTO `json:"highlights,omitempty"` // key 为 post_id
+	Facets     *PostFacetsDTO              `json:"facets,omitempty"`
+}
+
+// 帖子标题补全
+type PostTitleSuggestionDTO struct {
+	PostID string `json:"post_id"`
+	Title  string `json:"title"`
+}
+
+type PostSuggestDTO struct {
+	Titles  []PostTitleSuggestionDTO `json:"titles"`  // 标题以 prefix 开头的帖子
+	Queries []string                 `json:"queries"` // 以 prefix 开头的近期热门搜索词
 }
 
 type PostDTO struct {
@@ -43,6 +120,13 @@ type PostDTO struct {
 	CommunityCreatedAt Time `json:"community_created_at"`
 
 	VoteNum int64 `json:"vote_num"`
+
+	OriginalPostID  int64    `json:"original_post_id,string,omitempty"` // 转发的原帖
+	Original        *PostDTO `json:"original,omitempty"`                // 原帖，原帖被删除时为空
+	OriginalRemoved bool     `json:"original_removed,omitempty"`        // 原帖是否已被删除
+	RepostCount     int64    `json:"repost_count"`
+
+	IsBookmarked bool `json:"is_bookmarked"` // 当前用户是否收藏，未登录时为 false
 }
 
 type PostListDTO struct {
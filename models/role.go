@@ -0,0 +1,51 @@
+package models
+
+import "slices"
+
+// 角色
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator" // 社区版主，只对 CommunityID 对应的社区有管理权限
+	RoleAdmin     = "admin"
+)
+
+// 用户角色，普通用户不需要记录；admin 的 CommunityID 为 0
+type UserRole struct {
+	ID          int64  `gorm:"type:bigint;auto_increment"`
+	UserID      int64  `gorm:"type:bigint;not null;index"`
+	Role        string `gorm:"type:varchar(16);not null"`
+	CommunityID int64  `gorm:"type:bigint;not null;default:0"`
+	CreatedAt   Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+// 携带在 JWT 中的角色信息
+type RoleInfo struct {
+	Role           string  `json:"role,omitempty"`
+	ModCommunities []int64 `json:"mod_communities,omitempty"` // 担任版主的社区
+}
+
+func (r RoleInfo) IsAdmin() bool {
+	return r.Role == RoleAdmin
+}
+
+// 是否拥有某个社区的管理权限
+func (r RoleInfo) CanModerate(communityID int64) bool {
+	return r.IsAdmin() || slices.Contains(r.ModCommunities, communityID)
+}
+
+// 由数据库中的角色记录，生成 RoleInfo
+func NewRoleInfo(roles []UserRole) RoleInfo {
+	info := RoleInfo{Role: RoleUser}
+	for _, role := range roles {
+		switch role.Role {
+		case RoleAdmin:
+			info.Role = RoleAdmin
+		case RoleModerator:
+			if info.Role != RoleAdmin {
+				info.Role = RoleModerator
+			}
+			info.ModCommunities = append(info.ModCommunities, role.CommunityID)
+		}
+	}
+	return info
+}
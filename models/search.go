@@ -0,0 +1,62 @@
+package models
+
+// 搜索引擎的健康状态
+type SearchEngineStatusDTO struct {
+	Name          string `json:"name"`
+	State         string `json:"state"`                     // closed：正常；open：熔断中；half_open：探测是否恢复
+	IsQueryEngine bool   `json:"is_query_engine"`           // 是否为配置的查询引擎（search.engine）
+	Failures      int    `json:"failures"`                  // 连续失败次数
+	LastFailureAt *Time  `json:"last_failure_at,omitempty"` // 最近一次失败的时间
+	OpenedAt      *Time  `json:"opened_at,omitempty"`       // 最近一次熔断的时间
+}
+
+type SearchStatusDTO struct {
+	Engines       []*SearchEngineStatusDTO `json:"engines"`
+	MySQLFallback bool                     `json:"mysql_fallback"` // 所有引擎都不可用时，是否使用 mysql 兜底搜索帖子
+}
+
+// 用户在搜索引擎中的文档
+type UserDoc struct {
+	UserID   int64  `json:"user_id"`
+	UserName string `json:"username"`
+	Intro    string `json:"intro"`
+}
+
+// 社区在搜索引擎中的文档
+type CommunityDoc struct {
+	CommunityID   int64  `json:"community_id"`
+	CommunityName string `json:"community_name"`
+	Introduction  string `json:"introduction"`
+}
+
+// 搜索类型
+const (
+	SearchTypePost      = "post"
+	SearchTypeUser      = "user"
+	SearchTypeCommunity = "community"
+)
+
+type UserSearchDTO struct {
+	UserID   int64  `json:"user_id,string"`
+	UserName string `json:"username"`
+	Avatar   string `json:"avatar"`
+	Intro    string `json:"intro"`
+}
+
+type UserSearchListDTO struct {
+	Total int              `json:"total"`
+	Users []*UserSearchDTO `json:"users"`
+}
+
+type CommunitySearchListDTO struct {
+	Total       int            `json:"total"`
+	Communities []CommunityDTO `json:"communities"`
+}
+
+// 统一搜索的结果，只有与 Type 对应的字段不为空
+type SearchResultDTO struct {
+	Type        string                  `json:"type"`
+	Posts       *PostSearchListDTO      `json:"posts,omitempty"`
+	Users       *UserSearchListDTO      `json:"users,omitempty"`
+	Communities *CommunitySearchListDTO `json:"communities,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// user_stats 中可以递增的字段
+const (
+	UserStatPostCount        = "post_count"
+	UserStatVoteCount        = "vote_count"
+	UserStatCommentCount     = "comment_count"
+	UserStatCommentLikeCount = "comment_like_count"
+)
+
+// 用户的统计数据，由各个消费者增量维护
+//
+// PostCount、CommentCount 是当前的帖子、评论数，删除时递减；
+// VoteCount、CommentLikeCount 是累计收到的赞成票、点赞数，删除帖子、评论时不递减
+type UserStat struct {
+	ID               int64 `gorm:"type:bigint;auto_increment"`
+	UserID           int64 `gorm:"type:bigint;not null;unique"`
+	PostCount        int64 `gorm:"type:bigint;not null;default:0"`
+	VoteCount        int64 `gorm:"type:bigint;not null;default:0"`
+	CommentCount     int64 `gorm:"type:bigint;not null;default:0"`
+	CommentLikeCount int64 `gorm:"type:bigint;not null;default:0"`
+	CreatedAt        Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+	UpdatedAt        Time  `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+type UserStatDTO struct {
+	PostCount        int64 `json:"post_count"`
+	VoteCount        int64 `json:"vote_count"` // 收到的赞成票总数
+	CommentCount     int64 `json:"comment_count"`
+	CommentLikeCount int64 `json:"comment_like_count"` // 评论收到的点赞总数
+	JoinedAt         Time  `json:"joined_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// 实时推送的事件类型
+const (
+	StreamEventComment       = "comment"        // 订阅的帖子有新评论
+	StreamEventNotification  = "notification"   // 新通知
+	StreamEventDirectMessage = "direct_message" // 新私信
+)
+
+// 通过 redis pub/sub 在各个实例间广播的事件
+type StreamEvent struct {
+	Type   string `json:"type"`
+	UserID int64  `json:"user_id,string,omitempty"` // 通知、私信的接收者
+	PostID int64  `json:"post_id,string,omitempty"` // 评论所属的帖子
+	Data   any    `json:"data"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// TOTP 两步验证，Enabled 为 false 表示处于绑定流程中、尚未确认
+type UserTOTP struct {
+	ID        int64  `gorm:"type:bigint;auto_increment"`
+	UserID    int64  `gorm:"type:bigint;not null;unique"`
+	Secret    string `gorm:"type:varchar(64);not null"`
+	Enabled   bool   `gorm:"type:tinyint;not null;default:0"`
+	CreatedAt Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+	UpdatedAt Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
+
+// 恢复码，只保存哈希值，每个恢复码只能使用一次
+type UserRecoveryCode struct {
+	ID        int64  `gorm:"type:bigint;auto_increment"`
+	UserID    int64  `gorm:"type:bigint;not null;index"`
+	CodeHash  string `gorm:"type:varchar(64);not null"`
+	Used      bool   `gorm:"type:tinyint;not null;default:0"`
+	CreatedAt Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
+}
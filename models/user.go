@@ -14,10 +14,24 @@ type User struct {
 }
 
 type UserDTO struct {
-	UserID   int64      `json:"user_id,string"`
-	UserName string     `json:"username"`
-	Email    string     `json:"email"`
-	Gender   int8       `json:"gender"`
-	Avatar   string     `json:"avatar"`
-	Intro    string     `json:"intro"`
+	UserID         int64        `json:"user_id,string"`
+	UserName       string       `json:"username"`
+	Email          string       `json:"email"`
+	Gender         int8         `json:"gender"`
+	Avatar         string       `json:"avatar"`
+	Intro          string       `json:"intro"`
+	FollowerCount  int          `json:"follower_count"`  // 粉丝数
+	FollowingCount int          `json:"following_count"` // 关注数
+	Stat           *UserStatDTO `json:"stat"`            // 帖子数、收到的赞成票数、评论数等统计数据
+	Karma          int64        `json:"karma"`           // 由帖子的投票、评论的点赞（踩）计算，定期刷新
+}
+
+// 第三方登录的身份，(provider, subject) 唯一
+type UserIdentity struct {
+	ID        int64  `gorm:"type:bigint;auto_increment"`
+	UserID    int64  `gorm:"type:bigint;not null;index"`
+	Provider  string `gorm:"type:varchar(32);not null"`
+	Subject   string `gorm:"type:varchar(255);not null"`
+	Email     string `gorm:"type:varchar(64)"`
+	CreatedAt Time   `gorm:"type:timestamp default CURRENT_TIMESTAMP"`
 }
@@ -5,6 +5,7 @@ import (
 	docs "bluebell/docs"
 	"bluebell/logger"
 	"bluebell/middleware"
+	"bluebell/models"
 	"fmt"
 	"net/http"
 
@@ -22,6 +23,10 @@ func Init() {
 	}
 
 	router = gin.New()
+	// 只信任配置的反向代理添加的 X-Forwarded-For，未配置时 ClientIP 即连接的对端 IP，避免客户端伪造 IP 绕过按 IP 的限流
+	if err := router.SetTrustedProxies(viper.GetStringSlice("router.trusted_proxies")); err != nil {
+		panic(fmt.Sprintf("router: invalid trusted proxies: %s", err.Error()))
+	}
 	frontendPath := viper.GetString("router.corf.frontend_path")
 	middlewares := []gin.HandlerFunc{logger.GinLogger(), logger.GinRecovery(true), middleware.CORF(frontendPath)}
 	if viper.GetBool("router.ratelimit.enable") { // 全局限流
@@ -46,8 +51,27 @@ func Init() {
 	usrGrp := v1.Group("/user")
 	usrGrp.POST("/register", controller.UserRegisterHandler)
 	usrGrp.POST("/login", controller.UserLoginHandler)
+	usrGrp.POST("/login/2fa", controller.UserLoginTwoFactorHandler)
+	usrGrp.POST("/2fa/enroll", middleware.Auth(), middleware.VerifyToken(), controller.TwoFactorEnrollHandler)
+	usrGrp.POST("/2fa/confirm", middleware.Auth(), middleware.VerifyToken(), controller.TwoFactorConfirmHandler)
+	usrGrp.POST("/2fa/disable", middleware.Auth(), middleware.VerifyToken(), controller.TwoFactorDisableHandler)
+	if viper.GetBool("oauth.enable") {
+		usrGrp.GET("/oauth/:provider/login", controller.OAuthLoginURLHandler)
+		usrGrp.GET("/oauth/:provider/callback", controller.OAuthCallbackHandler)
+		usrGrp.GET("/oauth/:provider/reauth", middleware.Auth(), middleware.VerifyToken(), controller.OAuthReauthURLHandler)
+	}
 	usrGrp.POST("/update", middleware.Auth(), middleware.VerifyToken(), controller.UserUpdateHandler)
 	usrGrp.GET("/info", middleware.Auth(), middleware.VerifyToken(), controller.UserInfoHandler)
+	usrGrp.POST("/follow", middleware.Auth(), middleware.VerifyToken(), controller.UserFollowHandler)
+	usrGrp.POST("/unfollow", middleware.Auth(), middleware.VerifyToken(), controller.UserUnfollowHandler)
+	usrGrp.POST("/block", middleware.Auth(), middleware.VerifyToken(), controller.UserBlockHandler)
+	usrGrp.POST("/unblock", middleware.Auth(), middleware.VerifyToken(), controller.UserUnblockHandler)
+	usrGrp.GET("/blocks", middleware.Auth(), middleware.VerifyToken(), controller.BlockListHandler)
+	usrGrp.POST("/bookmark", middleware.Auth(), middleware.VerifyToken(), controller.BookmarkHandler)
+	usrGrp.POST("/unbookmark", middleware.Auth(), middleware.VerifyToken(), controller.UnbookmarkHandler)
+	usrGrp.GET("/bookmarks", middleware.Auth(), middleware.VerifyToken(), controller.BookmarkListHandler)
+	usrGrp.GET("/export", middleware.Auth(), middleware.VerifyToken(), controller.UserExportHandler)
+	usrGrp.DELETE("", middleware.Auth(), middleware.VerifyToken(), controller.UserDeleteHandler)
 	usrGrp.GET("/:user_id", controller.UserHomeHandler)
 	usrGrp.GET("/posts", controller.UserGetPostListHandler)
 
@@ -57,22 +81,32 @@ func Init() {
 	communityGrp.POST("/create", controller.CommunityCreateHandler)
 	communityGrp.GET("/list", controller.CommunityListHandler)
 	communityGrp.GET("/detail", controller.CommunityDetailHandler)
+	communityGrp.POST("/join", controller.CommunityJoinHandler)
+	communityGrp.POST("/leave", controller.CommunityLeaveHandler)
+	communityGrp.GET("/joined", controller.CommunityJoinedListHandler)
 
 	/* Post */
 	postGrp := v1.Group("/post")
 	postGrp.Use(middleware.Auth(), middleware.VerifyToken())
 	postGrp.POST("/create", controller.CreatePostHandler)
+	postGrp.POST("/repost", controller.PostRepostHandler)
 	postGrp.DELETE("/remove", controller.PostRemoveHandler)
 	postGrp.GET("/:post_id", controller.PostDetailHandler)
 	postGrp.POST("/vote", controller.PostVoteHandler)
+	postGrp.GET("/feed", controller.PostFeedHandler)
 
-	v1.GET("/post/list", controller.PostListHandler)       // 查看列表
+	v1.GET("/post/list", middleware.TryAuth(), controller.PostListHandler)       // 查看列表
 	v1.GET("/post/hot", controller.PostHotController)
-	if viper.GetBool("elasticsearch.enable") {
-		v1.GET("/post/search2", controller.PostSearchHandler2) // 使用 es 实现的搜索
-	}
-	if viper.GetBool("bleve.enable") {
-		v1.GET("/post/search", controller.PostSearchHandler)   // 使用 bleve 实现的搜索
+	if viper.GetBool("elasticsearch.enable") || viper.GetBool("bleve.enable") {
+		v1.GET("/post/search", controller.PostSearchHandler) // 搜索引擎由 search.engine 配置决定
+		v1.GET("/post/suggest", middleware.RateLimitByIP(
+			viper.GetFloat64("search.suggest.ratelimit.rate"),
+			viper.GetInt64("search.suggest.ratelimit.capacity"),
+			viper.GetInt("search.suggest.ratelimit.max_ips"),
+		), controller.PostSuggestHandler)
+		v1.GET("/post/related", controller.PostRelatedHandler)
+		v1.GET("/search", controller.SearchHandler) // 统一搜索帖子、用户、社区
+		v1.GET("/search/status", controller.SearchStatusHandler)
 	}
 
 	/* Comment */
@@ -84,8 +118,27 @@ func Init() {
 	commentGrp.POST("/hate", controller.CommentHateHandler)
 	commentGrp.GET("/likeOrHateList", controller.CommentUserLikeOrHateListHandler)
 	
-	v1.GET("/comment/list", controller.CommentListHandler)
+	v1.GET("/comment/list", middleware.TryAuth(), controller.CommentListHandler)
+	if viper.GetBool("elasticsearch.enable") || viper.GetBool("bleve.enable") {
+		v1.GET("/comment/search", middleware.TryAuth(), controller.CommentSearchHandler)
+	}
 	
+	/* Notification */
+	notificationGrp := v1.Group("/notifications")
+	notificationGrp.Use(middleware.Auth(), middleware.VerifyToken())
+	notificationGrp.GET("", controller.NotificationListHandler)
+	notificationGrp.POST("/read", controller.NotificationReadHandler)
+
+	/* Direct Message */
+	messageGrp := v1.Group("/messages")
+	messageGrp.Use(middleware.Auth(), middleware.VerifyToken())
+	messageGrp.POST("", controller.DirectMessageSendHandler)
+	messageGrp.GET("/conversations", controller.ConversationListHandler)
+	messageGrp.GET("/history", controller.DirectMessageHistoryHandler)
+
+	/* Stream */
+	v1.GET("/stream", middleware.QueryToken(), middleware.Auth(), middleware.VerifyToken(), controller.StreamHandler)
+
 	/* Qiniu */
 	qiniuGrp := v1.Group("/qiniu")
 	qiniuGrp.Use(middleware.Auth(), middleware.VerifyToken())
@@ -96,6 +149,12 @@ func Init() {
 	emailGrp := v1.Group("/email")
 	emailGrp.POST("/verification", controller.EmailSendVerificationCodeHandler)
 
+	/* Admin */
+	adminGrp := v1.Group("/admin")
+	adminGrp.Use(middleware.Auth(), middleware.VerifyToken(), middleware.RequireRole(models.RoleAdmin))
+	adminGrp.POST("/role/grant", controller.AdminGrantRoleHandler)
+	adminGrp.POST("/role/revoke", controller.AdminRevokeRoleHandler)
+
 	/* Empty */
 	v1.GET("/empty0", controller.EmptyHandler0) // 空接口
 	v1.GET("/empty1", middleware.Auth(), middleware.VerifyToken(), controller.EmptyHandler1) // 空接口，但有鉴权
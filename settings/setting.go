@@ -11,6 +11,8 @@ func InitSettings(confPath string) {
 	viper.SetDefault("server.develop_mode", false)
 	viper.SetDefault("server.shutdown_waitting_time", 30) // 收到 SIGINT 信号后，超过 30s，服务器将强制退出
 
+	viper.SetDefault("router.trusted_proxies", []string{}) // 信任的反向代理 IP 或网段，只有来自这些地址的请求才会使用 X-Forwarded-For 中的客户端 IP
+
 	viper.SetDefault("mysql.driverName", "mysql")
 	viper.SetDefault("mysql.host", "127.0.0.1")
 	viper.SetDefault("mysql.port", 3306)
@@ -28,6 +30,29 @@ func InitSettings(confPath string) {
 	viper.SetDefault("redis.cache_key_tls", 60)
 	viper.SetDefault("redis.hot_key_tls", 60)
 
+	viper.SetDefault("search.engine", "bleve")                  // 处理查询的搜索引擎（bleve 或 elasticsearch），写操作会同步到所有启用的引擎
+	viper.SetDefault("search.vote_sync_delay", 5)               // 赞成票数变化后，延迟同步到搜索引擎的时间，合并短时间内的多次投票
+	viper.SetDefault("search.boost.title", 2.0)                 // 关键字匹配标题时的权重
+	viper.SetDefault("search.boost.content", 1.0)               // 关键字匹配内容时的权重
+	viper.SetDefault("search.suggest.term_days", 7)             // 统计最近几天的热门搜索词
+	viper.SetDefault("search.suggest.max_terms", 1000)          // 每天最多保留的搜索词数量
+	viper.SetDefault("search.suggest.ratelimit.rate", 5.0)      // 搜索补全接口每个 IP 每秒生成的令牌数
+	viper.SetDefault("search.suggest.ratelimit.capacity", 10)   // 搜索补全接口每个 IP 的令牌桶大小
+	viper.SetDefault("search.suggest.ratelimit.max_ips", 10000) // 最多记录的 IP 数
+	viper.SetDefault("search.related.size", 5)                  // 返回的相关帖子数量
+	viper.SetDefault("search.related.candidate_num", 20)        // 按相似度召回的候选数量，再结合帖子分数重新排序
+	viper.SetDefault("search.related.score_boost", 0.5)         // 帖子分数对排序的影响程度
+	viper.SetDefault("search.related.cache_time", 600)          // 相关帖子在本地缓存中的过期时间
+	viper.SetDefault("search.breaker.failure_threshold", 5)     // 引擎连续失败多少次后熔断，熔断期间查询降级到其他启用的引擎
+	viper.SetDefault("search.breaker.open_time", 30)            // 熔断时间，之后放行一个请求探测引擎是否恢复
+	viper.SetDefault("search.breaker.timeout", 2000)            // 单次查询的超时时间（ms），超时计为失败
+	viper.SetDefault("search.fallback.mysql", false)            // 所有引擎都不可用时，是否使用 mysql 的 LIKE 查询兜底搜索帖子
+
+	viper.SetDefault("kafka.partition.notification", 6)
+	viper.SetDefault("kafka.replication_factor.notification", 1)
+	viper.SetDefault("kafka.partition.direct_message", 6)
+	viper.SetDefault("kafka.replication_factor.direct_message", 1)
+
 	viper.SetDefault("logger.level", 0)
 	viper.SetDefault("logger.path", "./logs/bluebell.log")
 	viper.SetDefault("logger.max_size", 16)
@@ -38,10 +63,39 @@ func InitSettings(confPath string) {
 	viper.SetDefault("service.token.access_token_expire_duration", 86400)
 	viper.SetDefault("service.token.refresh_token_expire_duration", 864000)
 
+	viper.SetDefault("service.login.max_attempts", 5)     // 单个账户在 fail_window 内允许的最大失败次数
+	viper.SetDefault("service.login.ip_max_attempts", 20) // 单个 IP 在 fail_window 内允许的最大失败次数
+	viper.SetDefault("service.login.fail_window", 900)
+	viper.SetDefault("service.login.lock_base_time", 60) // 第一次锁定的时间，之后每次翻倍
+	viper.SetDefault("service.login.lock_max_time", 86400)
+	viper.SetDefault("service.login.lock_level_expire", 86400) // 锁定次数的记忆时间
+
+	viper.SetDefault("service.two_factor.issuer", "bluebell")
+	viper.SetDefault("service.two_factor.challenge_expire_time", 300)
+	viper.SetDefault("service.two_factor.recovery_code_num", 10)
+
 	viper.SetDefault("service.post.active_time", 604800)
 	viper.SetDefault("service.post.persistence_interval", 43200)
 	viper.SetDefault("service.post.content_max_length", 256)
 
+	viper.SetDefault("service.feed.fanout_threshold", 5000) // 粉丝数达到该值的作者，发帖时不再写扩散
+	viper.SetDefault("service.feed.inbox_size", 1000)
+
+	viper.SetDefault("service.notification.unread_expire_time", 300) // 未读通知数缓存的过期时间
+
+	viper.SetDefault("service.stream.heartbeat_interval", 30) // 实时推送的心跳间隔
+	viper.SetDefault("service.stream.buffer_size", 16)        // 每个连接缓冲的事件数，超出后丢弃
+
+	viper.SetDefault("service.block.cache_expire_time", 600) // 拉黑列表缓存的过期时间
+
+	viper.SetDefault("service.karma.refresh_interval", 600) // karma 的刷新间隔
+	viper.SetDefault("service.karma.weight.post_up", 10)
+	viper.SetDefault("service.karma.weight.post_down", -2)
+	viper.SetDefault("service.karma.weight.comment_like", 2)
+	viper.SetDefault("service.karma.weight.comment_hate", -1)
+	viper.SetDefault("service.karma.privilege.create_community", 1000) // 创建社区需要的 karma，管理员不受限制
+	viper.SetDefault("service.karma.privilege.upload_image", 20)       // 获取七牛云上传 token 需要的 karma
+
 	viper.SetDefault("service.comment.index.remove_interval", 60)
 	viper.SetDefault("service.comment.index.expire_time", 120)
 
@@ -58,6 +112,13 @@ func InitSettings(confPath string) {
 
 	viper.SetDefault("service.swagger.enable", true)
 
+	viper.SetDefault("service.rbac.admins", []int{}) // 启动时设置为管理员的 user_id
+
+	viper.SetDefault("oauth.enable", false)
+	viper.SetDefault("oauth.timeout", 10)             // 请求第三方平台的超时时间
+	viper.SetDefault("oauth.state_expire_time", 600)  // 授权流程需要在该时间内完成
+	viper.SetDefault("oauth.reauth_expire_time", 300) // 重新授权得到的 reauth_token 的有效时间
+
 	viper.SetConfigFile(confPath)
 
 	if err := viper.ReadInConfig(); err != nil {
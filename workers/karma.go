@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"bluebell/logger"
+	"bluebell/logic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 定期全量计算用户的 karma
+func RefreshUserKarma() {
+	refreshInterval := time.Second * time.Duration(viper.GetInt64("service.karma.refresh_interval"))
+	waitTime := 0 * time.Second
+
+	go func() {
+		for {
+			time.Sleep(waitTime)
+			if checkIfExit() {
+				return
+			}
+
+			count, err := logic.RefreshUserKarma()
+			if !checkError(err, &waitTime) {
+				continue
+			}
+			logger.Infof("workers:RefreshUserKarma: Refreshed karma of %d users", count)
+
+			waitTime = refreshInterval
+			markAsExit()
+		}
+	}()
+}
@@ -5,7 +5,7 @@ import "bluebell/logger"
 var done chan int		// 标记主 goroutine 即将退出
 var semWorker chan int  // 看作信号量，代表当前正在运行的后台 worker 数量
 
-const total = 12 // 后台任务的数量
+const total = 13 // 后台任务的数量
 
 func InitWorkers() {
 	done = make(chan int, total)
@@ -28,6 +28,8 @@ func InitWorkers() {
 	RefreshPostHotSpot()
 	RefreshCommentHotSpot()
 	RemoveExpiredObjectView()
+
+	RefreshUserKarma()
 }
 
 func Wait() {
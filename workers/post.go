@@ -50,7 +50,7 @@ func PersistencePostScore() {
 			if !checkError(err, &waitTime) {
 				continue
 			}
-			downVoteNums, err := redis.GetPostUpVoteNums(postIDs)
+			downVoteNums, err := redis.GetPostDownVoteNums(postIDs)
 			if !checkError(err, &waitTime) {
 				continue
 			}
@@ -68,6 +68,8 @@ func PersistencePostScore() {
 					PostID:      post_id,
 					PostScore:   postScores[i],
 					PostVoteNum: upVoteNums[i] - downVoteNums[i],
+					UpVoteNum:   upVoteNums[i],
+					DownVoteNum: downVoteNums[i],
 				})
 			}
 